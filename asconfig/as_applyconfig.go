@@ -0,0 +1,473 @@
+package asconfig
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+
+	aero "github.com/aerospike/aerospike-client-go/v8"
+	"github.com/aerospike/aerospike-management-lib/deployment"
+)
+
+type (
+	ApplyMode string
+	KeyStatus string
+)
+
+// Enum values for ApplyMode
+const (
+	// ApplySequential applies the config on one node at a time and stops at the first failing node.
+	ApplySequential ApplyMode = "sequential"
+	// ApplyParallel applies the config on all the nodes at the same time.
+	ApplyParallel ApplyMode = "parallel"
+)
+
+// Enum values for KeyStatus
+const (
+	// KeyPending means no command was run for the key.
+	KeyPending KeyStatus = "pending"
+	// KeyVerified means the commands were run and the value read back matches the desired value.
+	KeyVerified KeyStatus = "verified"
+	// KeyApplied means the commands were run, but the value cannot be read back for verification
+	// eg. adding or removing an entry from a list field or a named section.
+	KeyApplied KeyStatus = "applied"
+	// KeyMismatch means the commands were run, but the value read back differs from the desired value.
+	KeyMismatch KeyStatus = "mismatch"
+	// KeyFailed means the server rejected one of the commands of the key.
+	KeyFailed KeyStatus = "failed"
+	// KeyRolledBack means the key was changed and then restored to the snapshot value.
+	KeyRolledBack KeyStatus = "rolled-back"
+	// KeyRollbackFailed means the key was changed, but restoring the snapshot value failed.
+	KeyRollbackFailed KeyStatus = "rollback-failed"
+)
+
+// KeyResult is the outcome of applying a single config key on a node.
+type KeyResult struct {
+	// Previous is the value of the key read from the node before applying the change.
+	Previous interface{}
+	// Desired is the value requested in the DynamicConfigMap.
+	Desired map[OpType]interface{}
+	// Actual is the value read from the node after applying the change.
+	Actual   interface{}
+	Err      error
+	Key      string
+	Status   KeyStatus
+	Commands []string
+	// changed is true if at least one command of the key succeeded on the node.
+	changed bool
+}
+
+// NodeResult is the outcome of applying the config on a single node.
+type NodeResult struct {
+	Err         error
+	RollbackErr error
+	Keys        map[string]*KeyResult
+	HostID      string
+	// Order is the order in which the keys were applied on the node.
+	Order      []string
+	RolledBack bool
+}
+
+// ApplyReport is the per-node, per-key outcome of ApplySetConfig.
+type ApplyReport struct {
+	Nodes      map[string]*NodeResult
+	Succeeded  bool
+	RolledBack bool
+}
+
+// FailedNodes returns the ids of the nodes on which the config could not be applied.
+func (r *ApplyReport) FailedNodes() []string {
+	failed := make([]string, 0)
+
+	for id, node := range r.Nodes {
+		if node.Err != nil {
+			failed = append(failed, id)
+		}
+	}
+
+	return failed
+}
+
+// nodeConfigClient reads config from and runs set-config commands on a single node.
+// It is injected for testability.
+type nodeConfigClient interface {
+	getConfig(paths []string) (map[string]interface{}, error)
+	createCommands(configMap DynamicConfigMap) ([]string, error)
+	runCommands(cmds []string) ([]string, error)
+}
+
+// hostConfigClient is the nodeConfigClient backed by a live aerospike host.
+type hostConfigClient struct {
+	log     logr.Logger
+	policy  *aero.ClientPolicy
+	host    *deployment.HostConn
+	version string
+	// buildFetched is true once the build of the host was fetched for an empty version.
+	buildFetched bool
+}
+
+func (c *hostConfigClient) getConfig(paths []string) (map[string]interface{}, error) {
+	return GetASConfig(paths, c.host.ASConn, c.policy)
+}
+
+func (c *hostConfigClient) createCommands(configMap DynamicConfigMap) ([]string, error) {
+	// The commands are created key by key, so the build is fetched once here
+	// instead of for every key.
+	if c.version == "" && !c.buildFetched {
+		c.buildFetched = true
+
+		if m, err := c.host.ASConn.RunInfo(c.policy, "build"); err == nil {
+			c.version = m["build"]
+		}
+	}
+
+	return CreateSetConfigCmdListWithBuildVersion(c.log, configMap, c.host.ASConn, c.policy, c.version)
+}
+
+func (c *hostConfigClient) runCommands(cmds []string) ([]string, error) {
+	hosts := []*deployment.HostConn{c.host}
+	return deployment.SetConfigCommandsOnHosts(c.log, c.policy, hosts, hosts, cmds)
+}
+
+// ApplySetConfig applies the dynamic config changes in configMap on all the given hosts and verifies them.
+// The values are validated against the config schema of the version before any node is contacted.
+// Before changing anything, the current values of all the keys are read from every node with GetASConfig.
+// After the set-config commands of a key are run, the value is read back and compared with the desired one.
+// If applying or verifying fails on any node, all the nodes that were already changed are rolled back to the
+// values read before the change.
+//
+// The returned report is always non-nil once the snapshot is taken. The error is ErrConfigApply (wrapped)
+// if the change could not be applied on all the nodes.
+func ApplySetConfig(log logr.Logger, policy *aero.ClientPolicy, hosts []*deployment.HostConn,
+	configMap DynamicConfigMap, version string, mode ApplyMode,
+) (*ApplyReport, error) {
+	return defaultRegistry.ApplySetConfig(log, policy, hosts, configMap, version, mode)
+}
+
+// ApplySetConfig is ApplySetConfig using the schemas of the registry to validate configMap.
+func (r *SchemaRegistry) ApplySetConfig(log logr.Logger, policy *aero.ClientPolicy, hosts []*deployment.HostConn,
+	configMap DynamicConfigMap, version string, mode ApplyMode,
+) (*ApplyReport, error) {
+	if err := r.validateDynamicConfigValues(log, configMap, version); err != nil {
+		return nil, err
+	}
+
+	clients := make(map[string]nodeConfigClient, len(hosts))
+	hostIDs := make([]string, 0, len(hosts))
+
+	for _, h := range hosts {
+		clients[h.ID] = &hostConfigClient{
+			log:     log.WithValues("node", h.ID),
+			policy:  policy,
+			host:    h,
+			version: version,
+		}
+		hostIDs = append(hostIDs, h.ID)
+	}
+
+	return applySetConfig(log, hostIDs, clients, configMap, mode)
+}
+
+func applySetConfig(log logr.Logger, hostIDs []string, clients map[string]nodeConfigClient,
+	configMap DynamicConfigMap, mode ApplyMode,
+) (*ApplyReport, error) {
+	if mode != ApplySequential && mode != ApplyParallel {
+		return nil, fmt.Errorf("invalid apply mode: %s", mode)
+	}
+
+	order := rearrangeConfigMap(log, configMap)
+	report := &ApplyReport{
+		Nodes: make(map[string]*NodeResult, len(hostIDs)),
+	}
+
+	// Snapshot all the nodes before touching any of them, so that a node which is not reachable
+	// fails the whole operation upfront.
+	for _, id := range hostIDs {
+		node, err := snapshotNode(clients[id], id, order, configMap)
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot config of node %s: %w", id, err)
+		}
+
+		report.Nodes[id] = node
+	}
+
+	failed := false
+
+	switch mode {
+	case ApplySequential:
+		for _, id := range hostIDs {
+			log.V(1).Info("Applying config", "node", id)
+
+			if err := applyOnNode(clients[id], report.Nodes[id]); err != nil {
+				failed = true
+				break
+			}
+		}
+
+	case ApplyParallel:
+		var (
+			mut sync.Mutex
+			wg  sync.WaitGroup
+		)
+
+		wg.Add(len(hostIDs))
+
+		for _, id := range hostIDs {
+			go func(id string) {
+				defer wg.Done()
+
+				log.V(1).Info("Applying config", "node", id)
+
+				if err := applyOnNode(clients[id], report.Nodes[id]); err != nil {
+					mut.Lock()
+					defer mut.Unlock()
+
+					failed = true
+				}
+			}(id)
+		}
+
+		wg.Wait()
+	}
+
+	if !failed {
+		report.Succeeded = true
+		return report, nil
+	}
+
+	for _, id := range hostIDs {
+		node := report.Nodes[id]
+		if !node.changed() {
+			continue
+		}
+
+		log.Info("Rolling back config", "node", id)
+
+		if err := rollbackNode(clients[id], node); err != nil {
+			log.Error(err, "Failed to rollback config", "node", id)
+		}
+	}
+
+	report.RolledBack = true
+
+	return report, fmt.Errorf("%w: failed on nodes %v", ErrConfigApply, report.FailedNodes())
+}
+
+// snapshotNode reads the current value of all the keys in configMap from the node.
+func snapshotNode(client nodeConfigClient, id string, order []string, configMap DynamicConfigMap,
+) (*NodeResult, error) {
+	node := &NodeResult{
+		HostID: id,
+		Keys:   make(map[string]*KeyResult, len(order)),
+		Order:  order,
+	}
+
+	current, err := client.getConfig(order)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range order {
+		node.Keys[key] = &KeyResult{
+			Key:      key,
+			Desired:  configMap[key],
+			Previous: current[key],
+			Status:   KeyPending,
+		}
+	}
+
+	return node, nil
+}
+
+// applyOnNode runs the set-config commands key by key and verifies every key after all of them are run.
+func applyOnNode(client nodeConfigClient, node *NodeResult) error {
+	for _, key := range node.Order {
+		res := node.Keys[key]
+
+		cmds, err := client.createCommands(DynamicConfigMap{key: res.Desired})
+		if err != nil {
+			res.Status = KeyFailed
+			res.Err = err
+			node.Err = fmt.Errorf("failed to create set-config commands for %s: %w", key, err)
+
+			return node.Err
+		}
+
+		res.Commands = cmds
+
+		succeeded, err := client.runCommands(cmds)
+		// Partially applied key should be rolled back too.
+		res.changed = len(succeeded) > 0
+
+		if err != nil {
+			res.Status = KeyFailed
+			res.Err = err
+			node.Err = fmt.Errorf("failed to apply %s: %w", key, err)
+
+			return node.Err
+		}
+
+		res.changed = true
+		res.Status = KeyApplied
+	}
+
+	actual, err := client.getConfig(node.Order)
+	if err != nil {
+		node.Err = fmt.Errorf("failed to read back config for verification: %w", err)
+		return node.Err
+	}
+
+	mismatched := make([]string, 0)
+
+	for _, key := range node.Order {
+		res := node.Keys[key]
+		res.Actual = actual[key]
+
+		desired, ok := res.Desired[Update]
+		if !ok {
+			// Added or removed list entries and named sections are not verifiable with a simple read back.
+			continue
+		}
+
+		if isSetConfigValueEqual(desired, res.Actual) {
+			res.Status = KeyVerified
+		} else {
+			res.Status = KeyMismatch
+			res.Err = fmt.Errorf("value mismatch: desired %v, actual %v", desired, res.Actual)
+			mismatched = append(mismatched, key)
+		}
+	}
+
+	if len(mismatched) > 0 {
+		node.Err = fmt.Errorf("verification failed for keys %v", mismatched)
+		return node.Err
+	}
+
+	return nil
+}
+
+// rollbackNode restores the snapshot values of all the keys changed on the node in reverse order.
+func rollbackNode(client nodeConfigClient, node *NodeResult) error {
+	var errs []error
+
+	for i := len(node.Order) - 1; i >= 0; i-- {
+		res := node.Keys[node.Order[i]]
+		if !res.changed {
+			continue
+		}
+
+		revert, ok := revertOperation(res)
+		if !ok {
+			res.Status = KeyRollbackFailed
+			errs = append(errs, fmt.Errorf("no previous value of %s to rollback to", res.Key))
+
+			continue
+		}
+
+		cmds, err := client.createCommands(DynamicConfigMap{res.Key: revert})
+		if err == nil {
+			_, err = client.runCommands(cmds)
+		}
+
+		if err != nil {
+			res.Status = KeyRollbackFailed
+			errs = append(errs, fmt.Errorf("failed to rollback %s: %w", res.Key, err))
+
+			continue
+		}
+
+		res.Status = KeyRolledBack
+	}
+
+	node.RolledBack = true
+
+	if len(errs) > 0 {
+		node.RollbackErr = fmt.Errorf("%v", errs)
+		return node.RollbackErr
+	}
+
+	return nil
+}
+
+// revertOperation returns the operations undoing the desired change of the key.
+func revertOperation(res *KeyResult) (map[OpType]interface{}, bool) {
+	revert := make(map[OpType]interface{})
+
+	if _, ok := res.Desired[Update]; ok {
+		if res.Previous == nil {
+			return nil, false
+		}
+
+		revert[Update] = setConfigValue(res.Previous)
+	}
+
+	if v, ok := res.Desired[Add]; ok {
+		revert[Remove] = v
+	}
+
+	if v, ok := res.Desired[Remove]; ok {
+		revert[Add] = v
+	}
+
+	return revert, len(revert) > 0
+}
+
+// setConfigValue converts a value read from the server to a value accepted by
+// the set-config commands. The server returns the list fields as
+// []interface{}, the commands take them as []string.
+func setConfigValue(v interface{}) interface{} {
+	l, ok := v.([]interface{})
+	if !ok {
+		return v
+	}
+
+	values := make([]string, 0, len(l))
+	for _, e := range l {
+		values = append(values, fmt.Sprintf("%v", e))
+	}
+
+	return values
+}
+
+// changed returns true if any command was run on the node.
+func (n *NodeResult) changed() bool {
+	for _, res := range n.Keys {
+		if res.changed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isSetConfigValueEqual compares the desired value of a set-config command
+// with the value read back from the server. The server returns all the values
+// as int64, bool or string so the values are compared in their string form.
+func isSetConfigValueEqual(desired, actual interface{}) bool {
+	desiredStr, err := convertValueToString(map[OpType]interface{}{Update: desired})
+	if err != nil {
+		return reflect.DeepEqual(desired, actual)
+	}
+
+	if len(desiredStr[Update]) == 0 {
+		// An empty list matches a missing or empty value.
+		a := fmt.Sprintf("%v", actual)
+		return actual == nil || a == "" || a == "[]"
+	}
+
+	d := desiredStr[Update][0]
+
+	if actual == nil {
+		return d == "null"
+	}
+
+	a := fmt.Sprintf("%v", actual)
+	if a == "" {
+		a = "null"
+	}
+
+	return strings.EqualFold(d, a)
+}
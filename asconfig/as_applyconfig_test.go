@@ -0,0 +1,187 @@
+package asconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeNodeConfigClient keeps the config of a node in memory and applies
+// "set-config:context=service;<key>=<value>" commands to it.
+type fakeNodeConfigClient struct {
+	conf    map[string]interface{}
+	failCmd string
+	// ignoreCmd is accepted by the fake server but the value does not change.
+	ignoreCmd string
+	cmds      []string
+}
+
+func (c *fakeNodeConfigClient) getConfig(paths []string) (map[string]interface{}, error) {
+	res := make(map[string]interface{}, len(paths))
+	for _, p := range paths {
+		res[p] = c.conf[p]
+	}
+
+	return res, nil
+}
+
+func (c *fakeNodeConfigClient) createCommands(configMap DynamicConfigMap) ([]string, error) {
+	return CreateSetConfigCmdList(logr.Discard(), configMap, nil, nil)
+}
+
+func (c *fakeNodeConfigClient) runCommands(cmds []string) ([]string, error) {
+	succeeded := make([]string, 0, len(cmds))
+
+	for _, cmd := range cmds {
+		if cmd == c.failCmd {
+			return succeeded, errors.New("ServerError: failed to execute set-config")
+		}
+
+		c.cmds = append(c.cmds, cmd)
+
+		if cmd != c.ignoreCmd {
+			kv := strings.SplitN(strings.TrimPrefix(cmd, cmdSetConfigService), equal, 2)
+			c.conf["service."+kv[0]] = kv[1]
+		}
+
+		succeeded = append(succeeded, cmd)
+	}
+
+	return succeeded, nil
+}
+
+type AsApplyConfigTestSuite struct {
+	suite.Suite
+	clients map[string]*fakeNodeConfigClient
+	hostIDs []string
+}
+
+func (s *AsApplyConfigTestSuite) SetupTest() {
+	s.hostIDs = []string{"A1", "B1", "C1"}
+	s.clients = make(map[string]*fakeNodeConfigClient)
+
+	for _, id := range s.hostIDs {
+		s.clients[id] = &fakeNodeConfigClient{
+			conf: map[string]interface{}{
+				"service.proto-fd-max":    int64(15000),
+				"service.migrate-threads": int64(1),
+			},
+		}
+	}
+}
+
+func (s *AsApplyConfigTestSuite) apply(mode ApplyMode) (*ApplyReport, error) {
+	clients := make(map[string]nodeConfigClient, len(s.clients))
+	for id, c := range s.clients {
+		clients[id] = c
+	}
+
+	configMap := DynamicConfigMap{
+		"service.proto-fd-max":    {Update: uint64(20000)},
+		"service.migrate-threads": {Update: uint64(2)},
+	}
+
+	return applySetConfig(logr.Discard(), s.hostIDs, clients, configMap, mode)
+}
+
+func (s *AsApplyConfigTestSuite) TestApplySuccess() {
+	for _, mode := range []ApplyMode{ApplySequential, ApplyParallel} {
+		s.Run(string(mode), func() {
+			s.SetupTest()
+
+			report, err := s.apply(mode)
+			s.Require().NoError(err)
+			s.Assert().True(report.Succeeded)
+			s.Assert().False(report.RolledBack)
+
+			for _, id := range s.hostIDs {
+				node := report.Nodes[id]
+				s.Assert().NoError(node.Err)
+				s.Assert().Equal(KeyVerified, node.Keys["service.proto-fd-max"].Status)
+				s.Assert().Equal(int64(15000), node.Keys["service.proto-fd-max"].Previous)
+				s.Assert().Equal("20000", s.clients[id].conf["service.proto-fd-max"])
+			}
+		})
+	}
+}
+
+func (s *AsApplyConfigTestSuite) TestApplyFailureRollsBack() {
+	s.clients["B1"].failCmd = "set-config:context=service;proto-fd-max=20000"
+
+	report, err := s.apply(ApplySequential)
+	s.Require().ErrorIs(err, ErrConfigApply)
+	s.Assert().False(report.Succeeded)
+	s.Assert().True(report.RolledBack)
+	s.Assert().Equal([]string{"B1"}, report.FailedNodes())
+
+	// The keys are applied in sorted order.
+	s.Assert().Equal([]string{"service.migrate-threads", "service.proto-fd-max"}, report.Nodes["A1"].Order)
+
+	// A1 was fully applied and then rolled back.
+	s.Assert().True(report.Nodes["A1"].RolledBack)
+	s.Assert().Equal(KeyRolledBack, report.Nodes["A1"].Keys["service.migrate-threads"].Status)
+	s.Assert().Equal(KeyRolledBack, report.Nodes["A1"].Keys["service.proto-fd-max"].Status)
+	s.Assert().Equal("15000", s.clients["A1"].conf["service.proto-fd-max"])
+	s.Assert().Equal("1", s.clients["A1"].conf["service.migrate-threads"])
+
+	// B1 was partially applied and then rolled back.
+	s.Assert().True(report.Nodes["B1"].RolledBack)
+	s.Assert().Equal(KeyRolledBack, report.Nodes["B1"].Keys["service.migrate-threads"].Status)
+	s.Assert().Equal(KeyFailed, report.Nodes["B1"].Keys["service.proto-fd-max"].Status)
+	s.Assert().Equal("1", s.clients["B1"].conf["service.migrate-threads"])
+	s.Assert().Equal(int64(15000), s.clients["B1"].conf["service.proto-fd-max"])
+
+	// C1 was never touched.
+	s.Assert().False(report.Nodes["C1"].RolledBack)
+	s.Assert().Empty(s.clients["C1"].cmds)
+	s.Assert().Equal(KeyPending, report.Nodes["C1"].Keys["service.proto-fd-max"].Status)
+}
+
+func (s *AsApplyConfigTestSuite) TestRollbackList() {
+	client := s.clients["A1"]
+	node := &NodeResult{
+		Order: []string{"service.feature-key-files"},
+		Keys: map[string]*KeyResult{
+			"service.feature-key-files": {
+				Key:      "service.feature-key-files",
+				Desired:  map[OpType]interface{}{Update: []string{"/etc/aerospike/f2.conf"}},
+				Previous: []interface{}{"/etc/aerospike/f1.conf"},
+				changed:  true,
+			},
+		},
+	}
+
+	s.Require().NoError(rollbackNode(client, node))
+	s.Assert().Equal(KeyRolledBack, node.Keys["service.feature-key-files"].Status)
+	s.Assert().Equal([]string{"set-config:context=service;feature-key-files=/etc/aerospike/f1.conf"}, client.cmds)
+}
+
+func (s *AsApplyConfigTestSuite) TestIsSetConfigValueEqual() {
+	s.Assert().True(isSetConfigValueEqual(uint64(20000), int64(20000)))
+	s.Assert().True(isSetConfigValueEqual("", nil))
+	s.Assert().True(isSetConfigValueEqual([]string{}, nil))
+	s.Assert().True(isSetConfigValueEqual([]string{}, ""))
+	s.Assert().False(isSetConfigValueEqual([]string{}, "/etc/aerospike/f1.conf"))
+}
+
+func (s *AsApplyConfigTestSuite) TestApplyVerificationMismatch() {
+	s.clients["C1"].ignoreCmd = "set-config:context=service;proto-fd-max=20000"
+
+	report, err := s.apply(ApplyParallel)
+	s.Require().ErrorIs(err, ErrConfigApply)
+	s.Assert().Equal([]string{"C1"}, report.FailedNodes())
+	s.Assert().Equal(KeyRolledBack, report.Nodes["C1"].Keys["service.proto-fd-max"].Status)
+
+	for _, id := range s.hostIDs {
+		s.Assert().True(report.Nodes[id].RolledBack, fmt.Sprintf("node %s not rolled back", id))
+		s.Assert().Equal("1", s.clients[id].conf["service.migrate-threads"])
+	}
+}
+
+func TestAsApplyConfigTestSuite(t *testing.T) {
+	suite.Run(t, new(AsApplyConfigTestSuite))
+}
@@ -3,6 +3,7 @@ package asconfig
 import (
 	"container/list"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/go-logr/logr"
@@ -415,7 +416,16 @@ func rearrangeConfigMap(log logr.Logger, configMap DynamicConfigMap) []string {
 		lastDCConfig *list.Element // Last DC config eg. node-address-ports
 	)
 
-	for k, v := range configMap {
+	// The keys are sorted so that the same configMap is always applied in the same order.
+	keys := make([]string, 0, len(configMap))
+	for k := range configMap {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := configMap[k]
 		baseKey := BaseKey(k)
 		context := ContextKey(k)
 		tokens := SplitKey(log, k, sep)
@@ -522,6 +532,13 @@ func (p ConfigOperation) Validate() error {
 
 func CreateConfigSetCmdsUsingPatch(
 	configMap map[string]interface{}, conn *deployment.ASConn, aerospikePolicy *aero.ClientPolicy, version string,
+) ([]string, error) {
+	return defaultRegistry.CreateConfigSetCmdsUsingPatch(configMap, conn, aerospikePolicy, version)
+}
+
+// CreateConfigSetCmdsUsingPatch is CreateConfigSetCmdsUsingPatch using the schemas of the registry.
+func (r *SchemaRegistry) CreateConfigSetCmdsUsingPatch(
+	configMap map[string]interface{}, conn *deployment.ASConn, aerospikePolicy *aero.ClientPolicy, version string,
 ) ([]string, error) {
 	conf, err := NewMapAsConfig(conn.Log, configMap)
 	if err != nil {
@@ -548,7 +565,7 @@ func CreateConfigSetCmdsUsingPatch(
 		asConfChange[k] = valueMap
 	}
 
-	isDynamic, err := IsAllDynamicConfig(conn.Log, asConfChange, version)
+	isDynamic, err := r.IsAllDynamicConfig(conn.Log, asConfChange, version)
 	if err != nil {
 		return nil, err
 	}
@@ -557,12 +574,46 @@ func CreateConfigSetCmdsUsingPatch(
 		return nil, fmt.Errorf("static field has been changed, cannot change config dynamically")
 	}
 
+	if err := r.validateDynamicConfigValues(conn.Log, asConfChange, version); err != nil {
+		return nil, err
+	}
+
 	return CreateSetConfigCmdListWithBuildVersion(logr.Logger{}, asConfChange, conn, aerospikePolicy, version)
 }
 
 func CreateConfigSetCmdsUsingOperation(
 	confOp ConfigOperation, conn *deployment.ASConn, aerospikePolicy *aero.ClientPolicy, version string,
 ) ([]string, error) {
+	return defaultRegistry.CreateConfigSetCmdsUsingOperation(confOp, conn, aerospikePolicy, version)
+}
+
+// CreateConfigSetCmdsUsingOperation is CreateConfigSetCmdsUsingOperation using the schemas of the registry.
+func (r *SchemaRegistry) CreateConfigSetCmdsUsingOperation(
+	confOp ConfigOperation, conn *deployment.ASConn, aerospikePolicy *aero.ClientPolicy, version string,
+) ([]string, error) {
+	asConfChange, err := confOpToDynamicConfigMap(confOp)
+	if err != nil {
+		return nil, err
+	}
+
+	isDynamic, err := r.IsAllDynamicConfig(conn.Log, asConfChange, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isDynamic {
+		return nil, fmt.Errorf("static field has been changed, cannot change config dynamically")
+	}
+
+	if err := r.validateDynamicConfigValues(conn.Log, asConfChange, version); err != nil {
+		return nil, err
+	}
+
+	return CreateSetConfigCmdListWithBuildVersion(logr.Logger{}, asConfChange, conn, aerospikePolicy, version)
+}
+
+// confOpToDynamicConfigMap converts a ConfigOperation to a DynamicConfigMap with a single key.
+func confOpToDynamicConfigMap(confOp ConfigOperation) (DynamicConfigMap, error) {
 	if err := confOp.Validate(); err != nil {
 		return nil, err
 	}
@@ -585,14 +636,22 @@ func CreateConfigSetCmdsUsingOperation(
 	valueMap[confOp.Operation] = value
 	asConfChange[path] = valueMap
 
-	isDynamic, err := IsAllDynamicConfig(conn.Log, asConfChange, version)
+	return asConfChange, nil
+}
+
+// validateDynamicConfigValues returns ErrConfigSchema if any value in configMap
+// is not valid according to the config schema of the version.
+func (r *SchemaRegistry) validateDynamicConfigValues(
+	log logr.Logger, configMap DynamicConfigMap, version string,
+) error {
+	vErrs, err := r.ValidateDynamicConfigMap(log, configMap, version)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	if !isDynamic {
-		return nil, fmt.Errorf("static field has been changed, cannot change config dynamically")
+	if len(vErrs) > 0 {
+		return validationErrsToError(vErrs)
 	}
 
-	return CreateSetConfigCmdListWithBuildVersion(logr.Logger{}, asConfChange, conn, aerospikePolicy, version)
+	return nil
 }
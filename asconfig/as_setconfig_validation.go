@@ -0,0 +1,427 @@
+package asconfig
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// JSON schema keywords used for value validation
+const (
+	schemaKeyType    = "type"
+	schemaKeyMinimum = "minimum"
+	schemaKeyMaximum = "maximum"
+	schemaKeyEnum    = "enum"
+	schemaKeyPattern = "pattern"
+
+	schemaTypeInteger = "integer"
+	schemaTypeNumber  = "number"
+	schemaTypeString  = "string"
+	schemaTypeBoolean = "boolean"
+	schemaTypeArray   = "array"
+	schemaTypeObject  = "object"
+)
+
+// Validation error types, these match the error types reported by gojsonschema.
+const (
+	errTypeInvalidType  = "invalid_type"
+	errTypeNumberGTE    = "number_gte"
+	errTypeNumberLTE    = "number_lte"
+	errTypeEnum         = "enum"
+	errTypePattern      = "pattern"
+	errTypeAdditionalKV = "additional_property_not_allowed"
+)
+
+// valueConstraint holds the value related keywords of a single schema
+// definition of a config key.
+type valueConstraint struct {
+	minimum *float64
+	maximum *float64
+	pattern *regexp.Regexp
+	typ     string
+	enum    []string
+}
+
+// valueConstraints maps normalized flat keys eg. namespaces._.replication-factor
+// to all the schema definitions of the key. A key has more than one definition
+// when it is defined under a "oneOf" or "anyOf", eg. storage-engine fields.
+type valueConstraints map[string][]*valueConstraint
+
+var constraintKeywordRe = regexp.MustCompile(
+	`^(.*)\.(` + schemaKeyType + `|` + schemaKeyMinimum + `|` + schemaKeyMaximum + `|` + schemaKeyEnum + `|` +
+		schemaKeyPattern + `)$`,
+)
+
+// getValueConstraints collects type, minimum, maximum, enum and pattern
+// keywords of all the keys in the flat schema.
+func getValueConstraints(flatSchema map[string]interface{}) valueConstraints {
+	// Group keywords by their un-normalized schema path so that keywords
+	// of different oneOf/anyOf branches do not mix.
+	byPath := make(map[string]*valueConstraint)
+
+	for _, k := range sortKeys(flatSchema) {
+		match := constraintKeywordRe.FindStringSubmatch(k)
+		if match == nil {
+			continue
+		}
+
+		path, keyword := match[1], match[2]
+
+		c, ok := byPath[path]
+		if !ok {
+			c = &valueConstraint{}
+			byPath[path] = c
+		}
+
+		v := flatSchema[k]
+
+		switch keyword {
+		case schemaKeyType:
+			c.typ, _ = v.(string)
+		case schemaKeyMinimum:
+			if f, ok := toFloat64(v); ok {
+				c.minimum = &f
+			}
+		case schemaKeyMaximum:
+			if f, ok := toFloat64(v); ok {
+				c.maximum = &f
+			}
+		case schemaKeyEnum:
+			if l, ok := v.([]interface{}); ok {
+				for _, e := range l {
+					c.enum = append(c.enum, fmt.Sprintf("%v", e))
+				}
+			}
+		case schemaKeyPattern:
+			if p, ok := v.(string); ok {
+				if re, err := regexp.Compile(p); err == nil {
+					c.pattern = re
+				}
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(byPath))
+	for path := range byPath {
+		paths = append(paths, path)
+	}
+
+	sort.Strings(paths)
+
+	res := make(valueConstraints, len(byPath))
+
+	for _, path := range paths {
+		key := removeJSONSpecKeywords(path)
+		res[key] = append(res[key], byPath[path])
+	}
+
+	return res
+}
+
+// getValueConstraintsForVersion returns the value constraints of all the keys
+// in the config schema of the version. The returned constraints are cached
+// and shared by all the callers, they must not be modified.
+func (r *SchemaRegistry) getValueConstraintsForVersion(ver string) (valueConstraints, error) {
+	derived, err := r.getDerivedSchema(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	return derived.constraints, nil
+}
+
+// ValidateDynamicConfigMap validates the values in configMap against the type,
+// minimum, maximum, enum and pattern of the corresponding keys in the config
+// schema of the version. Values of Remove operations are not validated.
+// It returns nil if all the values are valid.
+func ValidateDynamicConfigMap(log logr.Logger, configMap DynamicConfigMap, version string) (
+	[]*ValidationErr, error,
+) {
+	return defaultRegistry.ValidateDynamicConfigMap(log, configMap, version)
+}
+
+// ValidateDynamicConfigMap validates the values in configMap against the type,
+// minimum, maximum, enum and pattern of the corresponding keys in the config
+// schema of the version. Values of Remove operations are not validated.
+// It returns nil if all the values are valid.
+func (r *SchemaRegistry) ValidateDynamicConfigMap(log logr.Logger, configMap DynamicConfigMap, version string) (
+	[]*ValidationErr, error,
+) {
+	constraints, err := r.getValueConstraintsForVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	return constraints.validateDynamicConfigMap(log, configMap), nil
+}
+
+// ValidateConfigOperation validates the value of confOp against the config
+// schema of the version. It returns nil if the value is valid.
+func ValidateConfigOperation(log logr.Logger, confOp ConfigOperation, version string) (
+	[]*ValidationErr, error,
+) {
+	return defaultRegistry.ValidateConfigOperation(log, confOp, version)
+}
+
+// ValidateConfigOperation validates the value of confOp against the config
+// schema of the version. It returns nil if the value is valid.
+func (r *SchemaRegistry) ValidateConfigOperation(log logr.Logger, confOp ConfigOperation, version string) (
+	[]*ValidationErr, error,
+) {
+	configMap, err := confOpToDynamicConfigMap(confOp)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ValidateDynamicConfigMap(log, configMap, version)
+}
+
+func (vc valueConstraints) validateDynamicConfigMap(log logr.Logger, configMap DynamicConfigMap) []*ValidationErr {
+	var vErrs []*ValidationErr
+
+	keys := make([]string, 0, len(configMap))
+	for k := range configMap {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, op := range []OpType{Update, Add} {
+			value, ok := configMap[key][op]
+			if !ok {
+				continue
+			}
+
+			vErrs = append(vErrs, vc.validateValue(log, key, value)...)
+		}
+	}
+
+	return vErrs
+}
+
+// validateValue validates value of the flat config key eg. namespaces.{test}.replication-factor.
+func (vc valueConstraints) validateValue(log logr.Logger, key string, value interface{}) []*ValidationErr {
+	tokens := SplitKey(log, key, sep)
+	flatKey := GetFlatKey(tokens)
+	context, _ := splitContextBaseKey(key)
+
+	defs, ok := vc[flatKey]
+	if !ok {
+		return []*ValidationErr{{
+			ErrType:     errTypeAdditionalKV,
+			Context:     context,
+			Description: fmt.Sprintf("Additional property %s is not allowed", BaseKey(key)),
+			Field:       key,
+			Value:       value,
+		}}
+	}
+
+	var firstErr *ValidationErr
+
+	for _, def := range defs {
+		var vErr *ValidationErr
+
+		if def.typ == schemaTypeArray {
+			vErr = vc.validateArray(key, flatKey, value)
+		} else {
+			vErr = def.validate(key, value)
+		}
+
+		if vErr == nil {
+			// Valid against one of the definitions is enough.
+			return nil
+		}
+
+		if firstErr == nil {
+			firstErr = vErr
+		}
+	}
+
+	if firstErr == nil {
+		return nil
+	}
+
+	firstErr.Context = context
+
+	return []*ValidationErr{firstErr}
+}
+
+// validateArray validates each entry of a list field against the "items" definition of the key.
+func (vc valueConstraints) validateArray(key, flatKey string, value interface{}) *ValidationErr {
+	items, ok := vc[flatKey+sep+"_"]
+	if !ok {
+		return nil
+	}
+
+	var values []string
+
+	switch v := value.(type) {
+	case []string:
+		values = v
+	case string:
+		values = []string{v}
+	default:
+		return &ValidationErr{
+			ErrType:     errTypeInvalidType,
+			Description: fmt.Sprintf("Invalid type. Expected: %s, given: %T", schemaTypeArray, value),
+			Field:       key,
+			Value:       value,
+		}
+	}
+
+	for _, v := range values {
+		var firstErr *ValidationErr
+
+		for _, item := range items {
+			vErr := item.validate(key, v)
+			if vErr == nil {
+				firstErr = nil
+				break
+			}
+
+			if firstErr == nil {
+				firstErr = vErr
+			}
+		}
+
+		if firstErr != nil {
+			return firstErr
+		}
+	}
+
+	return nil
+}
+
+// validate validates a scalar value against the definition.
+func (c *valueConstraint) validate(key string, value interface{}) *ValidationErr {
+	newErr := func(errType, desc string) *ValidationErr {
+		return &ValidationErr{
+			ErrType:     errType,
+			Description: desc,
+			Field:       key,
+			Value:       value,
+		}
+	}
+
+	switch c.typ {
+	case schemaTypeInteger, schemaTypeNumber:
+		n, ok := toNumber(BaseKey(key), value, c.typ == schemaTypeInteger)
+		if !ok {
+			return newErr(errTypeInvalidType, fmt.Sprintf("Invalid type. Expected: %s, given: %v", c.typ, value))
+		}
+
+		if c.minimum != nil && n < *c.minimum {
+			return newErr(errTypeNumberGTE, fmt.Sprintf("Must be greater than or equal to %v", *c.minimum))
+		}
+
+		if c.maximum != nil && n > *c.maximum {
+			return newErr(errTypeNumberLTE, fmt.Sprintf("Must be less than or equal to %v", *c.maximum))
+		}
+
+	case schemaTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+		case string:
+			if _, err := strconv.ParseBool(v); err != nil {
+				return newErr(errTypeInvalidType, fmt.Sprintf("Invalid type. Expected: %s, given: %v", c.typ, value))
+			}
+		default:
+			return newErr(errTypeInvalidType, fmt.Sprintf("Invalid type. Expected: %s, given: %T", c.typ, value))
+		}
+
+	case schemaTypeString:
+		str, ok := value.(string)
+		if !ok {
+			return newErr(errTypeInvalidType, fmt.Sprintf("Invalid type. Expected: %s, given: %T", c.typ, value))
+		}
+
+		if c.pattern != nil && !c.pattern.MatchString(str) {
+			return newErr(errTypePattern, fmt.Sprintf("Does not match pattern '%s'", c.pattern.String()))
+		}
+
+	case schemaTypeObject, schemaTypeArray:
+		return newErr(errTypeInvalidType, fmt.Sprintf("Invalid type. Expected: %s, given: %T", c.typ, value))
+	}
+
+	if len(c.enum) > 0 {
+		str := fmt.Sprintf("%v", value)
+
+		for _, e := range c.enum {
+			if e == str {
+				return nil
+			}
+		}
+
+		return newErr(errTypeEnum, fmt.Sprintf("Must be one of the following: %s", strings.Join(c.enum, ", ")))
+	}
+
+	return nil
+}
+
+// validationErrsToError converts validation errors to a single ErrConfigSchema error.
+func validationErrsToError(vErrs []*ValidationErr) error {
+	descs := make([]string, 0, len(vErrs))
+	for _, vErr := range vErrs {
+		descs = append(descs, fmt.Sprintf("%s: %s (value %v)", vErr.Field, vErr.Description, vErr.Value))
+	}
+
+	return fmt.Errorf("%w: %s", ErrConfigSchema, strings.Join(descs, "; "))
+}
+
+// toNumber converts value to float64. String values are parsed, including
+// humanized size and time values eg. 4G or 1h.
+func toNumber(baseKey string, value interface{}, integer bool) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		if integer && v != float64(int64(v)) {
+			return 0, false
+		}
+
+		return v, true
+	case string:
+		if ok, humanizeFn := isSizeOrTime(baseKey); ok {
+			n, err := humanizeFn(v)
+			if err == nil {
+				return float64(n), true
+			}
+		}
+
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return float64(n), true
+		}
+
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+			return float64(n), true
+		}
+
+		if integer {
+			return 0, false
+		}
+
+		n, err := strconv.ParseFloat(v, 64)
+
+		return n, err == nil
+	default:
+		return toFloat64(value)
+	}
+}
+
+func toFloat64(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,112 @@
+package asconfig
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type SetConfigValidationTestSuite struct {
+	suite.Suite
+}
+
+func (s *SetConfigValidationTestSuite) SetupSuite() {
+	InitFromMap(logr.Discard(), testSchemas)
+}
+
+func (s *SetConfigValidationTestSuite) TestValidateDynamicConfigMap() {
+	testCases := []struct {
+		name      string
+		configMap DynamicConfigMap
+		errTypes  []string
+	}{
+		{
+			"valid",
+			DynamicConfigMap{
+				"service.proto-fd-max":                                  {Update: uint64(20000)},
+				"namespaces.{test}.conflict-resolution-policy":          {Update: "last-update-time"},
+				"namespaces.{test}.default-ttl":                         {Update: "1d"},
+				"namespaces.{test}.storage-engine.stop-writes-used-pct": {Update: "60"},
+				"namespaces.{test}.sets.{s1}.disable-eviction":          {Update: "true"},
+				"security.log.report-data-op":                           {Add: []string{"ns1:set1"}, Remove: []string{"ns2:set2"}},
+				"xdr.dcs.{DC1}.namespaces.{ns1}.bin-policy":             {Update: "no-bins"},
+				"xdr.dcs.{DC1}.name":                                    {Add: "DC1"},
+			},
+			nil,
+		},
+		{
+			"negative migrate-threads",
+			DynamicConfigMap{"service.migrate-threads": {Update: int64(-1)}},
+			[]string{errTypeNumberGTE},
+		},
+		{
+			"above maximum",
+			DynamicConfigMap{"namespaces.{test}.storage-engine.evict-used-pct": {Update: uint64(101)}},
+			[]string{errTypeNumberLTE},
+		},
+		{
+			"invalid enum",
+			DynamicConfigMap{"xdr.dcs.{DC1}.namespaces.{ns1}.bin-policy": {Update: "some-bins"}},
+			[]string{errTypeEnum},
+		},
+		{
+			"invalid type",
+			DynamicConfigMap{
+				"service.proto-fd-max":   {Update: "many"},
+				"service.advertise-ipv6": {Update: "yes"},
+			},
+			[]string{errTypeInvalidType, errTypeInvalidType},
+		},
+		{
+			"unknown key",
+			DynamicConfigMap{"service.proto-fd-min": {Update: uint64(1)}},
+			[]string{errTypeAdditionalKV},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			vErrs, err := ValidateDynamicConfigMap(logr.Discard(), tc.configMap, "7.0.0")
+			s.Require().NoError(err)
+
+			errTypes := make([]string, 0, len(vErrs))
+			for _, vErr := range vErrs {
+				errTypes = append(errTypes, vErr.ErrType)
+			}
+
+			if tc.errTypes == nil {
+				s.Assert().Empty(vErrs)
+			} else {
+				s.Assert().Equal(tc.errTypes, errTypes)
+			}
+		})
+	}
+}
+
+func (s *SetConfigValidationTestSuite) TestValidateConfigOperation() {
+	vErrs, err := ValidateConfigOperation(logr.Discard(), ConfigOperation{
+		Operation: Update,
+		Context:   "namespaces.{test}",
+		Config:    "default-ttl",
+		Value:     "-5",
+	}, "7.0.0")
+	s.Require().NoError(err)
+	s.Require().Len(vErrs, 1)
+	s.Assert().Equal(errTypeNumberGTE, vErrs[0].ErrType)
+	s.Assert().Equal("namespaces.{test}.default-ttl", vErrs[0].Field)
+	s.Assert().Equal("namespaces.{test}", vErrs[0].Context)
+
+	vErrs, err = ValidateConfigOperation(logr.Discard(), ConfigOperation{
+		Operation: Add,
+		Context:   "xdr.dcs",
+		Config:    KeyName,
+		Value:     "DC2",
+	}, "7.0.0")
+	s.Require().NoError(err)
+	s.Assert().Empty(vErrs)
+}
+
+func TestSetConfigValidationTestSuite(t *testing.T) {
+	suite.Run(t, new(SetConfigValidationTestSuite))
+}
@@ -1,7 +1,8 @@
 package asconfig
 
 import (
-	"bufio"
+	"errors"
+	"fmt"
 	"io"
 
 	"github.com/go-logr/logr"
@@ -10,7 +11,10 @@ import (
 // AsConfig is wrapper over Conf
 type AsConfig struct {
 	baseConf *Conf
-	log      logr.Logger
+	// positions are the positions of the keys in the aerospike.conf the
+	// config was read from, nil for configs not read from a conf file.
+	positions map[string]Position
+	log       logr.Logger
 }
 
 func New(log logr.Logger, bconf *Conf) *AsConfig {
@@ -22,11 +26,18 @@ func New(log logr.Logger, bconf *Conf) *AsConfig {
 
 // ValidationErr represents version validation error
 type ValidationErr struct {
-	Value       interface{}
+	Value interface{}
+	// Position is the position of Field in the aerospike.conf the config was
+	// read from, nil if the config was not read from a conf file.
+	Position    *Position
 	ErrType     string
 	Context     string
 	Description string
 	Field       string
+	// Ref is the missing key referenced by Field, for the errors of
+	// ConfReferencesValid, eg. "network.tls.{tls1}", or the key conflicting
+	// with Field, for the errors of ConfNetworkValid.
+	Ref string
 }
 
 // NewMapAsConfig creates AsConfig. Typically, an unmarshalled yaml file is passed in
@@ -53,7 +64,91 @@ func newMap(log logr.Logger, configMap map[string]interface{}) (Conf, error) {
 func (cfg *AsConfig) IsValid(log logr.Logger, version string) (
 	bool, []*ValidationErr, error,
 ) {
-	return confIsValid(log, cfg.baseConf, version)
+	return defaultRegistry.IsValid(log, cfg, version)
+}
+
+// IsValid checks validity of config against the schema of the version in the
+// registry. It does not check what the schema cannot, eg. the references
+// between the sections or the network section, see Validate.
+func (r *SchemaRegistry) IsValid(log logr.Logger, cfg *AsConfig, version string) (
+	bool, []*ValidationErr, error,
+) {
+	valid, vErrs, err := r.confIsValid(log, cfg.baseConf, version)
+
+	for _, vErr := range vErrs {
+		flatKey := schemaFieldToFlatKey(*cfg.baseConf, vErr.Field)
+		if pos, ok := cfg.Position(flatKey); ok {
+			vErr.Position = &pos
+		}
+	}
+
+	return valid, vErrs, err
+}
+
+// Validate checks the config against the schema of the version, see IsValid,
+// the references between its sections, see ReferencesValid, and its network
+// section, see NetworkValid.
+func (cfg *AsConfig) Validate(log logr.Logger, version string) (
+	bool, []*ValidationErr, error,
+) {
+	return defaultRegistry.Validate(log, cfg, version)
+}
+
+// Validate checks the config against the schema of the version in the
+// registry, the references between its sections and its network section. The
+// errors of all the checks are returned, the error is the one of the first
+// failing check: ErrConfigSchema, ErrConfigCrossReference, then
+// ErrConfigNetwork.
+func (r *SchemaRegistry) Validate(log logr.Logger, cfg *AsConfig, version string) (
+	bool, []*ValidationErr, error,
+) {
+	valid, vErrs, err := r.IsValid(log, cfg, version)
+	if err != nil && !errors.Is(err, ErrConfigSchema) {
+		return false, vErrs, err
+	}
+
+	checks := []func() (bool, []*ValidationErr, error){
+		cfg.ReferencesValid,
+		func() (bool, []*ValidationErr, error) {
+			return cfg.NetworkValid(log)
+		},
+	}
+
+	for _, check := range checks {
+		checkValid, checkErrs, checkErr := check()
+		if checkValid {
+			continue
+		}
+
+		valid = false
+		vErrs = append(vErrs, checkErrs...)
+
+		if err == nil {
+			err = checkErr
+		}
+	}
+
+	return valid, vErrs, err
+}
+
+// Describe returns the validation error as a message, prefixed by the
+// file:line:column of the field and followed by the source line if the
+// position of the field is known.
+func (e *ValidationErr) Describe() string {
+	msg := fmt.Sprintf("%s: %s (value %v)", e.Field, e.Description, e.Value)
+	if e.Position == nil {
+		return msg
+	}
+
+	return e.Position.Describe(msg)
+}
+
+// Position returns the position in the aerospike.conf the config was read
+// from of the flat key, eg. "namespaces.{test}.replication-factor". For keys
+// not in the file, eg. defaults, the position of the closest enclosing
+// section is returned. ok is false if the config was not read from a conf file.
+func (cfg *AsConfig) Position(flatKey string) (pos Position, ok bool) {
+	return positionOf(cfg.log, cfg.positions, flatKey)
 }
 
 // ToConfFile returns DotConf
@@ -80,14 +175,28 @@ func (cfg *AsConfig) GetFlatMap() *Conf {
 
 // FromConfFile unmarshales the aerospike config text in "in" into a new *AsConfig
 func FromConfFile(log logr.Logger, in io.Reader) (*AsConfig, error) {
-	scanner := bufio.NewScanner(in)
+	return FromNamedConfFile(log, "", in)
+}
+
+// FromNamedConfFile unmarshales the aerospike config text in "in" read from
+// the file fileName into a new *AsConfig. fileName is only used in the
+// positions of parse errors, a *ConfParseError, and of validation errors.
+func FromNamedConfFile(log logr.Logger, fileName string, in io.Reader) (*AsConfig, error) {
+	scanner := newConfScanner(fileName, in)
 
 	configMap, err := process(log, scanner, Conf{})
 	if err != nil {
 		return nil, err
 	}
 
-	return NewMapAsConfig(log, configMap)
+	cfg, err := NewMapAsConfig(log, configMap)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.positions = scanner.positions
+
+	return cfg, nil
 }
 
 // IsSupportedVersion returns true if version supported else false
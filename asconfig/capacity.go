@@ -0,0 +1,341 @@
+package asconfig
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+
+	"github.com/aerospike/aerospike-management-lib/info"
+)
+
+// primaryIndexEntryBytes is the size of a record in the primary index.
+const primaryIndexEntryBytes = 64
+
+// Defaults of the server for the keys missing in the config.
+const (
+	defaultStopWritesUsedPct = 70
+	defaultStopWritesPct     = 90
+	defaultReplicationFactor = 2
+)
+
+// CapacityOptions are the inputs of PlanCapacity besides the config.
+type CapacityOptions struct {
+	// Stats are the statistics of the live nodes, as returned by
+	// AsInfo.GetAsInfo("statistics"), one per node. Optional. If fewer nodes
+	// than NodeCount have the stats of a namespace, its objects are scaled up
+	// as if the other nodes had the same objects.
+	Stats []info.NodeAsStats
+	// Objects are the expected master objects of the whole cluster by
+	// namespace. They override the objects of Stats.
+	Objects map[string]uint64
+	// RecordSize are the expected average bytes of a record on storage by
+	// namespace. They override the record size of Stats.
+	RecordSize map[string]uint64
+	// DeviceSize is the bytes of a storage device, as the config does not
+	// have it. It overrides the storage size of Stats.
+	DeviceSize uint64
+	// SystemMemory is the bytes of memory of a node, for the
+	// stop-writes-sys-memory-pct of the namespaces from 7.0. Optional.
+	SystemMemory uint64
+	// NodeCount is the number of nodes of the cluster, the number of Stats
+	// if not set.
+	NodeCount int
+}
+
+// ResourceCapacity is the usage of a resource of a node, eg. the memory of
+// the indexes, with its thresholds. A threshold of 0 is disabled or unknown.
+type ResourceCapacity struct {
+	Used uint64
+	// Total is the budget of the resource, 0 if unknown or unlimited.
+	Total uint64
+	// StopWrites is the usage at which the node stops the writes.
+	StopWrites uint64
+	// Evict is the usage at which the node evicts records.
+	Evict uint64
+}
+
+// StopWritesHeadroom returns the bytes which can still be used before the
+// node stops the writes, negative if the writes are stopped. ok is false if
+// there is no stop-writes threshold.
+func (rc *ResourceCapacity) StopWritesHeadroom() (headroom int64, ok bool) {
+	return headroomTo(rc.StopWrites, rc.Used)
+}
+
+// EvictHeadroom returns the bytes which can still be used before the node
+// evicts records, negative if it evicts. ok is false if eviction is disabled.
+func (rc *ResourceCapacity) EvictHeadroom() (headroom int64, ok bool) {
+	return headroomTo(rc.Evict, rc.Used)
+}
+
+func headroomTo(threshold, used uint64) (headroom int64, ok bool) {
+	if threshold == 0 {
+		return 0, false
+	}
+
+	return int64(threshold) - int64(used), true //nolint:gosec // sizes are far below the int64 limit
+}
+
+// NodeCapacity is the estimated usage of a namespace on each node.
+type NodeCapacity struct {
+	// Indexes is the memory of the primary and secondary indexes, against
+	// indexes-memory-budget, or memory-size before 7.0. From 7.0 its
+	// StopWrites is the lowest of indexes-memory-budget and the
+	// stop-writes-sys-memory-pct of CapacityOptions.SystemMemory, 0 if neither
+	// is known.
+	Indexes ResourceCapacity
+	// Data is the storage of the records, against the devices, files or
+	// data-size of the storage-engine.
+	Data ResourceCapacity
+	// Records are the master and replica records of the node.
+	Records           uint64
+	PrimaryIndexBytes uint64
+	SindexBytes       uint64
+	DataBytes         uint64
+	// NodeCount is the number of nodes the records are spread on.
+	NodeCount int
+}
+
+// NamespaceCapacity is the capacity plan of a namespace.
+type NamespaceCapacity struct {
+	// NodeLoss is the usage after the loss of a node, when its records are
+	// redistributed on the other nodes, nil for a single node cluster.
+	NodeLoss *NodeCapacity
+	Name     string
+	// Nodes is the usage with all the nodes.
+	Nodes             NodeCapacity
+	ReplicationFactor int
+	// MasterObjects are the master objects of the whole cluster.
+	MasterObjects uint64
+	// RecordSize is the average bytes of a record on storage.
+	RecordSize uint64
+	// SindexRecordSize is the average bytes of secondary indexes by record.
+	SindexRecordSize uint64
+}
+
+// CapacityPlan is the result of PlanCapacity.
+type CapacityPlan struct {
+	Namespaces map[string]*NamespaceCapacity
+	NodeCount  int
+}
+
+// PlanCapacity estimates the memory and the storage used by every namespace
+// of the config on each node, and the headroom before the stop-writes and
+// eviction thresholds. The records are assumed evenly spread on the nodes,
+// racks are ignored.
+//
+// The objects and the record sizes are taken from opts, or from the stats of
+// the live nodes. The plan also models the loss of a node (N-1), where the
+// replication-factor is capped to the remaining nodes.
+func PlanCapacity(log logr.Logger, cfg *AsConfig, opts *CapacityOptions) (*CapacityPlan, error) {
+	if opts == nil {
+		opts = &CapacityOptions{}
+	}
+
+	if opts.NodeCount < 0 {
+		return nil, fmt.Errorf("invalid node count %d", opts.NodeCount)
+	}
+
+	nodeCount := opts.NodeCount
+	if nodeCount == 0 {
+		nodeCount = len(opts.Stats)
+	}
+
+	if nodeCount == 0 {
+		return nil, fmt.Errorf("unknown node count, no node count or stats given")
+	}
+
+	conf := *cfg.GetFlatMap()
+	plan := &CapacityPlan{
+		Namespaces: make(map[string]*NamespaceCapacity),
+		NodeCount:  nodeCount,
+	}
+
+	for _, ns := range namedSections(conf, "namespaces") {
+		nc := planNamespace(conf, ns, nodeCount, opts)
+
+		log.V(1).Info("Planned namespace capacity", "namespace", ns, "objects", nc.MasterObjects,
+			"recordSize", nc.RecordSize, "records", nc.Nodes.Records)
+
+		plan.Namespaces[ns] = nc
+	}
+
+	return plan, nil
+}
+
+func planNamespace(conf Conf, ns string, nodeCount int, opts *CapacityOptions) *NamespaceCapacity {
+	prefix := fmt.Sprintf("namespaces.{%s}.", ns)
+	nc := &NamespaceCapacity{
+		Name:              ns,
+		ReplicationFactor: defaultReplicationFactor,
+	}
+
+	if rf, ok := flatNumber(conf, prefix+"replication-factor"); ok {
+		nc.ReplicationFactor = int(rf)
+	}
+
+	var objects, records, dataUsed, sindexUsed, dataTotal, statNodes int64
+
+	for _, stats := range opts.Stats {
+		nsStats := stats.GetInnerVal(info.ConstStat, "namespace", ns, "service")
+		if len(nsStats) == 0 {
+			continue
+		}
+
+		statNodes++
+		objects += nsStats.TryInt("master_objects", 0)
+		records += nsStats.TryInt("objects", 0)
+		dataUsed += nsStats.TryInt("data_used_bytes", 0, "device_used_bytes", "memory_used_data_bytes")
+		sindexUsed += nsStats.TryInt("sindex_used_bytes", 0, "memory_used_sindex_bytes")
+		dataTotal += nsStats.TryInt("data_total_bytes", 0, "device_total_bytes")
+	}
+
+	// the nodes without stats are assumed to have the average objects of the
+	// nodes with stats
+	if statNodes > 0 && statNodes < int64(nodeCount) {
+		objects = objects * int64(nodeCount) / statNodes
+	}
+
+	nc.MasterObjects = nonNegative(objects)
+	if v, ok := opts.Objects[ns]; ok {
+		nc.MasterObjects = v
+	}
+
+	if records > 0 {
+		nc.RecordSize = nonNegative(dataUsed / records)
+		nc.SindexRecordSize = nonNegative(sindexUsed / records)
+	}
+
+	if v, ok := opts.RecordSize[ns]; ok {
+		nc.RecordSize = v
+	}
+
+	var statDataTotal uint64
+	if statNodes > 0 {
+		statDataTotal = nonNegative(dataTotal / statNodes)
+	}
+
+	nc.Nodes = planNode(conf, prefix, nc, nodeCount, statDataTotal, opts)
+
+	if nodeCount > 1 {
+		nodeLoss := planNode(conf, prefix, nc, nodeCount-1, statDataTotal, opts)
+		nc.NodeLoss = &nodeLoss
+	}
+
+	return nc
+}
+
+// planNode estimates the usage of the namespace on each of nodeCount nodes.
+func planNode(conf Conf, prefix string, nc *NamespaceCapacity, nodeCount int, statDataTotal uint64,
+	opts *CapacityOptions,
+) NodeCapacity {
+	rf := min(nc.ReplicationFactor, nodeCount)
+
+	n := NodeCapacity{NodeCount: nodeCount}
+	n.Records = ceilDiv(nc.MasterObjects*nonNegative(int64(rf)), nonNegative(int64(nodeCount)))
+	n.PrimaryIndexBytes = n.Records * primaryIndexEntryBytes
+	n.SindexBytes = n.Records * nc.SindexRecordSize
+	n.DataBytes = n.Records * nc.RecordSize
+
+	n.Indexes = ResourceCapacity{Used: n.PrimaryIndexBytes + n.SindexBytes}
+	n.Indexes.Total = confBytes(conf, prefix+"indexes-memory-budget", prefix+"memory-size")
+	n.Indexes.Evict = pctOf(n.Indexes.Total, conf, prefix+"evict-indexes-memory-pct", prefix+"high-water-memory-pct")
+
+	// memory-size holds the data too before 7.0
+	if _, ok := conf[prefix+"memory-size"]; ok {
+		n.Indexes.StopWrites = pctOf(n.Indexes.Total, conf, prefix+"stop-writes-pct")
+
+		if n.Indexes.StopWrites == 0 {
+			n.Indexes.StopWrites = n.Indexes.Total * defaultStopWritesPct / 100
+		}
+	} else {
+		n.Indexes.StopWrites = sysMemoryStopWrites(conf, prefix, n.Indexes.Total, opts.SystemMemory)
+	}
+
+	n.Data = ResourceCapacity{Used: n.DataBytes}
+	n.Data.Total = dataTotal(conf, prefix, statDataTotal, opts.DeviceSize)
+	n.Data.StopWrites = pctOf(n.Data.Total, conf, prefix+"storage-engine.stop-writes-used-pct",
+		prefix+"storage-engine.max-used-pct")
+
+	if n.Data.StopWrites == 0 {
+		n.Data.StopWrites = n.Data.Total * defaultStopWritesUsedPct / 100
+	}
+
+	n.Data.Evict = pctOf(n.Data.Total, conf, prefix+"storage-engine.evict-used-pct", prefix+"high-water-disk-pct")
+
+	return n
+}
+
+// sysMemoryStopWrites returns the index memory at which a namespace from 7.0
+// stops the writes: the indexes-memory-budget, or the stop-writes-sys-memory-pct
+// of the system memory if lower. The memory of the host is shared with the
+// other namespaces, so the latter is an upper bound. It returns 0 if neither
+// is known.
+func sysMemoryStopWrites(conf Conf, prefix string, budget, systemMemory uint64) uint64 {
+	pct, ok := flatNumber(conf, prefix+"stop-writes-sys-memory-pct")
+	if !ok {
+		pct = defaultStopWritesPct
+	}
+
+	// stop-writes-sys-memory-pct 0 is disabled
+	if systemMemory == 0 || pct <= 0 {
+		return budget
+	}
+
+	sysStopWrites := systemMemory * uint64(pct) / 100
+	if budget == 0 {
+		return sysStopWrites
+	}
+
+	return min(budget, sysStopWrites)
+}
+
+// dataTotal returns the storage of the namespace on a node: data-size for
+// memory, the files at their filesize, or the devices at deviceSize. The
+// storage size of the stats is used if it is not in the config.
+func dataTotal(conf Conf, prefix string, statDataTotal, deviceSize uint64) uint64 {
+	if size := confBytes(conf, prefix+"storage-engine.data-size"); size > 0 {
+		return size
+	}
+
+	files, _ := conf[prefix+"storage-engine.files"].([]string)
+	if filesize := confBytes(conf, prefix+"storage-engine.filesize"); filesize > 0 && len(files) > 0 {
+		return filesize * uint64(len(files))
+	}
+
+	devices, _ := conf[prefix+"storage-engine.devices"].([]string)
+	if deviceSize > 0 && len(devices) > 0 {
+		return deviceSize * uint64(len(devices))
+	}
+
+	return statDataTotal
+}
+
+// confBytes returns the value of the first of the keys set in the config.
+func confBytes(conf Conf, keys ...string) uint64 {
+	for _, k := range keys {
+		if v, ok := flatNumber(conf, k); ok && v > 0 {
+			return uint64(v)
+		}
+	}
+
+	return 0
+}
+
+// pctOf returns the percentage of total set by the first of the keys set in
+// the config, 0 if none is set.
+func pctOf(total uint64, conf Conf, keys ...string) uint64 {
+	return total * confBytes(conf, keys...) / 100
+}
+
+// nonNegative returns v as uint64, 0 if v is negative.
+func nonNegative(v int64) uint64 {
+	if v < 0 {
+		return 0
+	}
+
+	return uint64(v) //nolint:gosec // v is non-negative
+}
+
+func ceilDiv(a, b uint64) uint64 {
+	return (a + b - 1) / b
+}
@@ -0,0 +1,344 @@
+package asconfig
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/aerospike/aerospike-management-lib/info"
+)
+
+const gib = 1024 * 1024 * 1024
+
+type CapacityTestSuite struct {
+	suite.Suite
+}
+
+func capacityTestConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"namespaces": []map[string]interface{}{
+			{
+				"name":                     "test",
+				"replication-factor":       2,
+				"indexes-memory-budget":    "1G",
+				"evict-indexes-memory-pct": 80,
+				"storage-engine": map[string]interface{}{
+					"type":                 "device",
+					"devices":              []string{"/dev/xvdb", "/dev/xvdc"},
+					"stop-writes-used-pct": 70,
+					"evict-used-pct":       60,
+				},
+			},
+			{
+				"name": "bar",
+				"storage-engine": map[string]interface{}{
+					"type":     "device",
+					"files":    []string{"/opt/aerospike/data/bar.dat"},
+					"filesize": 4 * gib,
+				},
+			},
+			{
+				"name":               "mem",
+				"replication-factor": 3,
+				"storage-engine": map[string]interface{}{
+					"type":      "memory",
+					"data-size": "4G",
+				},
+			},
+		},
+	}
+}
+
+// capacityTestAsConfig returns the capacityTestConfig with the flat keys of
+// set changed.
+func (s *CapacityTestSuite) capacityTestAsConfig(set Conf) *AsConfig {
+	cfg, err := NewMapAsConfig(logr.Discard(), capacityTestConfig())
+	s.Require().NoError(err)
+
+	for k, v := range set {
+		(*cfg.baseConf)[k] = v
+	}
+
+	return cfg
+}
+
+// capacityTestStats are the statistics of the test namespace on n nodes.
+func capacityTestStats(n int) []info.NodeAsStats {
+	stats := make([]info.NodeAsStats, 0, n)
+
+	for i := 0; i < n; i++ {
+		stats = append(stats, info.NodeAsStats{
+			info.ConstStat: info.NodeAsStats{
+				"namespace": info.NodeAsStats{
+					"test": info.NodeAsStats{
+						"service": info.NodeAsStats{
+							"master_objects":    int64(1000000),
+							"objects":           int64(2000000),
+							"data_used_bytes":   int64(2000000 * 1024),
+							"sindex_used_bytes": int64(2000000 * 32),
+							"data_total_bytes":  int64(100 * gib),
+						},
+					},
+				},
+			},
+		})
+	}
+
+	return stats
+}
+
+func (s *CapacityTestSuite) TestPlanCapacity() {
+	// the plan of the test namespace from the capacityTestStats of 3 nodes
+	fromStats := &NamespaceCapacity{
+		Name:              "test",
+		ReplicationFactor: 2,
+		MasterObjects:     3000000,
+		RecordSize:        1024,
+		SindexRecordSize:  32,
+		Nodes: NodeCapacity{
+			Indexes:           ResourceCapacity{Used: 2000000 * 96, Total: gib, StopWrites: gib, Evict: gib * 80 / 100},
+			Data:              ResourceCapacity{Used: 2000000 * 1024, Total: 100 * gib, StopWrites: 70 * gib, Evict: 60 * gib},
+			Records:           2000000,
+			PrimaryIndexBytes: 2000000 * 64,
+			SindexBytes:       2000000 * 32,
+			DataBytes:         2000000 * 1024,
+			NodeCount:         3,
+		},
+		NodeLoss: &NodeCapacity{
+			Indexes:           ResourceCapacity{Used: 3000000 * 96, Total: gib, StopWrites: gib, Evict: gib * 80 / 100},
+			Data:              ResourceCapacity{Used: 3000000 * 1024, Total: 100 * gib, StopWrites: 70 * gib, Evict: 60 * gib},
+			Records:           3000000,
+			PrimaryIndexBytes: 3000000 * 64,
+			SindexBytes:       3000000 * 32,
+			DataBytes:         3000000 * 1024,
+			NodeCount:         2,
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		set      Conf
+		opts     *CapacityOptions
+		ns       string
+		expected *NamespaceCapacity
+		err      string
+	}{
+		{
+			name:     "from stats",
+			opts:     &CapacityOptions{Stats: capacityTestStats(3)},
+			ns:       "test",
+			expected: fromStats,
+		},
+		{
+			// the objects of the node without stats are estimated
+			name:     "from partial stats",
+			opts:     &CapacityOptions{Stats: capacityTestStats(2), NodeCount: 3},
+			ns:       "test",
+			expected: fromStats,
+		},
+		{
+			name: "from options",
+			opts: &CapacityOptions{
+				NodeCount:  3,
+				Objects:    map[string]uint64{"test": 9000000},
+				RecordSize: map[string]uint64{"test": 2048},
+				DeviceSize: 10 * gib,
+			},
+			ns: "test",
+			expected: &NamespaceCapacity{
+				Name:              "test",
+				ReplicationFactor: 2,
+				MasterObjects:     9000000,
+				RecordSize:        2048,
+				Nodes: NodeCapacity{
+					Indexes:           ResourceCapacity{Used: 6000000 * 64, Total: gib, StopWrites: gib, Evict: gib * 80 / 100},
+					Data:              ResourceCapacity{Used: 6000000 * 2048, Total: 20 * gib, StopWrites: 14 * gib, Evict: 12 * gib},
+					Records:           6000000,
+					PrimaryIndexBytes: 6000000 * 64,
+					DataBytes:         6000000 * 2048,
+					NodeCount:         3,
+				},
+				// writes are stopped after the loss of a node
+				NodeLoss: &NodeCapacity{
+					Indexes:           ResourceCapacity{Used: 9000000 * 64, Total: gib, StopWrites: gib, Evict: gib * 80 / 100},
+					Data:              ResourceCapacity{Used: 9000000 * 2048, Total: 20 * gib, StopWrites: 14 * gib, Evict: 12 * gib},
+					Records:           9000000,
+					PrimaryIndexBytes: 9000000 * 64,
+					DataBytes:         9000000 * 2048,
+					NodeCount:         2,
+				},
+			},
+		},
+		{
+			// replication-factor 3 is capped to the 2 remaining nodes
+			name: "replication-factor above remaining nodes",
+			opts: &CapacityOptions{
+				NodeCount:  3,
+				Objects:    map[string]uint64{"mem": 1000},
+				RecordSize: map[string]uint64{"mem": 100},
+			},
+			ns: "mem",
+			expected: &NamespaceCapacity{
+				Name:              "mem",
+				ReplicationFactor: 3,
+				MasterObjects:     1000,
+				RecordSize:        100,
+				Nodes: NodeCapacity{
+					Indexes:           ResourceCapacity{Used: 1000 * 64},
+					Data:              ResourceCapacity{Used: 1000 * 100, Total: 4 * gib, StopWrites: 4 * gib * 70 / 100},
+					Records:           1000,
+					PrimaryIndexBytes: 1000 * 64,
+					DataBytes:         1000 * 100,
+					NodeCount:         3,
+				},
+				NodeLoss: &NodeCapacity{
+					Indexes:           ResourceCapacity{Used: 1000 * 64},
+					Data:              ResourceCapacity{Used: 1000 * 100, Total: 4 * gib, StopWrites: 4 * gib * 70 / 100},
+					Records:           1000,
+					PrimaryIndexBytes: 1000 * 64,
+					DataBytes:         1000 * 100,
+					NodeCount:         2,
+				},
+			},
+		},
+		{
+			name: "single node",
+			opts: &CapacityOptions{NodeCount: 1, Objects: map[string]uint64{"bar": 10}},
+			ns:   "bar",
+			expected: &NamespaceCapacity{
+				Name:              "bar",
+				ReplicationFactor: 2,
+				MasterObjects:     10,
+				Nodes: NodeCapacity{
+					Indexes:           ResourceCapacity{Used: 10 * 64},
+					Data:              ResourceCapacity{Total: 4 * gib, StopWrites: 4 * gib * 70 / 100},
+					Records:           10,
+					PrimaryIndexBytes: 10 * 64,
+					NodeCount:         1,
+				},
+			},
+		},
+		{
+			name: "memory-size before 7.0",
+			set:  Conf{"namespaces.{bar}.memory-size": 4 * gib},
+			opts: &CapacityOptions{NodeCount: 1, Objects: map[string]uint64{"bar": 10}},
+			ns:   "bar",
+			expected: &NamespaceCapacity{
+				Name:              "bar",
+				ReplicationFactor: 2,
+				MasterObjects:     10,
+				Nodes: NodeCapacity{
+					Indexes:           ResourceCapacity{Used: 10 * 64, Total: 4 * gib, StopWrites: 4 * gib * 90 / 100},
+					Data:              ResourceCapacity{Total: 4 * gib, StopWrites: 4 * gib * 70 / 100},
+					Records:           10,
+					PrimaryIndexBytes: 10 * 64,
+					NodeCount:         1,
+				},
+			},
+		},
+		{
+			// stop-writes-sys-memory-pct is below indexes-memory-budget
+			name: "stop-writes from system memory",
+			opts: &CapacityOptions{NodeCount: 1, Objects: map[string]uint64{"test": 10}, SystemMemory: gib},
+			ns:   "test",
+			expected: &NamespaceCapacity{
+				Name:              "test",
+				ReplicationFactor: 2,
+				MasterObjects:     10,
+				Nodes: NodeCapacity{
+					Indexes:           ResourceCapacity{Used: 10 * 64, Total: gib, StopWrites: gib * 90 / 100, Evict: gib * 80 / 100},
+					Records:           10,
+					PrimaryIndexBytes: 10 * 64,
+					NodeCount:         1,
+				},
+			},
+		},
+		{
+			name: "stop-writes from system memory without budget",
+			set:  Conf{"namespaces.{mem}.stop-writes-sys-memory-pct": 50},
+			opts: &CapacityOptions{NodeCount: 1, Objects: map[string]uint64{"mem": 10}, SystemMemory: 16 * gib},
+			ns:   "mem",
+			expected: &NamespaceCapacity{
+				Name:              "mem",
+				ReplicationFactor: 3,
+				MasterObjects:     10,
+				Nodes: NodeCapacity{
+					Indexes:           ResourceCapacity{Used: 10 * 64, StopWrites: 8 * gib},
+					Data:              ResourceCapacity{Total: 4 * gib, StopWrites: 4 * gib * 70 / 100},
+					Records:           10,
+					PrimaryIndexBytes: 10 * 64,
+					NodeCount:         1,
+				},
+			},
+		},
+		{
+			// neither indexes-memory-budget nor the system memory is known
+			name: "unknown index stop-writes",
+			set:  Conf{"namespaces.{mem}.stop-writes-sys-memory-pct": 50},
+			opts: &CapacityOptions{NodeCount: 1, Objects: map[string]uint64{"mem": 10}},
+			ns:   "mem",
+			expected: &NamespaceCapacity{
+				Name:              "mem",
+				ReplicationFactor: 3,
+				MasterObjects:     10,
+				Nodes: NodeCapacity{
+					Indexes:           ResourceCapacity{Used: 10 * 64},
+					Data:              ResourceCapacity{Total: 4 * gib, StopWrites: 4 * gib * 70 / 100},
+					Records:           10,
+					PrimaryIndexBytes: 10 * 64,
+					NodeCount:         1,
+				},
+			},
+		},
+		{
+			name: "unknown node count",
+			err:  "unknown node count",
+		},
+		{
+			name: "negative node count",
+			opts: &CapacityOptions{NodeCount: -1, Stats: capacityTestStats(3)},
+			err:  "invalid node count -1",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			plan, err := PlanCapacity(logr.Discard(), s.capacityTestAsConfig(tc.set), tc.opts)
+			if tc.err != "" {
+				s.Assert().ErrorContains(err, tc.err)
+				return
+			}
+
+			s.Require().NoError(err)
+			s.Assert().Equal(tc.expected, plan.Namespaces[tc.ns])
+		})
+	}
+}
+
+func (s *CapacityTestSuite) TestResourceCapacityHeadroom() {
+	rc := ResourceCapacity{Used: 80, Total: 100, StopWrites: 90, Evict: 60}
+
+	headroom, ok := rc.StopWritesHeadroom()
+	s.Assert().True(ok)
+	s.Assert().Equal(int64(10), headroom)
+
+	headroom, ok = rc.EvictHeadroom()
+	s.Assert().True(ok)
+	s.Assert().Equal(int64(-20), headroom)
+
+	rc.Evict = 0
+
+	_, ok = rc.EvictHeadroom()
+	s.Assert().False(ok)
+
+	// an unknown stop-writes threshold has no headroom
+	rc.StopWrites = 0
+
+	_, ok = rc.StopWritesHeadroom()
+	s.Assert().False(ok)
+}
+
+func TestCapacityTestSuite(t *testing.T) {
+	suite.Run(t, new(CapacityTestSuite))
+}
@@ -29,7 +29,7 @@ type CfgValue struct {
 // confIsValid checks if passed conf is valid. If it is not valid
 // then returns json validation error string. String is nil in case of other
 // error conditions.
-func confIsValid(log logr.Logger, flatConf *Conf, ver string) (bool, []*ValidationErr, error) {
+func (r *SchemaRegistry) confIsValid(log logr.Logger, flatConf *Conf, ver string) (bool, []*ValidationErr, error) {
 	confJSON, err := json.Marshal(expandConf(log, flatConf, sep))
 	if err != nil {
 		return false, nil, fmt.Errorf("failed to do json.Marshal for flatten aerospike conf: %v", err)
@@ -37,14 +37,12 @@ func confIsValid(log logr.Logger, flatConf *Conf, ver string) (bool, []*Validati
 
 	confLoader := gojsonschema.NewStringLoader(string(confJSON))
 
-	schema, err := getSchema(ver)
+	schema, err := r.getCompiledSchema(ver)
 	if err != nil {
 		return false, nil, fmt.Errorf("failed to get aerospike config schema for version %s: %v", ver, err)
 	}
 
-	schemaLoader := gojsonschema.NewStringLoader(schema)
-
-	result, err := gojsonschema.Validate(schemaLoader, confLoader)
+	result, err := schema.Validate(confLoader)
 	if err != nil {
 		return false, nil, err
 	}
@@ -0,0 +1,280 @@
+package asconfig
+
+import (
+	"fmt"
+	"sort"
+
+	sets "github.com/deckarep/golang-set/v2"
+	"github.com/go-logr/logr"
+)
+
+type ChangeClass string
+
+// Enum values for ChangeClass, in the increasing order of disruption.
+const (
+	// ChangeDynamic can be applied on a live cluster with set-config commands.
+	ChangeDynamic ChangeClass = "dynamic"
+	// ChangeRollingRestart is a static change which needs the nodes to be restarted one by one.
+	ChangeRollingRestart ChangeClass = "rolling-restart"
+	// ChangeClusterRestart must be coordinated across the whole cluster and needs a full stop
+	// of all the nodes, eg. cluster-name or heartbeat mode.
+	ChangeClusterRestart ChangeClass = "cluster-restart"
+	// ChangeDestructive risks losing data or availability, eg. swapping the storage-engine type.
+	ChangeDestructive ChangeClass = "destructive"
+)
+
+var changeClassRank = map[ChangeClass]int{
+	ChangeDynamic:        0,
+	ChangeRollingRestart: 1,
+	ChangeClusterRestart: 2,
+	ChangeDestructive:    3,
+}
+
+// clusterWideFields are the flat schema keys which must have the same value on
+// all the nodes of a cluster. Changing them node by node splits the cluster.
+var clusterWideFields = sets.NewSet(
+	"service.cluster-name",
+	"network.heartbeat.mode",
+	"network.heartbeat.protocol",
+)
+
+// ConfChange is a single classified config diff entry.
+type ConfChange struct {
+	// Operations is the value of the key in the DynamicConfigMap returned by ConfDiff.
+	Operations map[OpType]interface{}
+	// Current is the current value of the key, nil if the key is not set.
+	Current interface{}
+	Key     string
+	Class   ChangeClass
+	// Reason explains why the change has been put in its class.
+	Reason string
+}
+
+// ConfChangeSet is the classification of all the keys of a config diff.
+type ConfChangeSet struct {
+	// Changes is sorted by key.
+	Changes []*ConfChange
+}
+
+// ByClass returns the changes of the given class.
+func (cs *ConfChangeSet) ByClass(class ChangeClass) []*ConfChange {
+	res := make([]*ConfChange, 0)
+
+	for _, c := range cs.Changes {
+		if c.Class == class {
+			res = append(res, c)
+		}
+	}
+
+	return res
+}
+
+// Class returns the most disruptive class among all the changes.
+// An empty change set is ChangeDynamic.
+func (cs *ConfChangeSet) Class() ChangeClass {
+	class := ChangeDynamic
+
+	for _, c := range cs.Changes {
+		if changeClassRank[c.Class] > changeClassRank[class] {
+			class = c.Class
+		}
+	}
+
+	return class
+}
+
+// DynamicConfigMap returns the changes of the given class as a DynamicConfigMap.
+// eg. the ChangeDynamic part can be passed to CreateSetConfigCmdList.
+func (cs *ConfChangeSet) DynamicConfigMap(class ChangeClass) DynamicConfigMap {
+	res := make(DynamicConfigMap)
+
+	for _, c := range cs.ByClass(class) {
+		res[c.Key] = c.Operations
+	}
+
+	return res
+}
+
+// ClassifyConfDiff computes ConfDiff between the desired and the current config
+// and classifies every key of the diff.
+func ClassifyConfDiff(
+	log logr.Logger, desiredConf, currentConf Conf, isFlat bool, ver string,
+) (*ConfChangeSet, error) {
+	return defaultRegistry.ClassifyConfDiff(log, desiredConf, currentConf, isFlat, ver)
+}
+
+// ClassifyConfDiff is ClassifyConfDiff using the schemas of the registry.
+func (r *SchemaRegistry) ClassifyConfDiff(
+	log logr.Logger, desiredConf, currentConf Conf, isFlat bool, ver string,
+) (*ConfChangeSet, error) {
+	if !isFlat {
+		var err error
+
+		desiredConf, err = flattenConf(log, desiredConf, sep)
+		if err != nil {
+			return nil, fmt.Errorf("failed to flatten desired config: %w", err)
+		}
+
+		currentConf, err = flattenConf(log, currentConf, sep)
+		if err != nil {
+			return nil, fmt.Errorf("failed to flatten current config: %w", err)
+		}
+	}
+
+	diffs, err := r.ConfDiff(log, desiredConf, currentConf, true, ver)
+	if err != nil {
+		return nil, err
+	}
+
+	// ConfDiff ignores node specific fields, but removing storage devices or files is a
+	// destructive change which must not go unnoticed.
+	addStorageListDiff(log, desiredConf, currentConf, diffs)
+
+	return r.ClassifyDiff(log, diffs, currentConf, ver)
+}
+
+// addStorageListDiff adds the storage-engine devices and files entries which
+// differ between the desired and the current flat configs to diffs.
+func addStorageListDiff(log logr.Logger, desired, current Conf, diffs DynamicConfigMap) {
+	isStorageList := func(key string) bool {
+		flatKey := GetFlatKey(SplitKey(log, key, sep))
+		return flatKey == "namespaces._.storage-engine.devices" || flatKey == "namespaces._.storage-engine.files"
+	}
+
+	for key, desiredValue := range desired {
+		if !isStorageList(key) {
+			continue
+		}
+
+		desiredList, ok := desiredValue.([]string)
+		if !ok {
+			continue
+		}
+
+		currentList, _ := current[key].([]string)
+		handleValueDiff(key, desiredList, currentList, diffs)
+	}
+
+	for key, currentValue := range current {
+		if _, ok := desired[key]; ok || !isStorageList(key) {
+			continue
+		}
+
+		if currentList, ok := currentValue.([]string); ok && len(currentList) > 0 {
+			diffs[key] = map[OpType]interface{}{Remove: currentList}
+		}
+	}
+}
+
+// ClassifyDiff classifies every key of diffs, as returned by ConfDiff, into one of
+// ChangeDynamic, ChangeRollingRestart, ChangeClusterRestart or ChangeDestructive.
+// currentFlatConf is the flattened config the diff was computed against. It is
+// needed to find decreasing or toggled values.
+func ClassifyDiff(log logr.Logger, diffs DynamicConfigMap, currentFlatConf Conf, ver string) (*ConfChangeSet, error) {
+	return defaultRegistry.ClassifyDiff(log, diffs, currentFlatConf, ver)
+}
+
+// ClassifyDiff is ClassifyDiff using the schemas of the registry.
+func (r *SchemaRegistry) ClassifyDiff(log logr.Logger, diffs DynamicConfigMap, currentFlatConf Conf, ver string) (
+	*ConfChangeSet, error,
+) {
+	dynamic, err := r.GetDynamic(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(diffs))
+	for k := range diffs {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	cs := &ConfChangeSet{
+		Changes: make([]*ConfChange, 0, len(keys)),
+	}
+
+	for _, key := range keys {
+		c := &ConfChange{
+			Key:        key,
+			Operations: diffs[key],
+			Current:    currentFlatConf[key],
+		}
+
+		c.Class, c.Reason = classifyChange(log, dynamic, c)
+		cs.Changes = append(cs.Changes, c)
+	}
+
+	return cs, nil
+}
+
+// classifyChange returns the class of the change and the reason for it.
+// Checks go from the most disruptive class to the least one.
+func classifyChange(log logr.Logger, dynamic sets.Set[string], c *ConfChange) (ChangeClass, string) {
+	tokens := SplitKey(log, c.Key, sep)
+	flatKey := GetFlatKey(tokens)
+	baseKey := tokens[len(tokens)-1]
+	desired, updated := c.Operations[Update]
+
+	if class, reason, ok := classifyDestructive(flatKey, baseKey, desired, updated, c); ok {
+		return class, reason
+	}
+
+	if clusterWideFields.Contains(flatKey) {
+		return ChangeClusterRestart, fmt.Sprintf(
+			"%s must be the same on all the nodes, all the nodes must be stopped and started with the new value",
+			flatKey)
+	}
+
+	if IsDynamicConfig(log, dynamic, c.Key, c.Operations) {
+		return ChangeDynamic, "dynamic config, can be applied with set-config"
+	}
+
+	return ChangeRollingRestart, "static config, needs a rolling restart"
+}
+
+func classifyDestructive(
+	flatKey, baseKey string, desired interface{}, updated bool, c *ConfChange,
+) (class ChangeClass, reason string, ok bool) {
+	switch {
+	case flatKey == "namespaces._.storage-engine.type" && c.Current != nil && updated:
+		return ChangeDestructive, fmt.Sprintf(
+			"storage-engine type changes from %v to %v, data on the existing storage is not migrated",
+			c.Current, desired), true
+
+	case flatKey == "namespaces._.storage-engine.devices" || flatKey == "namespaces._.storage-engine.files":
+		if removed, ok := c.Operations[Remove]; ok {
+			return ChangeDestructive, fmt.Sprintf(
+				"%v removed from storage-engine %s, data on them is lost on this node", removed, baseKey), true
+		}
+
+	case flatKey == "namespaces._.name":
+		if _, ok := c.Operations[Remove]; ok {
+			return ChangeDestructive, "namespace is removed, its data is dropped from the cluster", true
+		}
+
+	case flatKey == "namespaces._.strong-consistency" && updated:
+		return ChangeDestructive, fmt.Sprintf(
+			"strong-consistency toggled from %v to %v, the namespace must be wiped and the roster reset",
+			valueOrDefault(c.Current, false), desired), true
+
+	case flatKey == "namespaces._.replication-factor" && updated:
+		cur, curOK := toFloat64(c.Current)
+		des, desOK := toFloat64(desired)
+
+		if curOK && desOK && des < cur {
+			return ChangeDestructive, fmt.Sprintf(
+				"replication-factor decreases from %v to %v, extra replicas are dropped", c.Current, desired), true
+		}
+	}
+
+	return "", "", false
+}
+
+func valueOrDefault(v, def interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+
+	return v
+}
@@ -0,0 +1,150 @@
+package asconfig
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfClassifyTestSuite struct {
+	suite.Suite
+}
+
+func (s *ConfClassifyTestSuite) SetupSuite() {
+	InitFromMap(logr.Discard(), testSchemas)
+}
+
+func classifyTestConf() Conf {
+	return Conf{
+		"service": Conf{
+			"cluster-name":    "c1",
+			"proto-fd-max":    uint64(15000),
+			"migrate-threads": uint64(1),
+		},
+		"network": Conf{
+			"heartbeat": Conf{"mode": "mesh", "port": uint64(3002)},
+		},
+		"namespaces": []Conf{
+			{
+				"name":               "test",
+				"replication-factor": uint64(2),
+				"default-ttl":        uint64(0),
+				"storage-engine": Conf{
+					"type":    "device",
+					"devices": []string{"/dev/xvdb", "/dev/xvdc"},
+				},
+			},
+		},
+	}
+}
+
+func (s *ConfClassifyTestSuite) TestClassifyConfDiff() {
+	testCases := []struct {
+		mutate   func(c Conf)
+		expected map[string]ChangeClass
+		name     string
+		class    ChangeClass
+	}{
+		{
+			func(c Conf) {
+				c["service"].(Conf)["proto-fd-max"] = uint64(20000)
+				c["namespaces"].([]Conf)[0]["default-ttl"] = uint64(100)
+			},
+			map[string]ChangeClass{
+				"service.proto-fd-max":          ChangeDynamic,
+				"namespaces.{test}.default-ttl": ChangeDynamic,
+			},
+			"dynamic",
+			ChangeDynamic,
+		},
+		{
+			func(c Conf) {
+				c["service"].(Conf)["proto-fd-max"] = uint64(20000)
+				c["service"].(Conf)["work-directory"] = "/var/aerospike"
+			},
+			map[string]ChangeClass{
+				"service.proto-fd-max":   ChangeDynamic,
+				"service.work-directory": ChangeRollingRestart,
+			},
+			"static",
+			ChangeRollingRestart,
+		},
+		{
+			func(c Conf) {
+				c["service"].(Conf)["cluster-name"] = "c2"
+				c["network"].(Conf)["heartbeat"].(Conf)["mode"] = "multicast"
+			},
+			map[string]ChangeClass{
+				"service.cluster-name":   ChangeClusterRestart,
+				"network.heartbeat.mode": ChangeClusterRestart,
+			},
+			"cluster-wide",
+			ChangeClusterRestart,
+		},
+		{
+			func(c Conf) {
+				ns := c["namespaces"].([]Conf)[0]
+				ns["replication-factor"] = uint64(1)
+				ns["strong-consistency"] = true
+				ns["storage-engine"].(Conf)["devices"] = []string{"/dev/xvdb"}
+			},
+			map[string]ChangeClass{
+				"namespaces.{test}.replication-factor":     ChangeDestructive,
+				"namespaces.{test}.strong-consistency":     ChangeDestructive,
+				"namespaces.{test}.storage-engine.devices": ChangeDestructive,
+			},
+			"destructive",
+			ChangeDestructive,
+		},
+		{
+			func(c Conf) {
+				c["namespaces"].([]Conf)[0]["replication-factor"] = uint64(3)
+			},
+			map[string]ChangeClass{
+				"namespaces.{test}.replication-factor": ChangeDynamic,
+			},
+			"replication-factor increase",
+			ChangeDynamic,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			desired := classifyTestConf()
+			tc.mutate(desired)
+
+			cs, err := ClassifyConfDiff(logr.Discard(), desired, classifyTestConf(), false, "7.0.0")
+			s.Require().NoError(err)
+
+			actual := make(map[string]ChangeClass, len(cs.Changes))
+			for _, c := range cs.Changes {
+				s.Assert().NotEmpty(c.Reason)
+				actual[c.Key] = c.Class
+			}
+
+			s.Assert().Equal(tc.expected, actual)
+			s.Assert().Equal(tc.class, cs.Class())
+		})
+	}
+}
+
+func (s *ConfClassifyTestSuite) TestClassifyStorageEngineSwap() {
+	current := Conf{
+		"namespaces.{test}.name":                "test",
+		"namespaces.{test}.storage-engine.type": "memory",
+	}
+	diffs := DynamicConfigMap{
+		"namespaces.{test}.storage-engine.type": {Update: "device"},
+	}
+
+	cs, err := ClassifyDiff(logr.Discard(), diffs, current, "7.0.0")
+	s.Require().NoError(err)
+	s.Require().Len(cs.ByClass(ChangeDestructive), 1)
+	s.Assert().Contains(cs.Changes[0].Reason, "from memory to device")
+	s.Assert().Empty(cs.DynamicConfigMap(ChangeDynamic))
+}
+
+func TestConfClassifyTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfClassifyTestSuite))
+}
@@ -194,7 +194,9 @@ func handleMissingSection(log logr.Logger, key string, desired, current Conf, d
 	return false
 }
 
-func handlePartialMissingSection(desiredKey, ver string, current Conf, d DynamicConfigMap) (bool, error) {
+func (r *SchemaRegistry) handlePartialMissingSection(desiredKey, ver string, current Conf, d DynamicConfigMap) (
+	bool, error,
+) {
 	diffUpdated := false
 	// Check current conf for any key which starts with desiredKey
 	// if found, then add default value to currentKey config parameter
@@ -210,7 +212,7 @@ func handlePartialMissingSection(desiredKey, ver string, current Conf, d Dynamic
 		if reflect.ValueOf(current[currentKey]).Kind() == reflect.Slice {
 			operationValueMap[Remove] = current[currentKey].([]string)
 		} else {
-			defaultMap, err := GetDefault(ver)
+			defaultMap, err := r.GetDefault(ver)
 			if err != nil {
 				return false, err
 			}
@@ -270,18 +272,29 @@ func handleValueDiff(key string, desiredValue, currentValue interface{}, d Dynam
 	}
 }
 
+// isValueEqual returns true if the values of the flat config key are equal
+// once normalized, or as they are if normalizer is nil.
+func isValueEqual(log logr.Logger, normalizer *ValueNormalizer, key string, v1, v2 interface{}) bool {
+	if normalizer == nil {
+		return !isValueDiff(log, v1, v2)
+	}
+
+	return normalizer.IsValueEqual(key, v1, v2)
+}
+
 // detailedDiff find diff between two configs;
 //
 //	detailedDiff = desired - current
 //
 // Generally used to compare current and desired config. This ignores
-// node specific information like address, device, interface etc.
-func detailedDiff(log logr.Logger, desired, current Conf, isFlat,
-	desiredToActual bool, ver string) (DynamicConfigMap, error) {
+// node specific information like address, device, interface etc. The values
+// are compared with the normalizer of the schema version ver, if not nil.
+func (r *SchemaRegistry) detailedDiff(log logr.Logger, desired, current Conf, isFlat,
+	desiredToActual bool, ver string, normalizer *ValueNormalizer) (DynamicConfigMap, error) {
+	var err error
+
 	// Flatten if not flattened already.
 	if !isFlat {
-		var err error
-
 		desired, err = flattenConf(log, desired, sep)
 		if err != nil {
 			return nil, fmt.Errorf("failed to flatten desired config: %w", err)
@@ -309,12 +322,11 @@ func detailedDiff(log logr.Logger, desired, current Conf, isFlat,
 		if !ok {
 			diffUpdated := false
 			if diffUpdated = handleMissingSection(log, key, desired, current, d, desiredToActual); !diffUpdated {
-				var err error
 				// Add default values to config parameter if available in schema.
 				// If key is not present in current, then check if any key in desired which starts with key is present in current
 				// eg. desired has security: {} current has security.log.report-sys-admin: true
 				// final diff should be map[security.log.report-sys-admin] = <default value>
-				diffUpdated, err = handlePartialMissingSection(key, ver, current, d)
+				diffUpdated, err = r.handlePartialMissingSection(key, ver, current, d)
 				if err != nil {
 					return nil, err
 				}
@@ -332,7 +344,9 @@ func detailedDiff(log logr.Logger, desired, current Conf, isFlat,
 			key, "desiredValue", desiredValue, "currentValue", currentValue,
 		)
 
-		if isValueDiff(log, desiredValue, currentValue) {
+		// Values are compared normalized, eg. 4G and 4294967296, not to
+		// issue no-op updates.
+		if !isValueEqual(log, normalizer, key, desiredValue, currentValue) {
 			handleValueDiff(key, desiredValue, currentValue, d)
 		}
 	}
@@ -352,19 +366,34 @@ func detailedDiff(log logr.Logger, desired, current Conf, isFlat,
 func ConfDiff(
 	log logr.Logger, desiredConf, currentConf Conf, isFlat bool, ver string,
 ) (DynamicConfigMap, error) {
+	return defaultRegistry.ConfDiff(log, desiredConf, currentConf, isFlat, ver)
+}
+
+// ConfDiff is ConfDiff using the schemas of the registry for the default values.
+func (r *SchemaRegistry) ConfDiff(
+	log logr.Logger, desiredConf, currentConf Conf, isFlat bool, ver string,
+) (DynamicConfigMap, error) {
+	// Without the schema of ver the values are compared as they are.
+	normalizer, err := r.NewValueNormalizer(log, ver)
+	if err != nil {
+		log.V(1).Info("Comparing config values without normalization", "version", ver, "err", err)
+	}
+
 	// Comparing desired and current config
-	diffs, err := detailedDiff(log, desiredConf, currentConf, isFlat, true, ver)
+	diffs, err := r.detailedDiff(log, desiredConf, currentConf, isFlat, true, ver, normalizer)
 	if err != nil {
 		return nil, err
 	}
 
 	// Comparing current and desired config
 	// If any config parameter is present in current but not in desired.
-	removedConfigs, err := detailedDiff(log, currentConf, desiredConf, isFlat, false, ver)
+	removedConfigs, err := r.detailedDiff(log, currentConf, desiredConf, isFlat, false, ver, normalizer)
 	if err != nil {
 		return nil, err
 	}
 
+	var defaultMap map[string]interface{}
+
 	for removedConfigKey := range removedConfigs {
 		// If any key difference is already captured while comparing desired and current config in detailedDiff,
 		// then ignore it while comparing current and desired config.
@@ -380,13 +409,23 @@ func ConfDiff(
 		}
 
 		// Setting defaults for atomic keys which are not present in desired config
-		defaultMap, err := GetDefault(ver)
-		if err != nil {
-			return nil, err
+		if defaultMap == nil {
+			defaultMap, err = r.GetDefault(ver)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		defaultValue := getDefaultValue(defaultMap, removedConfigKey)
+
+		// Resetting a key already at its default value is a no-op.
+		if currentValue, ok := removedConfigs[removedConfigKey][Update]; ok && defaultValue != nil &&
+			isValueEqual(log, normalizer, removedConfigKey, defaultValue, currentValue) {
+			continue
 		}
 
 		valueMap := make(map[OpType]interface{})
-		valueMap[Update] = getDefaultValue(defaultMap, removedConfigKey)
+		valueMap[Update] = defaultValue
 		diffs[removedConfigKey] = valueMap
 	}
 
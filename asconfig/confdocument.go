@@ -0,0 +1,807 @@
+package asconfig
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-logr/logr"
+
+	lib "github.com/aerospike/aerospike-management-lib"
+)
+
+// ConfDocument is the concrete syntax tree of an aerospike.conf. Unlike
+// AsConfig it keeps the comments, blank lines, layout and order of the file,
+// so that editing a value through Set, Delete or Update only changes the
+// lines of that value. An unedited ConfDocument is written back byte for byte.
+//
+// Keys are flat keys, eg. "namespace.{test}.storage-engine.device". The
+// plural names of the AsConfig maps are accepted as well, eg.
+// "namespaces.{test}.storage-engine.devices". The type of a typed section,
+// eg. storage-engine, is the "type" key of the section.
+type ConfDocument struct {
+	root *confNode
+	log  logr.Logger
+	// fileName is used in the positions of the errors.
+	fileName string
+	// indent is the unit of indentation of the file, used for added lines.
+	indent string
+	// gap is the space between the names and values of the file, used for
+	// added lines if their section has no field.
+	gap string
+	// finalNewline is true if the file ends with a new line.
+	finalNewline bool
+}
+
+type confNodeKind int
+
+const (
+	// confNodeText is a blank or comment only line.
+	confNodeText confNodeKind = iota
+	confNodeField
+	confNodeSection
+)
+
+// confNode is a line of an aerospike.conf, or a section with its opening
+// and closing lines.
+type confNode struct {
+	parent   *confNode
+	children []*confNode
+	// raw is the line, the opening line for sections.
+	raw string
+	// closeRaw is the closing line of sections.
+	closeRaw string
+	tokens   []string
+	// path are the flat key tokens added by a section, see sectionPathTokens.
+	path []string
+	line int
+	kind confNodeKind
+}
+
+// NewConfDocument parses the aerospike.conf text in "in" read from the file
+// fileName into a ConfDocument. Only the structure of the file is checked,
+// use AsConfig to parse the values.
+func NewConfDocument(log logr.Logger, fileName string, in io.Reader) (*ConfDocument, error) {
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &ConfDocument{
+		root:         &confNode{kind: confNodeSection},
+		log:          log,
+		fileName:     fileName,
+		indent:       indentString(1),
+		gap:          indentString(1),
+		finalNewline: bytes.HasSuffix(src, []byte("\n")),
+	}
+
+	scanner := newConfScanner(fileName, bytes.NewReader(src))
+	scanner.Split(scanRawLines)
+
+	current := doc.root
+	indentFound := false
+	gapFound := false
+
+	for scanner.Scan() {
+		node := &confNode{parent: current, raw: scanner.text, line: scanner.line}
+
+		line := parseLine(scanner.text)
+		if line == "" {
+			current.children = append(current.children, node)
+			continue
+		}
+
+		tok := strings.Split(line, " ")
+		lastToken := tok[len(tok)-1]
+
+		if lastToken != "{" && strings.HasSuffix(lastToken, "{") {
+			return nil, scanner.errorf(lastToken, "config file items must have a space between them and '{'")
+		}
+
+		if tok[0] == "}" {
+			if current == doc.root {
+				return nil, scanner.errorf("}", "unexpected '}'")
+			}
+
+			current.closeRaw = scanner.text
+			current = current.parent
+
+			continue
+		}
+
+		if !indentFound && current != doc.root && current.parent == doc.root {
+			if indent := lineIndent(scanner.text); indent != "" {
+				doc.indent = indent
+				indentFound = true
+			}
+		}
+
+		node.tokens = tok
+		current.children = append(current.children, node)
+
+		if lastToken == "{" {
+			node.kind = confNodeSection
+			node.path = sectionPathTokens(current.lastPathToken(), tok)
+			current = node
+
+			continue
+		}
+
+		node.kind = confNodeField
+
+		if !gapFound && len(tok) > 1 && tok[0] != "context" {
+			doc.gap = node.gap()
+			gapFound = true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if current != doc.root {
+		return nil, &ConfParseError{
+			Msg: fmt.Sprintf("section %s is not closed", current.tokens[0]),
+			Position: Position{
+				File:    fileName,
+				Line:    current.line,
+				Column:  len(lineIndent(current.raw)) + 1,
+				Snippet: current.raw,
+			},
+		}
+	}
+
+	return doc, nil
+}
+
+// scanRawLines is bufio.ScanLines keeping the carriage returns, so that
+// the lines can be written back unchanged.
+func scanRawLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+// Bytes returns the aerospike.conf text of the document.
+func (d *ConfDocument) Bytes() []byte {
+	lines := make([]string, 0)
+	d.root.writeLines(&lines)
+
+	text := strings.Join(lines, "\n")
+	if d.finalNewline && len(lines) > 0 {
+		text += "\n"
+	}
+
+	return []byte(text)
+}
+
+// WriteTo writes the aerospike.conf text of the document to w.
+func (d *ConfDocument) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(d.Bytes())
+	return int64(n), err
+}
+
+// AsConfig parses the document into an AsConfig, with the positions of the
+// keys in the document.
+func (d *ConfDocument) AsConfig() (*AsConfig, error) {
+	return FromNamedConfFile(d.log, d.fileName, bytes.NewReader(d.Bytes()))
+}
+
+// Get returns the values of the key, one per line for list fields, eg.
+// "namespace.{test}.storage-engine.device". ok is false if the key is not in
+// the document.
+func (d *ConfDocument) Get(key string) (values []string, ok bool) {
+	section, name := d.findParent(key)
+	if section == nil {
+		return nil, false
+	}
+
+	if header := section.typeHeader(name); header != nil {
+		return []string{header.tokens[1]}, true
+	}
+
+	for _, field := range section.fields(name) {
+		values = append(values, strings.Join(field.valueTokens(), " "))
+	}
+
+	return values, len(values) > 0
+}
+
+// Set sets the values of the key. Each value is written on its own line,
+// several values are only valid for list fields, eg. "device". The lines of
+// the key already in the document are edited in place, extra lines are
+// removed and missing lines are added after the last line of the key. The
+// missing sections of the key are added at the end of their enclosing section.
+func (d *ConfDocument) Set(key string, values ...string) error {
+	if len(values) == 0 {
+		return fmt.Errorf("%w: no value to set for %s", ErrConfigKeyInvalid, key)
+	}
+
+	tokens := d.keyTokens(key)
+	if len(tokens) == 0 {
+		return fmt.Errorf("%w: %s", ErrConfigKeyInvalid, key)
+	}
+
+	name := tokens[len(tokens)-1]
+
+	section, err := d.ensureSection(tokens[:len(tokens)-1], name, values[0])
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrConfigKeyInvalid, key, err)
+	}
+
+	if header := section.typeHeader(name); header != nil {
+		if len(values) > 1 {
+			return fmt.Errorf("%w: %s has a single value", ErrConfigKeyInvalid, key)
+		}
+
+		header.setValue(values[0])
+
+		return nil
+	}
+
+	fields := section.fields(name)
+
+	for i, value := range values {
+		if i < len(fields) {
+			fields[i].setValue(value)
+			continue
+		}
+
+		after := section.lastChild()
+		if len(fields) > 0 {
+			after = fields[len(fields)-1]
+		}
+
+		node := d.newField(section, name, value)
+		section.insertAfter(after, node)
+		fields = append(fields, node)
+	}
+
+	for _, field := range fields[len(values):] {
+		section.remove(field)
+	}
+
+	return nil
+}
+
+// Delete removes the lines of the key, or the whole section if the key is a
+// section, eg. "namespace.{test}".
+func (d *ConfDocument) Delete(key string) error {
+	if target := d.findSection(d.keyTokens(key)); target != nil && target != d.root {
+		target.parent.remove(target)
+		return nil
+	}
+
+	section, name := d.findParent(key)
+	if section == nil {
+		return fmt.Errorf("%w: %s is not in the config", ErrConfigKeyInvalid, key)
+	}
+
+	if section.typeHeader(name) != nil {
+		return fmt.Errorf("%w: type of %s can not be deleted", ErrConfigKeyInvalid, key)
+	}
+
+	fields := section.fields(name)
+	if len(fields) == 0 {
+		return fmt.Errorf("%w: %s is not in the config", ErrConfigKeyInvalid, key)
+	}
+
+	for _, field := range fields {
+		section.remove(field)
+	}
+
+	return nil
+}
+
+// Update edits the document so that it holds the config of cfg, changing
+// only the lines of the values which differ. cfg should be in the layout of
+// FromConfFile, eg. the config returned by AsConfig and then modified.
+// Values are compared by their conf file text, so that eg. "30d" is kept if
+// cfg has 2592000 for it.
+func (d *ConfDocument) Update(cfg *AsConfig) error {
+	current, err := d.AsConfig()
+	if err != nil {
+		return err
+	}
+
+	oldConf := *current.GetFlatMap()
+	newConf := *cfg.GetFlatMap()
+
+	// Removed sections and keys, outermost first so that the keys of the
+	// removed sections are skipped.
+	for _, k := range sortKeys(oldConf) {
+		if _, ok := newConf[k]; ok {
+			continue
+		}
+
+		tokens := SplitKey(d.log, k, sep)
+		if tokens[len(tokens)-1] == KeyName {
+			continue
+		}
+
+		// the section is removed, or changed from typed section to field
+		if tokens[len(tokens)-1] == keyIndex || tokens[len(tokens)-1] == keyType {
+			k = strings.Join(tokens[:len(tokens)-1], sep)
+		}
+
+		if _, ok := d.Get(k); !ok && d.findSection(d.keyTokens(k)) == nil {
+			continue
+		}
+
+		if err := d.Delete(k); err != nil {
+			return err
+		}
+	}
+
+	// Types first as the typed sections can not be added without their type.
+	keys := sortKeys(newConf)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return BaseKey(keys[i]) == keyType && BaseKey(keys[j]) != keyType
+	})
+
+	for _, k := range keys {
+		tokens := SplitKey(d.log, k, sep)
+
+		switch tokens[len(tokens)-1] {
+		case KeyName:
+			continue
+
+		case keyIndex:
+			if _, err := d.ensureSection(d.keyTokens(strings.Join(tokens[:len(tokens)-1], sep)), "", ""); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrConfigKeyInvalid, k, err)
+			}
+
+			continue
+		}
+
+		newValues := confFileValues(k, newConf[k])
+		if oldValue, ok := oldConf[k]; ok && reflect.DeepEqual(confFileValues(k, oldValue), newValues) {
+			continue
+		}
+
+		if len(newValues) == 0 {
+			if _, ok := d.Get(k); ok {
+				if err := d.Delete(k); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if err := d.Set(k, newValues...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// confFileValues returns the values of the flat key as written in a conf
+// file, one per line.
+func confFileValues(key string, value interface{}) []string {
+	base := BaseKey(key)
+
+	switch v := value.(type) {
+	case []string:
+		_, listSep := isListField(base)
+		values := make([]string, 0, len(v))
+
+		for _, s := range v {
+			if listSep != "" {
+				s = strings.ReplaceAll(s, listSep, " ")
+			}
+
+			values = append(values, s)
+		}
+
+		return values
+
+	case []interface{}:
+		values := make([]string, 0, len(v))
+
+		for _, i := range v {
+			s, _ := lib.ToString(i)
+			values = append(values, s)
+		}
+
+		return values
+
+	default:
+		s, _ := lib.ToString(v)
+		if ok, delim := isDelimitedStringField(base); ok && delim != "" {
+			s = strings.ReplaceAll(s, delim, " ")
+		}
+
+		return []string{s}
+	}
+}
+
+// keyTokens splits the flat key with the plural names replaced by the conf
+// file names.
+func (d *ConfDocument) keyTokens(key string) []string {
+	tokens := SplitKey(d.log, key, sep)
+	for i := range tokens {
+		tokens[i] = SingularOf(tokens[i])
+	}
+
+	return tokens
+}
+
+// findParent returns the section of the key and the last token of the key.
+func (d *ConfDocument) findParent(key string) (section *confNode, name string) {
+	tokens := d.keyTokens(key)
+	if len(tokens) == 0 {
+		return nil, ""
+	}
+
+	return d.findSection(tokens[:len(tokens)-1]), tokens[len(tokens)-1]
+}
+
+// findSection returns the first section with the flat key tokens, nil if
+// there is none.
+func (d *ConfDocument) findSection(tokens []string) *confNode {
+	section := d.root
+
+	for len(tokens) > 0 {
+		child := section.childSection(tokens)
+		if child == nil {
+			return nil
+		}
+
+		tokens = tokens[len(child.path):]
+		section = child
+	}
+
+	return section
+}
+
+// ensureSection returns the section with the flat key tokens, adding the
+// missing sections. name and value are the key being set in the section,
+// they give the type of a missing typed section.
+func (d *ConfDocument) ensureSection(tokens []string, name, value string) (*confNode, error) {
+	section := d.root
+
+	for len(tokens) > 0 {
+		if child := section.childSection(tokens); child != nil {
+			tokens = tokens[len(child.path):]
+			section = child
+
+			continue
+		}
+
+		var header []string
+
+		switch {
+		case isSpecialListSection(section.lastPathToken()):
+			logName := sectionName(tokens[0])
+			header = []string{logName}
+
+			if logName != constLoggingConsole && logName != constLoggingSyslog && logName != constLoggingStderr {
+				header = []string{keyFile, logName}
+			}
+
+		case isListSection(tokens[0]) && len(tokens) > 1:
+			header = []string{tokens[0], sectionName(tokens[1])}
+
+		case isTypedSection(tokens[0]):
+			typeField := section.fields(tokens[0])
+
+			switch {
+			case len(typeField) > 0:
+				// eg. "storage-engine memory" gets values
+				typeField[0].toSection()
+				continue
+
+			case len(tokens) == 1 && name == keyType:
+				header = []string{tokens[0], value}
+
+			default:
+				return nil, fmt.Errorf("section %s has no type, set its type first", tokens[0])
+			}
+
+		default:
+			header = []string{tokens[0]}
+		}
+
+		child := d.newSection(section, header)
+		after := section.lastChild()
+
+		// top level sections are separated by a blank line
+		if section == d.root && after != nil {
+			blank := &confNode{parent: section, kind: confNodeText}
+			section.insertAfter(after, blank)
+			after = blank
+		}
+
+		section.insertAfter(after, child)
+
+		tokens = tokens[len(child.path):]
+		section = child
+	}
+
+	return section, nil
+}
+
+func (d *ConfDocument) depthIndent(section *confNode) string {
+	depth := 0
+	for n := section; n.parent != nil; n = n.parent {
+		depth++
+	}
+
+	return strings.Repeat(d.indent, depth)
+}
+
+// childIndent returns the indentation of the lines added to the section,
+// the one of its children if any.
+func (d *ConfDocument) childIndent(section *confNode) string {
+	for _, child := range section.children {
+		if child.kind != confNodeText {
+			return lineIndent(child.raw)
+		}
+	}
+
+	return d.depthIndent(section)
+}
+
+func (d *ConfDocument) newField(section *confNode, name, value string) *confNode {
+	gap := d.gap
+
+	for _, child := range section.children {
+		if child.kind == confNodeField && child.tokens[0] != "context" {
+			gap = child.gap()
+			break
+		}
+	}
+
+	prefix := []string{name}
+	if section.parent != nil && isSpecialListSection(section.parent.lastPathToken()) &&
+		!isSyslogParam(name) {
+		prefix = []string{"context", name}
+	}
+
+	return &confNode{
+		parent: section,
+		raw:    d.childIndent(section) + strings.Join(prefix, " ") + gap + value,
+		tokens: append(prefix, strings.Fields(value)...),
+		kind:   confNodeField,
+	}
+}
+
+func (d *ConfDocument) newSection(parent *confNode, header []string) *confNode {
+	indent := d.childIndent(parent)
+	tokens := append(header, "{")
+
+	return &confNode{
+		parent:   parent,
+		raw:      indent + strings.Join(tokens, " "),
+		closeRaw: indent + "}",
+		tokens:   tokens,
+		path:     sectionPathTokens(parent.lastPathToken(), tokens),
+		kind:     confNodeSection,
+	}
+}
+
+// sectionName returns the name of a named section token, eg. "test" for "{test}".
+func sectionName(token string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(token, string(SectionNameStartChar)), string(SectionNameEndChar))
+}
+
+func isSyslogParam(name string) bool {
+	return name == "facility" || name == "path" || name == "tag"
+}
+
+func lineIndent(raw string) string {
+	return raw[:len(raw)-len(strings.TrimLeftFunc(raw, unicode.IsSpace))]
+}
+
+func (n *confNode) writeLines(lines *[]string) {
+	if n.parent != nil {
+		*lines = append(*lines, n.raw)
+	}
+
+	for _, child := range n.children {
+		child.writeLines(lines)
+	}
+
+	if n.kind == confNodeSection && n.parent != nil {
+		*lines = append(*lines, n.closeRaw)
+	}
+}
+
+func (n *confNode) lastPathToken() string {
+	if len(n.path) == 0 {
+		return ""
+	}
+
+	return n.path[len(n.path)-1]
+}
+
+// childSection returns the first child section whose path is a prefix of tokens.
+func (n *confNode) childSection(tokens []string) *confNode {
+	for _, child := range n.children {
+		if child.kind != confNodeSection || len(child.path) > len(tokens) {
+			continue
+		}
+
+		match := true
+
+		for i := range child.path {
+			if SingularOf(child.path[i]) != tokens[i] {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// typeHeader returns the section if name is the type of the typed section,
+// eg. "storage-engine device {".
+func (n *confNode) typeHeader(name string) *confNode {
+	if name != keyType || n.parent == nil || len(n.tokens) < 3 || !isTypedSection(n.tokens[0]) {
+		return nil
+	}
+
+	return n
+}
+
+// fields returns the field lines of the name, "context" lines are the
+// fields of their context name.
+func (n *confNode) fields(name string) []*confNode {
+	fields := make([]*confNode, 0)
+
+	for _, child := range n.children {
+		if child.kind == confNodeField && child.fieldName() == name {
+			fields = append(fields, child)
+		}
+	}
+
+	return fields
+}
+
+func (n *confNode) fieldName() string {
+	if n.tokens[0] == "context" && len(n.tokens) > 2 {
+		return n.tokens[1]
+	}
+
+	return n.tokens[0]
+}
+
+func (n *confNode) valueTokens() []string {
+	if n.tokens[0] == "context" && len(n.tokens) > 2 {
+		return n.tokens[2:]
+	}
+
+	return n.tokens[1:]
+}
+
+// spans returns the byte offsets of the tokens in raw, comments excluded.
+func (n *confNode) spans() [][2]int {
+	content := strings.Split(n.raw, "#")[0]
+	spans := make([][2]int, 0, len(n.tokens))
+	start := -1
+
+	for i, r := range content {
+		switch {
+		case unicode.IsSpace(r) && start >= 0:
+			spans = append(spans, [2]int{start, i})
+			start = -1
+		case !unicode.IsSpace(r) && start < 0:
+			start = i
+		}
+	}
+
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(content)})
+	}
+
+	return spans
+}
+
+// gap returns the white space between the name and the value of a field.
+func (n *confNode) gap() string {
+	spans := n.spans()
+	if len(spans) < 2 {
+		return " "
+	}
+
+	return n.raw[spans[0][1]:spans[1][0]]
+}
+
+// setValue replaces the value of a field, or the type of a typed section,
+// keeping the indentation, spacing and comment of the line.
+func (n *confNode) setValue(value string) {
+	first := len(n.tokens) - len(n.valueTokens())
+	last := len(n.tokens) - 1
+
+	if n.kind == confNodeSection {
+		first, last = 1, 1
+	}
+
+	newTokens := append(append(append([]string{}, n.tokens[:first]...), strings.Fields(value)...),
+		n.tokens[last+1:]...)
+	if strings.Join(newTokens, " ") == strings.Join(n.tokens, " ") {
+		return
+	}
+
+	spans := n.spans()
+
+	if first < len(spans) {
+		n.raw = n.raw[:spans[first][0]] + value + n.raw[spans[last][1]:]
+	} else {
+		// eg. "enable-benchmarks-read" without value.
+		end := spans[len(spans)-1][1]
+		n.raw = n.raw[:end] + " " + value + n.raw[end:]
+	}
+
+	n.tokens = newTokens
+}
+
+// toSection turns a typed field, eg. "storage-engine memory", into a section.
+func (n *confNode) toSection() {
+	spans := n.spans()
+	end := spans[len(spans)-1][1]
+	indent := lineIndent(n.raw)
+
+	n.raw = n.raw[:end] + " {" + n.raw[end:]
+	n.closeRaw = indent + "}"
+	n.tokens = append(n.tokens, "{")
+	n.path = sectionPathTokens(n.parent.lastPathToken(), n.tokens)
+	n.kind = confNodeSection
+}
+
+// lastChild returns the last field or section of the node, the new lines
+// are added after it, before the trailing blank and comment lines.
+func (n *confNode) lastChild() *confNode {
+	for i := len(n.children) - 1; i >= 0; i-- {
+		if n.children[i].kind != confNodeText {
+			return n.children[i]
+		}
+	}
+
+	return nil
+}
+
+// insertAfter inserts child after the child after, at the start if after is nil.
+func (n *confNode) insertAfter(after, child *confNode) {
+	idx := 0
+
+	for i := range n.children {
+		if n.children[i] == after {
+			idx = i + 1
+			break
+		}
+	}
+
+	n.children = append(n.children[:idx], append([]*confNode{child}, n.children[idx:]...)...)
+}
+
+func (n *confNode) remove(child *confNode) {
+	for i := range n.children {
+		if n.children[i] == child {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			return
+		}
+	}
+}
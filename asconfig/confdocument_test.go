@@ -0,0 +1,311 @@
+package asconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfDocumentTestSuite struct {
+	suite.Suite
+}
+
+const documentTestConf = `# Aerospike database configuration file.
+
+service {
+	proto-fd-max 15000   # raised for the load tests
+}
+
+logging {
+	console {
+		context any info
+	}
+}
+
+network {
+	service {
+		port 3000
+	}
+
+	heartbeat {
+		mode mesh
+		port 3002
+		mesh-seed-address-port 10.0.0.1 3002
+	}
+
+	fabric {
+		port 3001
+	}
+}
+
+namespace test {
+	replication-factor    2
+	default-ttl           30d # one month
+	storage-engine device {
+		device /dev/xvdb
+	}
+}
+`
+
+func (s *ConfDocumentTestSuite) newDocument(text string) *ConfDocument {
+	doc, err := NewConfDocument(logr.Discard(), "aerospike.conf", strings.NewReader(text))
+	s.Require().NoError(err)
+
+	return doc
+}
+
+// changedLines returns the lines of b which are not in a, and the lines of a not in b.
+func changedLines(a, b string) (added, removed []string) {
+	count := make(map[string]int)
+	for _, l := range strings.Split(a, "\n") {
+		count[l]++
+	}
+
+	for _, l := range strings.Split(b, "\n") {
+		if count[l] > 0 {
+			count[l]--
+			continue
+		}
+
+		added = append(added, l)
+	}
+
+	for l, n := range count {
+		for ; n > 0; n-- {
+			removed = append(removed, l)
+		}
+	}
+
+	return added, removed
+}
+
+func (s *ConfDocumentTestSuite) TestRoundTrip() {
+	for _, text := range []string{
+		documentTestConf,
+		strings.TrimSuffix(documentTestConf, "\n"),
+		strings.ReplaceAll(documentTestConf, "\n", "\r\n"),
+		"",
+	} {
+		doc := s.newDocument(text)
+		s.Assert().Equal(text, string(doc.Bytes()))
+	}
+}
+
+func (s *ConfDocumentTestSuite) TestParseErrors() {
+	_, err := NewConfDocument(logr.Discard(), "aerospike.conf", strings.NewReader("service {\n    port 3000\n"))
+	s.Require().Error(err)
+
+	var parseErr *ConfParseError
+
+	s.Require().True(errors.As(err, &parseErr))
+	s.Assert().Equal("aerospike.conf:1:1", parseErr.Position.String())
+
+	_, err = NewConfDocument(logr.Discard(), "", strings.NewReader("service {\n}\n}\n"))
+	s.Require().True(errors.As(err, &parseErr))
+	s.Assert().Equal("3:1", parseErr.Position.String())
+}
+
+func (s *ConfDocumentTestSuite) TestGet() {
+	doc := s.newDocument(documentTestConf)
+
+	testCases := []struct {
+		key    string
+		values []string
+	}{
+		{"service.proto-fd-max", []string{"15000"}},
+		{"namespaces.{test}.default-ttl", []string{"30d"}},
+		{"namespace.{test}.storage-engine.type", []string{"device"}},
+		{"namespaces.{test}.storage-engine.devices", []string{"/dev/xvdb"}},
+		{"logging.{console}.any", []string{"info"}},
+		{"network.heartbeat.mesh-seed-address-port", []string{"10.0.0.1 3002"}},
+	}
+
+	for _, tc := range testCases {
+		values, ok := doc.Get(tc.key)
+		s.Assert().True(ok, tc.key)
+		s.Assert().Equal(tc.values, values, tc.key)
+	}
+
+	_, ok := doc.Get("namespace.{bar}.default-ttl")
+	s.Assert().False(ok)
+}
+
+func (s *ConfDocumentTestSuite) TestSetChangesOnlyThatLine() {
+	doc := s.newDocument(documentTestConf)
+
+	s.Require().NoError(doc.Set("service.proto-fd-max", "30000"))
+	s.Require().NoError(doc.Set("namespaces.{test}.default-ttl", "7d"))
+
+	added, removed := changedLines(documentTestConf, string(doc.Bytes()))
+	s.Assert().ElementsMatch([]string{
+		"\tproto-fd-max 30000   # raised for the load tests",
+		"\tdefault-ttl           7d # one month",
+	}, added)
+	s.Assert().ElementsMatch([]string{
+		"\tproto-fd-max 15000   # raised for the load tests",
+		"\tdefault-ttl           30d # one month",
+	}, removed)
+
+	s.Require().NoError(doc.Set("logging.{console}.any", "warning"))
+	s.Require().NoError(doc.Set("namespace.{test}.storage-engine.type", "memory"))
+
+	text := string(doc.Bytes())
+	s.Assert().Contains(text, "\t\tcontext any warning\n")
+	s.Assert().Contains(text, "\tstorage-engine memory {\n")
+}
+
+func (s *ConfDocumentTestSuite) TestSetAddsLines() {
+	doc := s.newDocument(documentTestConf)
+
+	s.Require().NoError(doc.Set("namespaces.{test}.storage-engine.devices", "/dev/xvdb", "/dev/xvdc"))
+	s.Require().NoError(doc.Set("namespace.{test}.nsup-period", "120"))
+	s.Require().NoError(doc.Set("namespace.{bar}.replication-factor", "1"))
+	s.Require().NoError(doc.Set("namespace.{bar}.storage-engine.type", "memory"))
+	s.Require().NoError(doc.Set("namespace.{bar}.storage-engine.data-size", "4G"))
+	s.Require().NoError(doc.Set("logging.{/var/log/aerospike.log}.any", "info"))
+
+	added, removed := changedLines(documentTestConf, string(doc.Bytes()))
+	s.Assert().Empty(removed)
+	s.Assert().ElementsMatch([]string{
+		"\tfile /var/log/aerospike.log {",
+		"\t\tcontext any info",
+		"\t}",
+		"\t\tdevice /dev/xvdc",
+		"\tnsup-period    120",
+		"",
+		"namespace bar {",
+		"\treplication-factor 1",
+		"\tstorage-engine memory {",
+		"\t\tdata-size 4G",
+		"\t}",
+		"}",
+	}, added)
+
+	text := string(doc.Bytes())
+	s.Assert().Contains(text, "\t\tdevice /dev/xvdb\n\t\tdevice /dev/xvdc\n")
+	s.Assert().True(strings.HasSuffix(text, "\tnsup-period    120\n}\n\nnamespace bar {\n"+
+		"\treplication-factor 1\n\tstorage-engine memory {\n\t\tdata-size 4G\n\t}\n}\n"))
+
+	cfg, err := doc.AsConfig()
+	s.Require().NoError(err)
+
+	flat := *cfg.GetFlatMap()
+	s.Assert().Equal([]string{"/dev/xvdb", "/dev/xvdc"}, flat["namespace.{test}.storage-engine.device"])
+	s.Assert().EqualValues(4294967296, flat["namespace.{bar}.storage-engine.data-size"])
+	s.Assert().Equal("info", flat["logging.{/var/log/aerospike.log}.any"])
+
+	err = doc.Set("namespace.{baz}.storage-engine.data-size", "4G")
+	s.Assert().ErrorIs(err, ErrConfigKeyInvalid)
+}
+
+func (s *ConfDocumentTestSuite) TestDelete() {
+	doc := s.newDocument(documentTestConf)
+
+	s.Require().NoError(doc.Delete("network.heartbeat.mesh-seed-address-port"))
+	s.Require().NoError(doc.Delete("logging"))
+
+	added, removed := changedLines(documentTestConf, string(doc.Bytes()))
+	s.Assert().Empty(added)
+	s.Assert().ElementsMatch([]string{
+		"\t\tmesh-seed-address-port 10.0.0.1 3002",
+		"logging {", "\tconsole {", "\t\tcontext any info", "\t}", "}",
+	}, removed)
+
+	s.Assert().ErrorIs(doc.Delete("service.cluster-name"), ErrConfigKeyInvalid)
+	s.Assert().ErrorIs(doc.Delete("namespace.{test}.storage-engine.type"), ErrConfigKeyInvalid)
+	s.Assert().ErrorIs(doc.Delete("namespace.{foo}"), ErrConfigKeyInvalid)
+}
+
+// documentTestBarLines are the lines of the namespace bar of documentTestConfBar.
+var documentTestBarLines = []string{
+	"namespace bar {", "\treplication-factor 1", "\tstorage-engine memory {", "\t\tdata-size 1G", "\t}", "}",
+}
+
+// documentTestConfBar is documentTestConf with a second namespace.
+const documentTestConfBar = documentTestConf + `
+namespace bar {
+	replication-factor 1
+	storage-engine memory {
+		data-size 1G
+	}
+}
+`
+
+func (s *ConfDocumentTestSuite) TestDeleteSection() {
+	for _, key := range []string{"namespace.{bar}", "namespaces.{bar}"} {
+		s.Run(key, func() {
+			doc := s.newDocument(documentTestConfBar)
+			s.Require().NoError(doc.Delete(key))
+
+			added, removed := changedLines(documentTestConfBar, string(doc.Bytes()))
+			s.Assert().Empty(added)
+			s.Assert().ElementsMatch(documentTestBarLines, removed)
+		})
+	}
+
+	doc := s.newDocument(documentTestConfBar)
+	s.Require().NoError(doc.Delete("namespace.{test}.storage-engine"))
+
+	_, removed := changedLines(documentTestConfBar, string(doc.Bytes()))
+	s.Assert().ElementsMatch([]string{"	storage-engine device {", "		device /dev/xvdb", "	}"}, removed)
+}
+
+func (s *ConfDocumentTestSuite) TestUpdate() {
+	doc := s.newDocument(documentTestConf)
+
+	cfg, err := doc.AsConfig()
+	s.Require().NoError(err)
+
+	flat := *cfg.GetFlatMap()
+	flat["namespace.{test}.replication-factor"] = 3
+	flat["network.heartbeat.mesh-seed-address-port"] = []string{"10.0.0.1:3002", "10.0.0.2:3002"}
+	flat["service.cluster-name"] = "prod"
+	delete(flat, "network.fabric.port")
+
+	updated, err := NewMapAsConfig(logr.Discard(), expandConf(logr.Discard(), &flat, sep))
+	s.Require().NoError(err)
+	s.Require().NoError(doc.Update(updated))
+
+	added, removed := changedLines(documentTestConf, string(doc.Bytes()))
+	s.Assert().ElementsMatch([]string{
+		"\treplication-factor    3",
+		"\t\tmesh-seed-address-port 10.0.0.2 3002",
+		"\tcluster-name prod",
+	}, added)
+	s.Assert().ElementsMatch([]string{
+		"\treplication-factor    2",
+		"\t\tport 3001",
+	}, removed)
+
+	// unchanged values keep their conf file form, eg. 30d
+	s.Assert().Contains(string(doc.Bytes()), "30d # one month")
+}
+
+func (s *ConfDocumentTestSuite) TestUpdateRemovesSection() {
+	doc := s.newDocument(documentTestConfBar)
+
+	cfg, err := doc.AsConfig()
+	s.Require().NoError(err)
+
+	flat := *cfg.GetFlatMap()
+	for k := range flat {
+		if strings.HasPrefix(k, "namespace.{bar}") {
+			delete(flat, k)
+		}
+	}
+
+	updated, err := NewMapAsConfig(logr.Discard(), expandConf(logr.Discard(), &flat, sep))
+	s.Require().NoError(err)
+	s.Require().NoError(doc.Update(updated))
+
+	added, removed := changedLines(documentTestConfBar, string(doc.Bytes()))
+	s.Assert().Empty(added)
+	s.Assert().ElementsMatch(documentTestBarLines, removed)
+}
+
+func TestConfDocumentTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfDocumentTestSuite))
+}
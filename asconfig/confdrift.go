@@ -0,0 +1,337 @@
+package asconfig
+
+import (
+	"fmt"
+	"sort"
+
+	sets "github.com/deckarep/golang-set/v2"
+	"github.com/go-logr/logr"
+
+	aero "github.com/aerospike/aerospike-client-go/v8"
+	"github.com/aerospike/aerospike-management-lib/deployment"
+	"github.com/aerospike/aerospike-management-lib/info"
+)
+
+// DriftOptions are the options of DetectDrift.
+type DriftOptions struct {
+	// Desired is the config the nodes are compared with. If nil, every key
+	// of a node is compared with the value of the majority of the nodes.
+	Desired *AsConfig
+	// IgnoreKeys are the keys not compared, by base key, eg. "rack-id".
+	IgnoreKeys []string
+	// IgnoreNodeSpecific ignores the node specific contexts, eg. logging.
+	// The node specific fields, eg. address or devices, are always ignored,
+	// see ConfDiff.
+	IgnoreNodeSpecific bool
+}
+
+// NodeDrift is the drift of the config of a node.
+type NodeDrift struct {
+	// Diff brings the node back to the reference config, see ConfDiff.
+	Diff DynamicConfigMap
+	// Commands are the set-config commands applying the dynamic keys of Diff.
+	Commands []string
+	// StaticKeys are the keys of Diff which cannot be applied with
+	// set-config, see ClassifyDiff. They need a restart of the node.
+	StaticKeys []string
+	HostID     string
+	Version    string
+}
+
+// DriftReport is the result of DetectDrift.
+type DriftReport struct {
+	Nodes map[string]*NodeDrift
+	// Unresolved are the keys which no majority of the nodes agree on when
+	// the nodes are compared with the majority. They are not in the diffs.
+	Unresolved []string
+}
+
+// DriftedNodes returns the sorted ids of the nodes whose config drifted.
+func (r *DriftReport) DriftedNodes() []string {
+	drifted := make([]string, 0)
+
+	for id, node := range r.Nodes {
+		if len(node.Diff) > 0 {
+			drifted = append(drifted, id)
+		}
+	}
+
+	sort.Strings(drifted)
+
+	return drifted
+}
+
+// nodeDriftClient generates the config of a single node and the set-config
+// commands remediating its drift. It is injected for testability.
+type nodeDriftClient interface {
+	generateConf() (*GenConf, error)
+	createCommands(configMap DynamicConfigMap, version string) ([]string, error)
+}
+
+// hostDriftClient is the nodeDriftClient backed by a live aerospike host.
+type hostDriftClient struct {
+	log      logr.Logger
+	policy   *aero.ClientPolicy
+	host     *deployment.HostConn
+	registry *SchemaRegistry
+}
+
+func (c *hostDriftClient) generateConf() (*GenConf, error) {
+	asInfo := info.NewAsInfo(c.log, &aero.Host{
+		Name:    c.host.ASConn.AerospikeHostName,
+		Port:    c.host.ASConn.AerospikePort,
+		TLSName: c.host.ASConn.AerospikeTLSName,
+	}, c.policy)
+
+	defer func() {
+		if err := asInfo.Close(); err != nil {
+			c.log.V(1).Info("Failed to close node connection", "err", err)
+		}
+	}()
+
+	return c.registry.GenerateConf(c.log, asInfo, true)
+}
+
+func (c *hostDriftClient) createCommands(configMap DynamicConfigMap, version string) ([]string, error) {
+	return CreateSetConfigCmdListWithBuildVersion(c.log, configMap, c.host.ASConn, c.policy, version)
+}
+
+// DetectDrift fetches the config of all the given hosts and compares it with
+// the desired config of opts, or with the config of the majority of the
+// hosts. The config of a host is normalized with GenerateConf, without the
+// default values, and the keys of the desired config set to their default
+// value are removed too.
+//
+// The report has the diff of every host with the set-config commands
+// remediating its dynamic keys.
+func DetectDrift(log logr.Logger, policy *aero.ClientPolicy, hosts []*deployment.HostConn,
+	opts *DriftOptions,
+) (*DriftReport, error) {
+	return defaultRegistry.DetectDrift(log, policy, hosts, opts)
+}
+
+// DetectDrift is DetectDrift using the schemas of the registry.
+func (r *SchemaRegistry) DetectDrift(log logr.Logger, policy *aero.ClientPolicy, hosts []*deployment.HostConn,
+	opts *DriftOptions,
+) (*DriftReport, error) {
+	clients := make(map[string]nodeDriftClient, len(hosts))
+	hostIDs := make([]string, 0, len(hosts))
+
+	for _, h := range hosts {
+		clients[h.ID] = &hostDriftClient{
+			log:      log.WithValues("node", h.ID),
+			policy:   policy,
+			host:     h,
+			registry: r,
+		}
+		hostIDs = append(hostIDs, h.ID)
+	}
+
+	return r.detectDrift(log, hostIDs, clients, opts)
+}
+
+func (r *SchemaRegistry) detectDrift(log logr.Logger, hostIDs []string, clients map[string]nodeDriftClient,
+	opts *DriftOptions,
+) (*DriftReport, error) {
+	if opts == nil {
+		opts = &DriftOptions{}
+	}
+
+	flatConfs := make(map[string]Conf, len(hostIDs))
+	versions := make(map[string]string, len(hostIDs))
+
+	for _, id := range hostIDs {
+		genConf, err := clients[id].generateConf()
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate config of node %s: %w", id, err)
+		}
+
+		// Loaded as an AsConfig for the values to have the same types as
+		// the ones of the desired config.
+		cfg, err := NewMapAsConfig(log, genConf.Conf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config of node %s: %w", id, err)
+		}
+
+		flatConfs[id] = *cfg.GetFlatMap()
+		versions[id] = genConf.Version
+	}
+
+	report := &DriftReport{
+		Nodes: make(map[string]*NodeDrift, len(hostIDs)),
+	}
+
+	var desired Conf
+
+	if opts.Desired != nil {
+		desired = *opts.Desired.GetFlatMap()
+	} else {
+		desired, report.Unresolved = majorityConf(log, hostIDs, flatConfs)
+	}
+
+	ignored := sets.NewSet(opts.IgnoreKeys...)
+	ignored.Append(report.Unresolved...)
+
+	// the desired config without its default values by version, as the
+	// configs of the nodes
+	desiredByVersion := make(map[string]Conf)
+
+	for _, id := range hostIDs {
+		nodeDesired := desired
+
+		if opts.Desired != nil {
+			var ok bool
+
+			if nodeDesired, ok = desiredByVersion[versions[id]]; !ok {
+				var err error
+
+				if nodeDesired, err = r.withoutDefaults(log, desired, versions[id]); err != nil {
+					return nil, fmt.Errorf("failed to remove default values of desired config: %w", err)
+				}
+
+				desiredByVersion[versions[id]] = nodeDesired
+			}
+		}
+
+		diff, err := r.ConfDiff(log, nodeDesired, flatConfs[id], true, versions[id])
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff config of node %s: %w", id, err)
+		}
+
+		for k := range diff {
+			if ignored.Contains(k) || ignored.Contains(BaseKey(k)) ||
+				(opts.IgnoreNodeSpecific && isNodeSpecificContext(k)) {
+				delete(diff, k)
+			}
+		}
+
+		node := &NodeDrift{
+			HostID:     id,
+			Version:    versions[id],
+			Diff:       diff,
+			StaticKeys: make([]string, 0),
+		}
+
+		changes, err := r.ClassifyDiff(log, diff, flatConfs[id], versions[id])
+		if err != nil {
+			return nil, fmt.Errorf("failed to classify drift of node %s: %w", id, err)
+		}
+
+		for _, c := range changes.Changes {
+			if c.Class != ChangeDynamic {
+				node.StaticKeys = append(node.StaticKeys, c.Key)
+			}
+		}
+
+		if dynamic := changes.DynamicConfigMap(ChangeDynamic); len(dynamic) > 0 {
+			node.Commands, err = clients[id].createCommands(dynamic, versions[id])
+			if err != nil {
+				return nil, fmt.Errorf("failed to create set-config commands for node %s: %w", id, err)
+			}
+		}
+
+		report.Nodes[id] = node
+	}
+
+	return report, nil
+}
+
+// withoutDefaults returns the flat config without the keys set to their
+// default value in the config schema of the version, as the configs
+// generated by GenerateConf without the default values.
+func (r *SchemaRegistry) withoutDefaults(log logr.Logger, flatConf Conf, ver string) (Conf, error) {
+	defaults, err := r.GetDefault(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizer, err := r.NewValueNormalizer(log, ver)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(Conf, len(flatConf))
+
+	for k, v := range flatConf {
+		if def, ok := defaults[GetFlatKey(SplitKey(log, k, sep))]; ok && normalizer.IsValueEqual(k, def, v) {
+			continue
+		}
+
+		res[k] = v
+	}
+
+	return res, nil
+}
+
+// majorityConf returns the flat config holding the value of every key set
+// by more than half of the nodes. A key which is not set by more than half
+// of the nodes is not in the config. The keys without such a majority are
+// returned sorted as unresolved.
+func majorityConf(log logr.Logger, hostIDs []string, flatConfs map[string]Conf) (majority Conf,
+	unresolved []string) {
+	majority = make(Conf)
+	unresolved = make([]string, 0)
+
+	keys := sets.NewSet[string]()
+	for _, id := range hostIDs {
+		for k := range flatConfs[id] {
+			keys.Add(k)
+		}
+	}
+
+	for k := range keys.Iter() {
+		var (
+			values []interface{}
+			counts []int
+		)
+
+		for _, id := range hostIDs {
+			v, ok := flatConfs[id][k]
+			if !ok {
+				continue
+			}
+
+			found := false
+
+			for i := range values {
+				if !isClusterValueDiff(log, values[i], v) {
+					counts[i]++
+					found = true
+
+					break
+				}
+			}
+
+			if !found {
+				values = append(values, v)
+				counts = append(counts, 1)
+			}
+		}
+
+		resolved := false
+		set := 0
+
+		for i := range values {
+			set += counts[i]
+
+			if counts[i]*2 > len(hostIDs) {
+				majority[k] = values[i]
+				resolved = true
+			}
+		}
+
+		// unset on the majority of the nodes
+		if (len(hostIDs)-set)*2 > len(hostIDs) {
+			resolved = true
+		}
+
+		// ConfDiff ignores the node specific fields
+		if !resolved && BaseKey(k) != keyIndex && !isNodeSpecificField(BaseKey(k)) {
+			unresolved = append(unresolved, k)
+		}
+	}
+
+	sort.Strings(unresolved)
+
+	return majority, unresolved
+}
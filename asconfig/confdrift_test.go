@@ -0,0 +1,166 @@
+package asconfig
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+// fakeDriftClient generates the config of a node from a ConfGetter.
+type fakeDriftClient struct {
+	getter ConfGetter
+}
+
+func (c *fakeDriftClient) generateConf() (*GenConf, error) {
+	return GenerateConf(logr.Discard(), c.getter, true)
+}
+
+func (c *fakeDriftClient) createCommands(configMap DynamicConfigMap, version string) ([]string, error) {
+	return CreateSetConfigCmdListWithBuildVersion(logr.Discard(), configMap, nil, nil, version)
+}
+
+type ConfDriftTestSuite struct {
+	suite.Suite
+	ctrl *gomock.Controller
+}
+
+func (s *ConfDriftTestSuite) SetupSuite() {
+	InitFromMap(logr.Discard(), testSchemas)
+}
+
+func (s *ConfDriftTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+}
+
+func (s *ConfDriftTestSuite) clients(configs map[string]Conf) (hostIDs []string, clients map[string]nodeDriftClient) {
+	clients = make(map[string]nodeDriftClient, len(configs))
+
+	for id, conf := range configs {
+		getter := NewMockConfGetter(s.ctrl)
+		getter.EXPECT().AllConfigs().Return(conf, nil)
+		getter.EXPECT().GetAsInfo("metadata").Return(Conf{"metadata": Conf{"asd_build": "7.0.0.1"}}, nil)
+
+		clients[id] = &fakeDriftClient{getter: getter}
+		hostIDs = append(hostIDs, id)
+	}
+
+	return hostIDs, clients
+}
+
+func driftTestConfigs() map[string]Conf {
+	b1 := clusterTestConfigs("B1", "10.0.0.2", 15000, "/dev/xvdc")
+	b1["namespaces"].(Conf)["test"].(Conf)["rack-id"] = int64(1)
+
+	return map[string]Conf{
+		"A1": clusterTestConfigs("A1", "10.0.0.1", 15000, "/dev/xvdb"),
+		"B1": b1,
+		"C1": clusterTestConfigs("C1", "10.0.0.3", 20000, "/dev/xvdb"),
+	}
+}
+
+func (s *ConfDriftTestSuite) TestDetectDriftMajority() {
+	hostIDs, clients := s.clients(driftTestConfigs())
+
+	report, err := defaultRegistry.detectDrift(logr.Discard(), hostIDs, clients, nil)
+	s.Require().NoError(err)
+
+	s.Assert().Equal([]string{"B1", "C1"}, report.DriftedNodes())
+	s.Assert().Empty(report.Unresolved)
+
+	c1 := report.Nodes["C1"]
+	s.Assert().Equal("7.0.0.1", c1.Version)
+	s.Assert().Equal(DynamicConfigMap{"service.proto-fd-max": {Update: uint64(15000)}}, c1.Diff)
+	s.Assert().Equal([]string{"set-config:context=service;proto-fd-max=15000"}, c1.Commands)
+	s.Assert().Empty(c1.StaticKeys)
+
+	b1 := report.Nodes["B1"]
+	s.Assert().Contains(b1.Diff, "namespaces.{test}.rack-id")
+	s.Assert().Equal([]string{"namespaces.{test}.rack-id"}, b1.StaticKeys)
+	s.Assert().Empty(b1.Commands)
+}
+
+func (s *ConfDriftTestSuite) TestDetectDriftIgnoreKeys() {
+	hostIDs, clients := s.clients(driftTestConfigs())
+
+	report, err := defaultRegistry.detectDrift(logr.Discard(), hostIDs, clients, &DriftOptions{
+		IgnoreKeys: []string{"rack-id", "service.proto-fd-max"},
+	})
+	s.Require().NoError(err)
+	s.Assert().Empty(report.DriftedNodes())
+}
+
+func (s *ConfDriftTestSuite) TestDetectDriftDesired() {
+	testCases := []struct {
+		name        string
+		protoFdMax  int64
+		drifted     []string
+		diff        DynamicConfigMap
+		commands    []string
+		commandNode string
+	}{
+		{
+			name:        "non default value",
+			protoFdMax:  20000,
+			drifted:     []string{"A1", "B1"},
+			diff:        DynamicConfigMap{"service.proto-fd-max": {Update: uint64(20000)}},
+			commands:    []string{"set-config:context=service;proto-fd-max=20000"},
+			commandNode: "B1",
+		},
+		{
+			// the node configs are generated without the default values
+			name:        "default value",
+			protoFdMax:  15000,
+			drifted:     []string{"C1"},
+			diff:        DynamicConfigMap{"service.proto-fd-max": {Update: uint64(15000)}},
+			commands:    []string{"set-config:context=service;proto-fd-max=15000"},
+			commandNode: "C1",
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			hostIDs, clients := s.clients(driftTestConfigs())
+
+			desired, err := NewMapAsConfig(logr.Discard(), Conf{
+				"service": Conf{"cluster-name": "prod", "proto-fd-max": tc.protoFdMax},
+				"network": Conf{
+					"service":   Conf{"port": int64(3000)},
+					"heartbeat": Conf{"mode": "mesh", "port": int64(3002)},
+					"fabric":    Conf{"port": int64(3001)},
+				},
+				"namespaces": []Conf{
+					{"name": "bar", "storage-engine": Conf{"type": "memory", "data-size": int64(4294967296)}},
+					{"name": "test", "storage-engine": Conf{"type": "device", "devices": []string{"/dev/xvdb"}}},
+				},
+			})
+			s.Require().NoError(err)
+
+			report, err := defaultRegistry.detectDrift(logr.Discard(), hostIDs, clients, &DriftOptions{
+				Desired:    desired,
+				IgnoreKeys: []string{"rack-id"},
+			})
+			s.Require().NoError(err)
+
+			s.Assert().Equal(tc.drifted, report.DriftedNodes())
+			s.Assert().Equal(tc.diff, report.Nodes[tc.drifted[0]].Diff)
+			s.Assert().Equal(tc.commands, report.Nodes[tc.commandNode].Commands)
+		})
+	}
+}
+
+func (s *ConfDriftTestSuite) TestMajorityConfUnresolved() {
+	flatConfs := map[string]Conf{
+		"A1": {"service.proto-fd-max": 1000, "service.cluster-name": "prod"},
+		"B1": {"service.proto-fd-max": 2000, "service.cluster-name": "prod"},
+	}
+
+	majority, unresolved := majorityConf(logr.Discard(), []string{"A1", "B1"}, flatConfs)
+	s.Assert().Equal(Conf{"service.cluster-name": "prod"}, majority)
+	s.Assert().Equal([]string{"service.proto-fd-max"}, unresolved)
+}
+
+func TestConfDriftTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfDriftTestSuite))
+}
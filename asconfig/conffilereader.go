@@ -8,7 +8,6 @@
 package asconfig
 
 import (
-	"bufio"
 	"regexp"
 	"strconv"
 	"strings"
@@ -59,7 +58,7 @@ func toList(conf Conf) []Conf {
 }
 
 func processSection(
-	log logr.Logger, tok []string, scanner *bufio.Scanner, conf Conf,
+	log logr.Logger, tok []string, scanner *confScanner, conf Conf,
 ) error {
 	cfgName := tok[0]
 	// Unnamed Sections are simply processed as Map except special sections like logging
@@ -127,22 +126,30 @@ func addToStrList(conf Conf, cfgName, val string) {
 	}
 }
 
-func writeConf(log logr.Logger, tok []string, conf Conf) error {
+func writeConf(log logr.Logger, tok []string, scanner *confScanner, conf Conf) error {
 	cfgName := tok[0]
 
+	if cfgName == "context" && len(tok) > 1 {
+		scanner.record(tok[1], tok[1])
+	} else {
+		scanner.record(cfgName, cfgName)
+	}
+
 	// Handle special case for tls-authentication-client which can be a list
 	// or a string depending on its value
 	if cfgName == keyTLSAuthenticateClient {
 		if len(tok) < 2 {
 			log.Error(ErrConfigParse, "tls-authenticate-client requires a value")
-			return ErrConfigParse
+			return scanner.errorf(cfgName, "tls-authenticate-client requires a value")
 		}
 
 		v := strings.ToLower(tok[1])
 		if v == "false" || v == "any" {
 			if _, ok := conf[cfgName]; ok {
 				log.Error(ErrConfigParse, "tls-authenticate-client must only use 'any', 'false', or one or more subject names")
-				return ErrConfigParse
+
+				return scanner.errorf(tok[1],
+					"tls-authenticate-client must only use 'any', 'false', or one or more subject names")
 			}
 
 			conf[cfgName] = tok[1]
@@ -181,7 +188,14 @@ func writeConf(log logr.Logger, tok []string, conf Conf) error {
 
 	// Handle human readable content
 	if ok, humanizeFn := isSizeOrTime(cfgName); ok {
+		// a ${VAR} reference is kept for the expansion of the Resolver
+		if varRe.MatchString(tok[1]) {
+			conf[cfgName] = tok[1]
+			return nil
+		}
+
 		conf[cfgName], _ = humanizeFn(tok[1])
+
 		return nil
 	}
 	// More special Case handling
@@ -229,7 +243,7 @@ func parseValue(k string, val interface{}) interface{} {
 	return valStr
 }
 
-func process(log logr.Logger, scanner *bufio.Scanner, conf Conf) (Conf, error) {
+func process(log logr.Logger, scanner *confScanner, conf Conf) (Conf, error) {
 	for scanner.Scan() {
 		line := parseLine(scanner.Text())
 		if line == "" {
@@ -241,13 +255,14 @@ func process(log logr.Logger, scanner *bufio.Scanner, conf Conf) (Conf, error) {
 		// Zero tokens
 		if len(tok) == 0 {
 			log.Error(ErrConfigParse, "Config file line has 0 tokens")
-			return nil, ErrConfigParse
+			return nil, scanner.errorf("", "config file line has 0 tokens")
 		}
 
 		lastToken := tok[len(tok)-1]
 		if lastToken != "{" && strings.HasSuffix(lastToken, "{") {
 			log.Error(ErrConfigParse, "Config file items must have a space between them and '{' ", "token", lastToken)
-			return nil, ErrConfigParse
+
+			return nil, scanner.errorf(lastToken, "config file items must have a space between them and '{'")
 		}
 
 		// End of Section
@@ -261,22 +276,27 @@ func process(log logr.Logger, scanner *bufio.Scanner, conf Conf) (Conf, error) {
 			// if enable benchmark presence is
 			// enable
 			if isSpecialBoolField(tok[0]) || isSpecialOrNormalBoolField(tok[0]) {
+				scanner.record(tok[0], tok[0])
 				conf[tok[0]] = true
+
 				continue
 			}
 
 			log.Error(ErrConfigParse, "Config file line has  < 2 tokens:", "token", tok)
 
-			return nil, ErrConfigParse
+			return nil, scanner.errorf(tok[0], "%s requires a value", tok[0])
 		}
 
 		// Start section
 		if tok[len(tok)-1] == "{" {
+			n := scanner.enterSection(tok)
 			if err := processSection(log, tok, scanner, conf); err != nil {
 				return nil, err
 			}
+
+			scanner.leaveSection(n)
 		} else {
-			if err := writeConf(log, tok, conf); err != nil {
+			if err := writeConf(log, tok, scanner, conf); err != nil {
 				return nil, err
 			}
 		}
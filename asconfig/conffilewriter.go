@@ -31,18 +31,31 @@ func indentString(indent int) string {
 	return strings.Repeat(" ", indent*4)
 }
 
+// sectionLine returns the opening line of a section, without the newline.
+// The conf file lines are rendered by sectionLine, sectionEndLine and
+// fieldLine, both by ToConfFile and by FormatConf.
+func sectionLine(indent int, name ...string) string {
+	return indentString(indent) + strings.Join(name, " ") + " {"
+}
+
+// sectionEndLine returns the closing line of a section, without the newline.
+func sectionEndLine(indent int) string {
+	return indentString(indent) + "}"
+}
+
+// fieldLine returns the line of a field, without the newline.
+func fieldLine(indent int, key, value string) string {
+	return indentString(indent) + key + "    " + value
+}
+
 func beginSection(
 	_ logr.Logger, buf *bytes.Buffer, indent int, name ...string,
 ) {
-	buf.WriteString(
-		"\n" + indentString(indent) + strings.Join(
-			name, " ",
-		) + " {\n",
-	)
+	buf.WriteString("\n" + sectionLine(indent, name...) + "\n")
 }
 
 func endSection(buf *bytes.Buffer, indent int) {
-	buf.WriteString(strings.Repeat(" ", indent*4) + "}\n")
+	buf.WriteString(sectionEndLine(indent) + "\n")
 }
 
 func writeSimpleSection(
@@ -188,14 +201,10 @@ func writeListField(
 ) {
 	key = SingularOf(key)
 	if sep != "" {
-		buf.WriteString(
-			indentString(indent) + key + "    " + strings.ReplaceAll(
-				value, sep, " ",
-			) + "\n",
-		)
-	} else {
-		buf.WriteString(indentString(indent) + key + "    " + value + "\n")
+		value = strings.ReplaceAll(value, sep, " ")
 	}
+
+	buf.WriteString(fieldLine(indent, key, value) + "\n")
 }
 
 func writeField(buf *bytes.Buffer, key, value string, indent int) {
@@ -216,7 +225,7 @@ func writeField(buf *bytes.Buffer, key, value string, indent int) {
 		}
 	}
 
-	buf.WriteString(indentString(indent) + key + "    " + value + "\n")
+	buf.WriteString(fieldLine(indent, key, value) + "\n")
 }
 
 func writeKeys(
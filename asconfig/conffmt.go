@@ -0,0 +1,263 @@
+package asconfig
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	"github.com/aerospike/aerospike-management-lib/info"
+)
+
+// confSectionOrder is the canonical order of the top level sections of an
+// aerospike.conf. Other sections are kept after them in their order.
+var confSectionOrder = []string{
+	info.ConfigServiceContext,
+	info.ConfigLoggingContext,
+	info.ConfigNetworkContext,
+	info.ConfigSecurityContext,
+	info.ConfigXDRContext,
+	keyNamespace,
+	"mod-lua",
+}
+
+// FormatOptions are the options of FormatConf.
+type FormatOptions struct {
+	// HumanizeValues writes the byte sizes and times with the largest unit
+	// which divides them, eg. "4294967296" and "4096M" are written as "4G".
+	HumanizeValues bool
+}
+
+// FormatConf returns the aerospike.conf text in src in its canonical form:
+//   - the top level sections are in the order service, logging, network,
+//     security, xdr, namespaces, mod-lua,
+//   - lines are indented by 4 spaces per section level and names and values
+//     are separated by 4 spaces, other tokens by one space. The lines are
+//     rendered by the conffilewriter helpers used by ToConfFile,
+//   - sections are preceded by a blank line, other blank lines are collapsed
+//     and removed at the start and end of the sections.
+//
+// The lines are tokenized by the conffilereader parseLine. The order within
+// the sections is kept. Comments are kept, the comments right above a top
+// level section move with it.
+func FormatConf(log logr.Logger, src []byte, opts FormatOptions) ([]byte, error) {
+	doc, err := NewConfDocument(log, "", bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+
+	doc.Format(opts)
+
+	return doc.Bytes(), nil
+}
+
+// CheckConfFormat returns true if the aerospike.conf text in src is already
+// in the canonical form of FormatConf.
+func CheckConfFormat(log logr.Logger, src []byte, opts FormatOptions) (bool, error) {
+	formatted, err := FormatConf(log, src, opts)
+	if err != nil {
+		return false, err
+	}
+
+	return bytes.Equal(src, formatted), nil
+}
+
+// Format rewrites the document in the canonical form of FormatConf.
+func (d *ConfDocument) Format(opts FormatOptions) {
+	d.indent = indentString(1)
+	d.gap = indentString(1)
+	d.finalNewline = true
+	d.root.children = orderTopLevelSections(d.root.children)
+
+	formatChildren(d.root, 0, opts)
+}
+
+// orderTopLevelSections sorts the top level sections in confSectionOrder.
+// The text lines before a section move with it, except the header of the
+// file, the text lines before the first section up to the last blank line.
+func orderTopLevelSections(children []*confNode) []*confNode {
+	type block struct {
+		nodes []*confNode
+		rank  int
+	}
+
+	var (
+		header  []*confNode
+		blocks  []block
+		pending []*confNode
+	)
+
+	for _, child := range children {
+		if child.kind == confNodeText {
+			pending = append(pending, child)
+			continue
+		}
+
+		if blocks == nil {
+			for i := len(pending) - 1; i >= 0; i-- {
+				if isBlankNode(pending[i]) {
+					header = pending[:i+1]
+					pending = pending[i+1:]
+
+					break
+				}
+			}
+		}
+
+		blocks = append(blocks, block{nodes: append(pending, child), rank: sectionRank(child.tokens[0])})
+		pending = nil
+	}
+
+	sort.SliceStable(blocks, func(i, j int) bool {
+		return blocks[i].rank < blocks[j].rank
+	})
+
+	res := make([]*confNode, 0, len(children))
+	res = append(res, header...)
+
+	for _, b := range blocks {
+		res = append(res, b.nodes...)
+	}
+
+	return append(res, pending...)
+}
+
+func sectionRank(name string) int {
+	name = SingularOf(name)
+
+	for i, section := range confSectionOrder {
+		if SingularOf(section) == name {
+			return i
+		}
+	}
+
+	return len(confSectionOrder)
+}
+
+func isBlankNode(n *confNode) bool {
+	return n.kind == confNodeText && parseLine(n.raw) == "" && lineComment(n.raw) == ""
+}
+
+// lineComment returns the comment of the line, from '#' to the end of the line.
+func lineComment(raw string) string {
+	idx := strings.Index(raw, "#")
+	if idx < 0 {
+		return ""
+	}
+
+	return strings.TrimSpace(raw[idx:])
+}
+
+func formatChildren(n *confNode, depth int, opts FormatOptions) {
+	children := make([]*confNode, 0, len(n.children))
+
+	for _, child := range n.children {
+		if isBlankNode(child) {
+			// collapse blank lines, none at the start of the section
+			if len(children) == 0 || isBlankNode(children[len(children)-1]) {
+				continue
+			}
+
+			child.raw = ""
+			children = append(children, child)
+
+			continue
+		}
+
+		if child.kind == confNodeSection {
+			children = insertBlankBeforeComments(n, children)
+		}
+
+		child.raw = formatLine(child, depth, opts)
+
+		if child.kind == confNodeSection {
+			formatChildren(child, depth+1, opts)
+			child.closeRaw = withComment(sectionEndLine(depth), lineComment(child.closeRaw))
+		}
+
+		children = append(children, child)
+	}
+
+	// no blank lines at the end of the section
+	for len(children) > 0 && isBlankNode(children[len(children)-1]) {
+		children = children[:len(children)-1]
+	}
+
+	n.children = children
+}
+
+// insertBlankBeforeComments adds a blank line before the comment lines at
+// the end of children, if they are not at the start of the section.
+func insertBlankBeforeComments(parent *confNode, children []*confNode) []*confNode {
+	idx := len(children)
+	for idx > 0 && children[idx-1].kind == confNodeText && !isBlankNode(children[idx-1]) {
+		idx--
+	}
+
+	if idx == 0 || isBlankNode(children[idx-1]) {
+		return children
+	}
+
+	blank := &confNode{parent: parent, kind: confNodeText}
+
+	return append(children[:idx], append([]*confNode{blank}, children[idx:]...)...)
+}
+
+func formatLine(n *confNode, depth int, opts FormatOptions) string {
+	comment := lineComment(n.raw)
+
+	switch n.kind {
+	case confNodeText:
+		return indentString(depth) + comment
+
+	case confNodeSection:
+		return withComment(sectionLine(depth, n.tokens[:len(n.tokens)-1]...), comment)
+
+	case confNodeField:
+		values := n.valueTokens()
+		name := strings.Join(n.tokens[:len(n.tokens)-len(values)], " ")
+
+		if len(values) == 0 {
+			return withComment(indentString(depth)+name, comment)
+		}
+
+		if opts.HumanizeValues && len(values) == 1 {
+			values = []string{humanizeValue(n.fieldName(), values[0])}
+			n.tokens = append(n.tokens[:len(n.tokens)-1], values[0])
+		}
+
+		return withComment(fieldLine(depth, name, strings.Join(values, " ")), comment)
+	}
+
+	return n.raw
+}
+
+// humanizeValue returns the value of a byte size or time field with the
+// largest unit dividing it. Other values, eg. the counts which accept the
+// size units such as stop-writes-count, are returned as is.
+func humanizeValue(name, value string) string {
+	ok, deHumanize := isSizeOrTime(name)
+	if !ok || isSizeCountField(name) {
+		return value
+	}
+
+	val, err := deHumanize(value)
+	if err != nil {
+		return value
+	}
+
+	if isTimeField(name) {
+		return humanizeTime(val)
+	}
+
+	return humanizeSize(val)
+}
+
+func withComment(line, comment string) string {
+	if comment == "" {
+		return line
+	}
+
+	return line + " " + comment
+}
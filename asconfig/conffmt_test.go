@@ -0,0 +1,152 @@
+package asconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfFormatTestSuite struct {
+	suite.Suite
+}
+
+const unformattedConf = `# Aerospike database configuration file.
+
+
+namespace test {
+  replication-factor 2
+	default-ttl 2592000   # one month
+  storage-engine   device {
+      device /dev/xvdb
+      write-block-size 1048576
+
+
+      filesize 4096M
+  }
+
+}
+# network comes first
+network {
+	service {
+		port 3000
+	}
+	heartbeat {
+		mode mesh
+	}
+}
+logging {
+	console {
+		context any info
+	}
+}
+
+service {
+	proto-fd-max 15000
+}
+`
+
+const formattedConf = `# Aerospike database configuration file.
+
+service {
+    proto-fd-max    15000
+}
+
+logging {
+    console {
+        context any    info
+    }
+}
+
+# network comes first
+network {
+    service {
+        port    3000
+    }
+
+    heartbeat {
+        mode    mesh
+    }
+}
+
+namespace test {
+    replication-factor    2
+    default-ttl    2592000 # one month
+
+    storage-engine device {
+        device    /dev/xvdb
+        write-block-size    1048576
+
+        filesize    4096M
+    }
+}
+`
+
+func (s *ConfFormatTestSuite) TestFormatConf() {
+	formatted, err := FormatConf(logr.Discard(), []byte(unformattedConf), FormatOptions{})
+	s.Require().NoError(err)
+	s.Assert().Equal(formattedConf, string(formatted))
+
+	// formatting is idempotent
+	again, err := FormatConf(logr.Discard(), formatted, FormatOptions{})
+	s.Require().NoError(err)
+	s.Assert().Equal(formattedConf, string(again))
+
+	// the config is unchanged
+	before, err := FromConfFile(logr.Discard(), strings.NewReader(unformattedConf))
+	s.Require().NoError(err)
+
+	after, err := FromConfFile(logr.Discard(), strings.NewReader(string(formatted)))
+	s.Require().NoError(err)
+	s.Assert().Equal(*before.GetFlatMap(), *after.GetFlatMap())
+}
+
+func (s *ConfFormatTestSuite) TestFormatConfHumanize() {
+	formatted, err := FormatConf(logr.Discard(), []byte(unformattedConf), FormatOptions{HumanizeValues: true})
+	s.Require().NoError(err)
+
+	text := string(formatted)
+	s.Assert().Contains(text, "    default-ttl    30d # one month\n")
+	s.Assert().Contains(text, "        write-block-size    1M\n")
+	s.Assert().Contains(text, "        filesize    4G\n")
+	s.Assert().Contains(text, "    replication-factor    2\n")
+	s.Assert().Contains(text, "    proto-fd-max    15000\n")
+}
+
+func (s *ConfFormatTestSuite) TestCheckConfFormat() {
+	ok, err := CheckConfFormat(logr.Discard(), []byte(formattedConf), FormatOptions{})
+	s.Require().NoError(err)
+	s.Assert().True(ok)
+
+	ok, err = CheckConfFormat(logr.Discard(), []byte(unformattedConf), FormatOptions{})
+	s.Require().NoError(err)
+	s.Assert().False(ok)
+
+	ok, err = CheckConfFormat(logr.Discard(), []byte(formattedConf), FormatOptions{HumanizeValues: true})
+	s.Require().NoError(err)
+	s.Assert().False(ok)
+
+	_, err = CheckConfFormat(logr.Discard(), []byte("service {\n"), FormatOptions{})
+	s.Assert().ErrorIs(err, ErrConfigParse)
+}
+
+func (s *ConfFormatTestSuite) TestHumanize() {
+	s.Assert().Equal("30d", humanizeTime(2592000))
+	s.Assert().Equal("2h", humanizeTime(7200))
+	s.Assert().Equal("90", humanizeTime(90))
+	s.Assert().Equal("0", humanizeTime(0))
+	s.Assert().Equal("4G", humanizeSize(4294967296))
+	s.Assert().Equal("1536K", humanizeSize(1572864))
+	s.Assert().Equal("1000", humanizeSize(1000))
+
+	// counts accepting the size units are not sizes
+	s.Assert().Equal("1G", humanizeValue("data-size", "1073741824"))
+	s.Assert().Equal("1048576", humanizeValue("stop-writes-count", "1048576"))
+	s.Assert().Equal("4096", humanizeValue("partition-tree-sprigs", "4096"))
+	s.Assert().Equal("1024", humanizeValue("quarantine-allocations", "1024"))
+}
+
+func TestConfFormatTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfFormatTestSuite))
+}
@@ -0,0 +1,290 @@
+package asconfig
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	sets "github.com/deckarep/golang-set/v2"
+	"github.com/go-logr/logr"
+)
+
+// Network error types.
+const (
+	errTypePortConflict   = "port_conflict"
+	errTypeInvalidAddress = "invalid_address"
+)
+
+// listenerServices are the network services listening on a port.
+var listenerServices = []string{"service", "fabric", "heartbeat", "info", "admin"}
+
+// hostnameRe matches the RFC 1123 host names.
+var hostnameRe = regexp.MustCompile(
+	`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*\.?$`,
+)
+
+// listener is a port of a network service of the config.
+type listener struct {
+	addresses sets.Set[string]
+	key       string
+	port      int
+}
+
+// ConfNetworkValid checks the network section of the flat config for:
+//   - listeners on the same port, among the port and tls-port of the
+//     service, fabric, heartbeat, info and admin services. Listeners bound
+//     to distinct addresses do not conflict.
+//   - mesh-seed-address-port and tls-mesh-seed-address-port entries whose
+//     port is not the heartbeat port and tls-port of the nodes, the seeds
+//     are expected to be nodes with the same network config. Without the
+//     heartbeat port, the seed ports must not be the ports of the other
+//     listeners.
+//   - access-address, tls-access-address and alternate access addresses
+//     which are neither an IP nor a host name.
+//
+// The conflicting key of an error is in Ref. The errors are sorted by field.
+func ConfNetworkValid(log logr.Logger, flatConf *Conf) []*ValidationErr {
+	conf := *flatConf
+	vErrs := make([]*ValidationErr, 0)
+
+	vErrs = append(vErrs, portConflicts(conf)...)
+	vErrs = append(vErrs, meshSeedPortsValid(conf)...)
+	vErrs = append(vErrs, accessAddressesValid(log, conf)...)
+
+	sort.SliceStable(vErrs, func(i, j int) bool {
+		return vErrs[i].Field < vErrs[j].Field
+	})
+
+	return vErrs
+}
+
+func portConflicts(conf Conf) []*ValidationErr {
+	vErrs := make([]*ValidationErr, 0)
+	listeners := make([]*listener, 0)
+
+	for _, service := range listenerServices {
+		for _, tls := range []string{"", "tls-"} {
+			key := fmt.Sprintf("network.%s.%sport", service, tls)
+
+			port, ok := flatNumber(conf, key)
+			if !ok || port == 0 {
+				continue
+			}
+
+			addresses := sets.NewSet[string]()
+			if l, ok := conf[fmt.Sprintf("network.%s.%saddresses", service, tls)].([]string); ok {
+				addresses.Append(l...)
+			}
+
+			l := &listener{key: key, port: int(port), addresses: addresses}
+
+			for _, other := range listeners {
+				if other.port == l.port && l.overlaps(other) {
+					vErrs = append(vErrs, &ValidationErr{
+						ErrType:     errTypePortConflict,
+						Context:     "network." + service,
+						Description: fmt.Sprintf("port %d is already used by %s", l.port, other.key),
+						Field:       key,
+						Ref:         other.key,
+						Value:       conf[key],
+					})
+
+					break
+				}
+			}
+
+			listeners = append(listeners, l)
+		}
+	}
+
+	return vErrs
+}
+
+// overlaps returns true if both listeners may be bound to the same address.
+// A listener without addresses, or bound to "any", is bound to all of them.
+func (l *listener) overlaps(other *listener) bool {
+	isAny := func(addresses sets.Set[string]) bool {
+		return addresses.Cardinality() == 0 || addresses.Contains("any") || addresses.Contains("0.0.0.0") ||
+			addresses.Contains("::")
+	}
+
+	return isAny(l.addresses) || isAny(other.addresses) || l.addresses.Intersect(other.addresses).Cardinality() > 0
+}
+
+func meshSeedPortsValid(conf Conf) []*ValidationErr {
+	vErrs := make([]*ValidationErr, 0)
+
+	for seedKey, portKey := range map[string]string{
+		"network.heartbeat.mesh-seed-address-ports":     "network.heartbeat.port",
+		"network.heartbeat.tls-mesh-seed-address-ports": "network.heartbeat.tls-port",
+	} {
+		seeds, _ := conf[seedKey].([]string)
+		if len(seeds) == 0 {
+			continue
+		}
+
+		hbPort, hasPort := flatNumber(conf, portKey)
+		hasPort = hasPort && hbPort != 0
+
+		for _, seed := range seeds {
+			host, port, err := splitSeedAddressPort(seed)
+			if err != nil || !isValidHost(host) {
+				vErrs = append(vErrs, &ValidationErr{
+					ErrType:     errTypeInvalidAddress,
+					Context:     "network.heartbeat",
+					Description: fmt.Sprintf("invalid mesh seed %q", seed),
+					Field:       seedKey,
+					Value:       seed,
+				})
+
+				continue
+			}
+
+			switch {
+			case hasPort && float64(port) != hbPort:
+				vErrs = append(vErrs, &ValidationErr{
+					ErrType:     errTypePortConflict,
+					Context:     "network.heartbeat",
+					Description: fmt.Sprintf("mesh seed port %d is not the heartbeat port %d", port, int(hbPort)),
+					Field:       seedKey,
+					Ref:         portKey,
+					Value:       seed,
+				})
+
+			case !hasPort:
+				if key := listenerKeyOf(conf, port); key != "" {
+					vErrs = append(vErrs, &ValidationErr{
+						ErrType:     errTypePortConflict,
+						Context:     "network.heartbeat",
+						Description: fmt.Sprintf("mesh seed port %d is the port of %s", port, key),
+						Field:       seedKey,
+						Ref:         key,
+						Value:       seed,
+					})
+				}
+			}
+		}
+	}
+
+	return vErrs
+}
+
+// listenerKeyOf returns the port key of the listener other than heartbeat on
+// the port, "" if there is none.
+func listenerKeyOf(conf Conf, port int) string {
+	for _, service := range listenerServices {
+		if service == "heartbeat" {
+			continue
+		}
+
+		for _, tls := range []string{"", "tls-"} {
+			key := fmt.Sprintf("network.%s.%sport", service, tls)
+			if p, ok := flatNumber(conf, key); ok && int(p) == port {
+				return key
+			}
+		}
+	}
+
+	return ""
+}
+
+// NetworkValid checks the network section of the config, see
+// ConfNetworkValid. IsValid does not check it, Validate runs both.
+// The error is ErrConfigNetwork if the network section is invalid.
+func (cfg *AsConfig) NetworkValid(log logr.Logger) (bool, []*ValidationErr, error) {
+	vErrs := ConfNetworkValid(log, cfg.baseConf)
+	if len(vErrs) == 0 {
+		return true, vErrs, nil
+	}
+
+	for _, vErr := range vErrs {
+		if pos, ok := cfg.Position(vErr.Field); ok {
+			vErr.Position = &pos
+		}
+	}
+
+	return false, vErrs, ErrConfigNetwork
+}
+
+// splitSeedAddressPort splits a mesh seed of the form "<host> <port>", as in
+// aerospike.conf, or "<host>:<port>", as returned by the server.
+func splitSeedAddressPort(seed string) (host string, port int, err error) {
+	fields := strings.Fields(seed)
+
+	switch len(fields) {
+	case 2:
+		host = fields[0]
+		port, err = strconv.Atoi(fields[1])
+	case 1:
+		idx := strings.LastIndex(seed, colon)
+		if idx < 0 {
+			return "", 0, fmt.Errorf("missing port")
+		}
+
+		host = strings.Trim(seed[:idx], "[]")
+		port, err = strconv.Atoi(seed[idx+1:])
+	default:
+		return "", 0, fmt.Errorf("invalid format")
+	}
+
+	return host, port, err
+}
+
+func accessAddressesValid(log logr.Logger, conf Conf) []*ValidationErr {
+	vErrs := make([]*ValidationErr, 0)
+
+	for _, k := range sortKeys(conf) {
+		if !strings.HasPrefix(k, "network"+sep) {
+			continue
+		}
+
+		switch SingularOf(BaseKey(k)) {
+		case keyAccessAddress, keyTLSAccessAddress, keyAlternateAccessAddress, keyTLSAlternateAccessAddress:
+		default:
+			continue
+		}
+
+		stype, values := getSystemProperty(log, conf, k)
+		if stype != NETADDR {
+			continue
+		}
+
+		for _, v := range values {
+			if isValidHost(v) {
+				continue
+			}
+
+			context, _ := splitContextBaseKey(k)
+
+			vErrs = append(vErrs, &ValidationErr{
+				ErrType:     errTypeInvalidAddress,
+				Context:     context,
+				Description: fmt.Sprintf("%s is neither an IP nor a host name", v),
+				Field:       k,
+				Value:       v,
+			})
+		}
+	}
+
+	return vErrs
+}
+
+// isValidHost returns true if host is an IP or a host name.
+func isValidHost(host string) bool {
+	if net.ParseIP(host) != nil {
+		return true
+	}
+
+	if len(host) > 253 || !hostnameRe.MatchString(host) {
+		return false
+	}
+
+	// An all numeric top level label is a malformed IP, eg. 10.0.0.1.5.
+	labels := strings.Split(strings.TrimSuffix(host, "."), ".")
+	_, err := strconv.Atoi(labels[len(labels)-1])
+
+	return err != nil
+}
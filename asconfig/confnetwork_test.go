@@ -0,0 +1,240 @@
+package asconfig
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfNetworkTestSuite struct {
+	suite.Suite
+}
+
+// networkTestConfig is a config with a valid network section.
+func networkTestConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"service": map[string]interface{}{"cluster-name": "prod"},
+		"network": map[string]interface{}{
+			"service": map[string]interface{}{
+				"port":             3000,
+				"access-addresses": []string{"10.0.0.1", "node1.example.com"},
+				"tls-port":         4333,
+				"tls-name":         "tls1",
+			},
+			"heartbeat": map[string]interface{}{
+				"mode":                    "mesh",
+				"port":                    3002,
+				"mesh-seed-address-ports": []string{"10.0.0.2 3002", "node3.example.com:3002"},
+			},
+			"fabric": map[string]interface{}{"port": 3001},
+			"tls": []map[string]interface{}{
+				{"name": "tls1", "ca-file": "/etc/aerospike/ca.pem"},
+			},
+		},
+		"namespaces": []map[string]interface{}{
+			{
+				"name":           "test",
+				"storage-engine": map[string]interface{}{"type": "memory", "data-size": 4294967296},
+			},
+		},
+	}
+}
+
+// networkTestAsConfig returns the networkTestConfig with the flat keys of set
+// changed and the flat keys of del removed.
+func (s *ConfNetworkTestSuite) networkTestAsConfig(set Conf, del []string) *AsConfig {
+	cfg, err := NewMapAsConfig(logr.Discard(), networkTestConfig())
+	s.Require().NoError(err)
+
+	flat := *cfg.baseConf
+
+	for k, v := range set {
+		flat[k] = v
+	}
+
+	for _, k := range del {
+		s.Require().Contains(flat, k)
+		delete(flat, k)
+	}
+
+	return cfg
+}
+
+func (s *ConfNetworkTestSuite) TestConfNetworkValid() {
+	testCases := []struct {
+		name     string
+		set      Conf
+		del      []string
+		expected []string
+	}{
+		{
+			name:     "valid network",
+			expected: []string{},
+		},
+		{
+			name: "port conflict",
+			set:  Conf{"network.fabric.port": 3000},
+			expected: []string{
+				"port_conflict network.fabric.port network.service.port 3000",
+			},
+		},
+		{
+			name: "port conflict with a tls-port",
+			set:  Conf{"network.info.port": 4333},
+			expected: []string{
+				"port_conflict network.info.port network.service.tls-port 4333",
+			},
+		},
+		{
+			name: "same port on distinct addresses",
+			set: Conf{
+				"network.info.port":             4333,
+				"network.info.addresses":        []string{"127.0.0.1"},
+				"network.service.tls-addresses": []string{"10.0.0.1"},
+			},
+			expected: []string{},
+		},
+		{
+			name: "same port on any address",
+			set: Conf{
+				"network.info.port":             4333,
+				"network.info.addresses":        []string{"any"},
+				"network.service.tls-addresses": []string{"10.0.0.1"},
+			},
+			expected: []string{
+				"port_conflict network.info.port network.service.tls-port 4333",
+			},
+		},
+		{
+			name: "invalid mesh seeds",
+			set: Conf{
+				"network.heartbeat.mesh-seed-address-ports": []string{
+					"10.0.0.2 3000", "10.0.0.3 3002", "bad_host 3002", "10.0.0.4",
+				},
+			},
+			expected: []string{
+				"port_conflict network.heartbeat.mesh-seed-address-ports network.heartbeat.port 10.0.0.2 3000",
+				"invalid_address network.heartbeat.mesh-seed-address-ports bad_host 3002",
+				"invalid_address network.heartbeat.mesh-seed-address-ports 10.0.0.4",
+			},
+		},
+		{
+			name: "mesh seeds without heartbeat port",
+			set: Conf{
+				"network.heartbeat.mesh-seed-address-ports": []string{"10.0.0.2 3000", "10.0.0.3 4333", "10.0.0.4 3002"},
+			},
+			del: []string{"network.heartbeat.port"},
+			expected: []string{
+				"port_conflict network.heartbeat.mesh-seed-address-ports network.service.port 10.0.0.2 3000",
+				"port_conflict network.heartbeat.mesh-seed-address-ports network.service.tls-port 10.0.0.3 4333",
+			},
+		},
+		{
+			name: "invalid access addresses",
+			set: Conf{
+				"network.service.access-addresses":           []string{"10.0.0.1", "10.0.0.1.5", "::1"},
+				"network.service.alternate-access-addresses": []string{"-bad.example.com"},
+			},
+			expected: []string{
+				"invalid_address network.service.access-addresses 10.0.0.1.5",
+				"invalid_address network.service.alternate-access-addresses -bad.example.com",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			vErrs := ConfNetworkValid(logr.Discard(), s.networkTestAsConfig(tc.set, tc.del).GetFlatMap())
+
+			res := make([]string, 0, len(vErrs))
+			for _, vErr := range vErrs {
+				desc := vErr.ErrType + " " + vErr.Field
+				if vErr.Ref != "" {
+					desc += " " + vErr.Ref
+				}
+
+				res = append(res, fmt.Sprintf("%s %v", desc, vErr.Value))
+			}
+
+			s.Assert().Equal(tc.expected, res)
+		})
+	}
+}
+
+func (s *ConfNetworkTestSuite) TestNetworkValid() {
+	cfg := s.networkTestAsConfig(Conf{"network.fabric.port": 3002}, nil)
+
+	// the network section is not checked against the schema
+	valid, _, err := NewSchemaRegistryFromMap(logr.Discard(), testSchemas).IsValid(logr.Discard(), cfg, "7.0.0")
+	s.Require().NoError(err)
+	s.Assert().True(valid)
+
+	valid, vErrs, err := cfg.NetworkValid(logr.Discard())
+	s.Assert().False(valid)
+	s.Assert().ErrorIs(err, ErrConfigNetwork)
+	s.Require().Len(vErrs, 1)
+	s.Assert().Equal("network.heartbeat.port", vErrs[0].Field)
+	s.Assert().Equal("network.fabric.port", vErrs[0].Ref)
+
+	valid, vErrs, err = s.networkTestAsConfig(nil, nil).NetworkValid(logr.Discard())
+	s.Require().NoError(err)
+	s.Assert().True(valid)
+	s.Assert().Empty(vErrs)
+}
+
+func (s *ConfNetworkTestSuite) TestValidate() {
+	registry := NewSchemaRegistryFromMap(logr.Discard(), testSchemas)
+
+	testCases := []struct {
+		name     string
+		set      Conf
+		expected []string
+		err      error
+	}{
+		{
+			name:     "valid",
+			expected: []string{},
+		},
+		{
+			name:     "port conflict",
+			set:      Conf{"network.fabric.port": 3002},
+			expected: []string{"network.heartbeat.port"},
+			err:      ErrConfigNetwork,
+		},
+		{
+			name: "cross-reference error first",
+			set: Conf{
+				"network.fabric.port":     3002,
+				"network.fabric.tls-name": "tls2",
+			},
+			expected: []string{"network.fabric.tls-name", "network.heartbeat.port"},
+			err:      ErrConfigCrossReference,
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			valid, vErrs, err := registry.Validate(logr.Discard(), s.networkTestAsConfig(tc.set, nil), "7.0.0")
+
+			fields := make([]string, 0, len(vErrs))
+			for _, vErr := range vErrs {
+				fields = append(fields, vErr.Field)
+			}
+
+			s.Assert().Equal(tc.expected, fields)
+			s.Assert().Equal(tc.err == nil, valid)
+
+			if tc.err == nil {
+				s.Assert().NoError(err)
+			} else {
+				s.Assert().ErrorIs(err, tc.err)
+			}
+		})
+	}
+}
+
+func TestConfNetworkTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfNetworkTestSuite))
+}
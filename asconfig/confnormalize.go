@@ -0,0 +1,207 @@
+package asconfig
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	sets "github.com/deckarep/golang-set/v2"
+	"github.com/go-logr/logr"
+)
+
+// ValueNormalizer converts config values to a canonical form so that values
+// written differently but meaning the same to the server compare equal, eg.
+// 4G and 4294967296 for a size, 1h and 3600 for a time, "true" and true for a
+// boolean or lists in a different order.
+//
+// The canonical form of a value is:
+//   - uint64 for the non-negative integers and int64 for the negative ones,
+//     as loaded by NewMapAsConfig. Humanized sizes and times, see
+//     isSizeOrTime, and integer strings of integer keys are converted too.
+//   - bool for the boolean strings of boolean keys.
+//   - a sorted copy for the lists.
+//
+// Other values are kept as they are.
+type ValueNormalizer struct {
+	log         logr.Logger
+	constraints valueConstraints
+}
+
+// NewValueNormalizer returns a ValueNormalizer using the types of the keys
+// in the config schema of the version.
+func NewValueNormalizer(log logr.Logger, ver string) (*ValueNormalizer, error) {
+	return defaultRegistry.NewValueNormalizer(log, ver)
+}
+
+// NewValueNormalizer is NewValueNormalizer using the schemas of the registry.
+func (r *SchemaRegistry) NewValueNormalizer(log logr.Logger, ver string) (*ValueNormalizer, error) {
+	constraints, err := r.getValueConstraintsForVersion(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ValueNormalizer{
+		log:         log,
+		constraints: constraints,
+	}, nil
+}
+
+// NormalizeValue returns the canonical form of the value of the flat config
+// key eg. namespaces.{test}.storage-engine.data-size.
+func (n *ValueNormalizer) NormalizeValue(key string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return canonicalInt(int64(v))
+	case int64:
+		return canonicalInt(v)
+	case float64:
+		if v == float64(int64(v)) {
+			return canonicalInt(int64(v))
+		}
+
+		return v
+	case string:
+		return n.normalizeString(key, v)
+	case []string:
+		list := append([]string{}, v...)
+		sort.Strings(list)
+
+		return list
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for i := range v {
+			list = append(list, fmt.Sprint(v[i]))
+		}
+
+		sort.Strings(list)
+
+		return list
+	default:
+		return value
+	}
+}
+
+func (n *ValueNormalizer) normalizeString(key, value string) interface{} {
+	baseKey := BaseKey(key)
+
+	if ok, humanizeFn := isSizeOrTime(baseKey); ok && value != "" {
+		if v, err := humanizeFn(value); err == nil {
+			return v
+		}
+	}
+
+	types := n.schemaTypes(key)
+
+	if types.Contains(schemaTypeInteger) || types.Contains(schemaTypeNumber) {
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return canonicalInt(v)
+		}
+
+		if v, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return v
+		}
+	}
+
+	if types.Contains(schemaTypeBoolean) {
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	}
+
+	return value
+}
+
+// schemaTypes returns the types of the key in all its schema definitions.
+func (n *ValueNormalizer) schemaTypes(key string) sets.Set[string] {
+	types := sets.NewSet[string]()
+
+	for _, def := range n.constraints[GetFlatKey(SplitKey(n.log, key, sep))] {
+		types.Add(def.typ)
+	}
+
+	return types
+}
+
+// NormalizeConf returns a copy of the flat config with all the values in
+// their canonical form, see NormalizeValue.
+func (n *ValueNormalizer) NormalizeConf(flatConf Conf) Conf {
+	res := make(Conf, len(flatConf))
+
+	for k, v := range flatConf {
+		if isInternalField(k) {
+			res[k] = v
+			continue
+		}
+
+		res[k] = n.NormalizeValue(k, v)
+	}
+
+	return res
+}
+
+// IsValueEqual returns true if the values of the flat config key are the
+// same once normalized.
+func (n *ValueNormalizer) IsValueEqual(key string, v1, v2 interface{}) bool {
+	return !isValueDiff(n.log, n.NormalizeValue(key, v1), n.NormalizeValue(key, v2))
+}
+
+// ConfEqual returns true if both configs have the same keys with the same
+// normalized values, see ValueNormalizer. The order of the list sections,
+// eg. namespaces, is ignored.
+func ConfEqual(log logr.Logger, c1, c2 Conf, isFlat bool, ver string) (bool, error) {
+	return defaultRegistry.ConfEqual(log, c1, c2, isFlat, ver)
+}
+
+// ConfEqual is ConfEqual using the schemas of the registry.
+func (r *SchemaRegistry) ConfEqual(log logr.Logger, c1, c2 Conf, isFlat bool, ver string) (bool, error) {
+	if !isFlat {
+		var err error
+
+		c1, err = flattenConf(log, c1, sep)
+		if err != nil {
+			return false, fmt.Errorf("failed to flatten first config: %w", err)
+		}
+
+		c2, err = flattenConf(log, c2, sep)
+		if err != nil {
+			return false, fmt.Errorf("failed to flatten second config: %w", err)
+		}
+	}
+
+	n, err := r.NewValueNormalizer(log, ver)
+	if err != nil {
+		return false, err
+	}
+
+	count := 0
+
+	for k, v1 := range c1 {
+		if BaseKey(k) == keyIndex {
+			continue
+		}
+
+		v2, ok := c2[k]
+		if !ok || !n.IsValueEqual(k, v1, v2) {
+			return false, nil
+		}
+
+		count++
+	}
+
+	for k := range c2 {
+		if BaseKey(k) != keyIndex {
+			count--
+		}
+	}
+
+	return count == 0, nil
+}
+
+// canonicalInt returns v as uint64 if it is not negative, like toConf.
+func canonicalInt(v int64) interface{} {
+	if v >= 0 {
+		return uint64(v)
+	}
+
+	return v
+}
@@ -0,0 +1,143 @@
+package asconfig
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfNormalizeTestSuite struct {
+	suite.Suite
+	normalizer *ValueNormalizer
+}
+
+func (s *ConfNormalizeTestSuite) SetupSuite() {
+	InitFromMap(logr.Discard(), testSchemas)
+
+	var err error
+
+	s.normalizer, err = NewValueNormalizer(logr.Discard(), "7.0.0")
+	s.Require().NoError(err)
+}
+
+func (s *ConfNormalizeTestSuite) TestNormalizeValue() {
+	testCases := []struct {
+		name     string
+		key      string
+		value    interface{}
+		expected interface{}
+	}{
+		{"int", "service.proto-fd-max", 15000, uint64(15000)},
+		{"int64", "service.proto-fd-max", int64(15000), uint64(15000)},
+		{"float64", "service.proto-fd-max", float64(15000), uint64(15000)},
+		{"integer string", "service.proto-fd-max", "15000", uint64(15000)},
+		{"size", "namespaces.{test}.memory-size", "4G", uint64(4294967296)},
+		{"time", "namespaces.{test}.default-ttl", "1h", uint64(3600)},
+		{"boolean string", "namespaces.{test}.strong-consistency", "true", true},
+		{"string", "service.cluster-name", "123", "123"},
+		{"list", "network.service.addresses", []string{"b", "a"}, []string{"a", "b"}},
+		{"interface list", "network.service.addresses", []interface{}{"b", "a"}, []string{"a", "b"}},
+		{"negative", "service.proto-fd-max", -1, int64(-1)},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			s.Assert().Equal(tc.expected, s.normalizer.NormalizeValue(tc.key, tc.value))
+		})
+	}
+}
+
+func (s *ConfNormalizeTestSuite) TestNormalizeValueKeepsList() {
+	list := []string{"b", "a"}
+
+	s.normalizer.NormalizeValue("network.service.addresses", list)
+	s.Assert().Equal([]string{"b", "a"}, list)
+}
+
+func (s *ConfNormalizeTestSuite) TestConfEqual() {
+	c1 := Conf{
+		"service": Conf{"proto-fd-max": int64(15000)},
+		"namespaces": []Conf{
+			{"name": "bar", "memory-size": int64(4294967296)},
+			{"name": "test", "strong-consistency": true, "default-ttl": 3600},
+		},
+	}
+	c2 := Conf{
+		"service": Conf{"proto-fd-max": uint64(15000)},
+		"namespaces": []Conf{
+			{"name": "test", "strong-consistency": "true", "default-ttl": "1h"},
+			{"name": "bar", "memory-size": "4G"},
+		},
+	}
+
+	equal, err := ConfEqual(logr.Discard(), c1, c2, false, "7.0.0")
+	s.Require().NoError(err)
+	s.Assert().True(equal)
+
+	c2["service"].(Conf)["proto-fd-max"] = 20000
+
+	equal, err = ConfEqual(logr.Discard(), c1, c2, false, "7.0.0")
+	s.Require().NoError(err)
+	s.Assert().False(equal)
+
+	delete(c2, "service")
+
+	equal, err = ConfEqual(logr.Discard(), c1, c2, false, "7.0.0")
+	s.Require().NoError(err)
+	s.Assert().False(equal)
+}
+
+func (s *ConfNormalizeTestSuite) TestConfDiffNoOp() {
+	desired := Conf{
+		"service.proto-fd-max":                         "15000",
+		"namespaces.{test}.memory-size":                "4G",
+		"namespaces.{test}.strong-consistency":         "false",
+		"namespaces.{test}.storage-engine.devices":     []string{"/dev/xvdc", "/dev/xvdb"},
+		"namespaces.{test}.name":                       "test",
+		"namespaces.{test}.default-ttl":                "2h",
+		"namespaces.{test}.<index>":                    0,
+		"namespaces.{test}.conflict-resolution-policy": "generation",
+	}
+	current := Conf{
+		"service.proto-fd-max":                         uint64(15000),
+		"namespaces.{test}.memory-size":                uint64(4294967296),
+		"namespaces.{test}.strong-consistency":         false,
+		"namespaces.{test}.storage-engine.devices":     []string{"/dev/xvdb", "/dev/xvdc"},
+		"namespaces.{test}.name":                       "test",
+		"namespaces.{test}.default-ttl":                uint64(3600),
+		"namespaces.{test}.<index>":                    0,
+		"namespaces.{test}.rack-id":                    uint64(0),
+		"namespaces.{test}.conflict-resolution-policy": "generation",
+	}
+
+	diff, err := ConfDiff(logr.Discard(), desired, current, true, "7.0.0")
+	s.Require().NoError(err)
+	s.Assert().Equal(DynamicConfigMap{"namespaces.{test}.default-ttl": {Update: "2h"}}, diff)
+}
+
+func (s *ConfNormalizeTestSuite) TestConfDiffWithoutSchema() {
+	desired := Conf{
+		"service.proto-fd-max":                     15000,
+		"namespaces.{test}.name":                   "test",
+		"namespaces.{test}.<index>":                0,
+		"namespaces.{test}.memory-size":            "4G",
+		"namespaces.{test}.storage-engine.devices": []string{"/dev/xvdc", "/dev/xvdb"},
+	}
+	current := Conf{
+		"service.proto-fd-max":                     15000,
+		"namespaces.{test}.name":                   "test",
+		"namespaces.{test}.<index>":                0,
+		"namespaces.{test}.memory-size":            uint64(4294967296),
+		"namespaces.{test}.storage-engine.devices": []string{"/dev/xvdb", "/dev/xvdc"},
+	}
+
+	// the values are compared as they are without the schema of the version
+	diff, err := NewSchemaRegistry().ConfDiff(logr.Discard(), desired, current, true, "7.0.0")
+	s.Require().NoError(err)
+	s.Assert().Equal(DynamicConfigMap{"namespaces.{test}.memory-size": {Update: "4G"}}, diff)
+}
+
+func TestConfNormalizeTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfNormalizeTestSuite))
+}
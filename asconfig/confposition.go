@@ -0,0 +1,248 @@
+package asconfig
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// Position is a location in an aerospike.conf file.
+type Position struct {
+	// File is the name of the file, empty if the config was not read from a named file.
+	File string
+	// Snippet is the source line, without the line terminator.
+	Snippet string
+	// Line and Column are 1-based, Column counts bytes.
+	Line   int
+	Column int
+}
+
+// String returns the position as file:line:column, or line:column without a file name.
+func (p Position) String() string {
+	if p.File == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+// Describe returns msg prefixed by the position and followed by the source
+// line with a caret under the column, eg.
+//
+//	aerospike.conf:12:5: invalid value
+//	    replication-factor 300
+//	    ^
+func (p Position) Describe(msg string) string {
+	caret := strings.Repeat(" ", max(p.Column-1, 0)) + "^"
+
+	return fmt.Sprintf("%s: %s\n%s\n%s", p.String(), msg, p.Snippet, caret)
+}
+
+// ConfParseError is an aerospike.conf parse error at a known position.
+// It wraps ErrConfigParse.
+type ConfParseError struct {
+	Msg      string
+	Position Position
+}
+
+func (e *ConfParseError) Error() string {
+	return e.Position.Describe(e.Msg)
+}
+
+func (e *ConfParseError) Unwrap() error {
+	return ErrConfigParse
+}
+
+// confScanner is a line scanner over an aerospike.conf which tracks the
+// position of the current line and records the position of every section
+// and field read. Recorded keys are the flat keys with the names used in the
+// conf file, eg. "namespace.{test}.storage-engine.device".
+type confScanner struct {
+	*bufio.Scanner
+	positions map[string]Position
+	file      string
+	text      string
+	// path is the flat key of the current section.
+	path []string
+	line int
+}
+
+func newConfScanner(file string, in io.Reader) *confScanner {
+	return &confScanner{
+		Scanner:   bufio.NewScanner(in),
+		positions: make(map[string]Position),
+		file:      file,
+	}
+}
+
+func (s *confScanner) Scan() bool {
+	if !s.Scanner.Scan() {
+		return false
+	}
+
+	s.line++
+	s.text = s.Scanner.Text()
+
+	return true
+}
+
+// position returns the position of the first occurrence of token in the current line.
+func (s *confScanner) position(token string) Position {
+	col := 1
+	if idx := strings.Index(s.text, token); idx >= 0 && token != "" {
+		col = idx + 1
+	}
+
+	return Position{
+		File:    s.file,
+		Line:    s.line,
+		Column:  col,
+		Snippet: s.text,
+	}
+}
+
+// record saves the position of token in the current line for the key under
+// the current section. The first position of a key is kept for repeated list fields.
+func (s *confScanner) record(key, token string) {
+	flatKey := strings.Join(append(append([]string{}, s.path...), key), sep)
+	if _, ok := s.positions[flatKey]; !ok {
+		s.positions[flatKey] = s.position(token)
+	}
+}
+
+// errorf returns a ConfParseError at token in the current line.
+func (s *confScanner) errorf(token, format string, args ...interface{}) error {
+	return &ConfParseError{
+		Msg:      fmt.Sprintf(format, args...),
+		Position: s.position(token),
+	}
+}
+
+// enterSection records the position of the section started by the tokens of
+// the current line and makes it the current section. The keys follow the
+// layout of the parsed config, eg. "namespace test {" is "namespace.{test}",
+// "storage-engine device {" is "storage-engine" with a "type" field and
+// "file /var/log/aerospike.log {" in logging is "logging.{/var/log/aerospike.log}".
+// It returns the number of path tokens to pass to leaveSection.
+func (s *confScanner) enterSection(tok []string) int {
+	cfgName := tok[0]
+	parent := ""
+
+	if len(s.path) > 0 {
+		parent = s.path[len(s.path)-1]
+	}
+
+	pathTokens := sectionPathTokens(parent, tok)
+
+	for i := range pathTokens {
+		s.record(pathTokens[i], cfgName)
+		s.path = append(s.path, pathTokens[i])
+	}
+
+	if len(tok) > 2 && isTypedSection(cfgName) {
+		s.record(keyType, tok[1])
+	}
+
+	return len(pathTokens)
+}
+
+// sectionPathTokens returns the flat key tokens added by the section started
+// by tok, parent is the last flat key token of the enclosing section.
+func sectionPathTokens(parent string, tok []string) []string {
+	cfgName := tok[0]
+	inLogging := parent != "" && isSpecialListSection(parent)
+
+	switch {
+	case inLogging && len(tok) > 2:
+		return []string{namedToken(tok[1])}
+	case inLogging:
+		return []string{namedToken(cfgName)}
+	case len(tok) > 2 && isListSection(cfgName):
+		return []string{cfgName, namedToken(tok[1])}
+	default:
+		return []string{cfgName}
+	}
+}
+
+func (s *confScanner) leaveSection(n int) {
+	s.path = s.path[:len(s.path)-n]
+}
+
+// positionOf returns the position of the flat key in positions recorded by a
+// confScanner. Plural keys of the loaded config are looked up by their
+// singular conf file names. If the key itself has no position, the position
+// of the closest enclosing section is returned.
+func positionOf(log logr.Logger, positions map[string]Position, flatKey string) (Position, bool) {
+	if len(positions) == 0 {
+		return Position{}, false
+	}
+
+	tokens := SplitKey(log, flatKey, sep)
+
+	for n := len(tokens); n > 0; n-- {
+		key := strings.Join(tokens[:n], sep)
+		if pos, ok := positions[key]; ok {
+			return pos, true
+		}
+
+		singular := make([]string, n)
+		for i := range singular {
+			singular[i] = SingularOf(tokens[i])
+		}
+
+		if pos, ok := positions[strings.Join(singular, sep)]; ok {
+			return pos, true
+		}
+	}
+
+	return Position{}, false
+}
+
+// schemaFieldToFlatKey converts the field of a json schema validation error,
+// which has indexes for list items, eg. "namespaces.0.replication-factor",
+// to the flat key of the config, eg. "namespaces.{test}.replication-factor".
+func schemaFieldToFlatKey(flatConf Conf, field string) string {
+	field = strings.TrimPrefix(strings.TrimPrefix(field, "(root)"), sep)
+	if field == "" {
+		return ""
+	}
+
+	// list section prefix + index -> named prefix
+	named := make(map[string]string)
+
+	for k, v := range flatConf {
+		if !strings.HasSuffix(k, sep+keyIndex) {
+			continue
+		}
+
+		item := strings.TrimSuffix(k, sep+keyIndex)
+		if idx := strings.LastIndex(item, sep+string(SectionNameStartChar)); idx >= 0 {
+			named[item[:idx]+sep+fmt.Sprint(v)] = item
+		}
+	}
+
+	tokens := strings.Split(field, sep)
+	key := ""
+
+	for _, token := range tokens {
+		next := token
+		if key != "" {
+			next = key + sep + token
+		}
+
+		if _, err := strconv.Atoi(token); err == nil {
+			if item, ok := named[next]; ok {
+				key = item
+				continue
+			}
+		}
+
+		key = next
+	}
+
+	return key
+}
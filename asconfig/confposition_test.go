@@ -0,0 +1,149 @@
+package asconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfPositionTestSuite struct {
+	suite.Suite
+}
+
+func (s *ConfPositionTestSuite) SetupSuite() {
+	InitFromMap(logr.Discard(), testSchemas)
+}
+
+const positionTestConf = `# test config
+network {
+	service {
+		port 3000
+	}
+	heartbeat {
+		mode mesh
+		port 3002
+	}
+	fabric {
+		port 3001
+	}
+}
+
+logging {
+	console {
+		context any info
+	}
+}
+
+namespace test {
+	replication-factor 300
+	storage-engine device {
+		device /dev/xvdb
+		device /dev/xvdc
+	}
+}
+`
+
+func (s *ConfPositionTestSuite) TestParseErrorPosition() {
+	in := "service {\n    proto-fd-max 15000\n    cluster-name{\n}\n"
+
+	_, err := FromNamedConfFile(logr.Discard(), "aerospike.conf", strings.NewReader(in))
+	s.Require().Error(err)
+	s.Assert().ErrorIs(err, ErrConfigParse)
+
+	var parseErr *ConfParseError
+
+	s.Require().True(errors.As(err, &parseErr))
+	s.Assert().Equal(Position{File: "aerospike.conf", Line: 3, Column: 5, Snippet: "    cluster-name{"},
+		parseErr.Position)
+	s.Assert().Equal("aerospike.conf:3:5: config file items must have a space between them and '{'\n"+
+		"    cluster-name{\n    ^", err.Error())
+
+	_, err = FromConfFile(logr.Discard(), strings.NewReader("service {\n    cluster-name\n}\n"))
+	s.Require().True(errors.As(err, &parseErr))
+	s.Assert().Equal("2:5", parseErr.Position.String())
+}
+
+func (s *ConfPositionTestSuite) TestKeyPosition() {
+	cfg, err := FromNamedConfFile(logr.Discard(), "aerospike.conf", strings.NewReader(positionTestConf))
+	s.Require().NoError(err)
+
+	testCases := []struct {
+		key    string
+		line   int
+		column int
+	}{
+		{"network.heartbeat.port", 8, 3},
+		{"namespace.{test}.replication-factor", 22, 2},
+		{"namespace.{test}.storage-engine.type", 23, 17},
+		{"namespace.{test}.storage-engine.device", 24, 3},
+		{"logging.{console}.any", 17, 11},
+		// keys not in the file are at their section
+		{"namespace.{test}.default-ttl", 21, 1},
+	}
+
+	for _, tc := range testCases {
+		pos, ok := cfg.Position(tc.key)
+		s.Require().True(ok, tc.key)
+		s.Assert().Equal(tc.line, pos.Line, tc.key)
+		s.Assert().Equal(tc.column, pos.Column, tc.key)
+		s.Assert().Equal("aerospike.conf", pos.File, tc.key)
+	}
+
+	_, ok := cfg.Position("xdr.dcs.{DC1}.period-ms")
+	s.Assert().False(ok)
+
+	mapCfg, err := NewMapAsConfig(logr.Discard(), map[string]interface{}{"network": migrateTestNetwork()})
+	s.Require().NoError(err)
+
+	_, ok = mapCfg.Position("network.service.port")
+	s.Assert().False(ok)
+}
+
+func (s *ConfPositionTestSuite) TestValidationErrPosition() {
+	cfg, err := NewASConfigFromBytes(logr.Discard(), []byte(positionTestConf), AeroConfig)
+	s.Require().NoError(err)
+
+	pos, ok := cfg.Position("namespaces.{test}.storage-engine.devices")
+	s.Require().True(ok)
+	s.Assert().Equal(24, pos.Line)
+
+	valid, vErrs, err := cfg.IsValid(logr.Discard(), "7.0.0")
+	s.Assert().False(valid)
+	s.Assert().ErrorIs(err, ErrConfigSchema)
+
+	var rfErr *ValidationErr
+
+	for _, vErr := range vErrs {
+		if strings.HasSuffix(vErr.Field, "replication-factor") {
+			rfErr = vErr
+		}
+	}
+
+	s.Require().NotNil(rfErr)
+	s.Require().NotNil(rfErr.Position)
+	s.Assert().Equal(22, rfErr.Position.Line)
+	s.Assert().True(strings.HasPrefix(rfErr.Describe(), "22:2: namespaces.0.replication-factor: "))
+	s.Assert().True(strings.HasSuffix(rfErr.Describe(), "\n\treplication-factor 300\n ^"))
+}
+
+func (s *ConfPositionTestSuite) TestSchemaFieldToFlatKey() {
+	flatConf := Conf{
+		"namespaces.{a}.<index>":             0,
+		"namespaces.{b}.<index>":             1,
+		"namespaces.{b}.sets.{s1}.<index>":   0,
+		"namespaces.{b}.sets.{s1}.disable-x": true,
+	}
+
+	s.Assert().Equal("namespaces.{b}.sets.{s1}.disable-x",
+		schemaFieldToFlatKey(flatConf, "namespaces.1.sets.0.disable-x"))
+	s.Assert().Equal("namespaces.{a}", schemaFieldToFlatKey(flatConf, "namespaces.0"))
+	s.Assert().Equal("service.feature-key-files.0", schemaFieldToFlatKey(flatConf, "service.feature-key-files.0"))
+	s.Assert().Equal("", schemaFieldToFlatKey(flatConf, "(root)"))
+}
+
+func TestConfPositionTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfPositionTestSuite))
+}
@@ -0,0 +1,173 @@
+package asconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sets "github.com/deckarep/golang-set/v2"
+)
+
+// errTypeReference is the error type of the references to a missing section.
+const errTypeReference = "reference_not_found"
+
+// tlsServices are the network services which can listen with TLS.
+var tlsServices = []string{"service", "heartbeat", "fabric"}
+
+// ConfReferencesValid checks the references by name between the sections of
+// the flat config, which the config schema cannot check:
+//   - every tls-name, eg. network.service.tls-name or xdr.dcs.{dc1}.tls-name,
+//     must be a network.tls section.
+//   - the network services listening on a tls-port must have a tls-name.
+//   - the namespaces of the XDR DCs must be namespaces of the config.
+//   - the namespace and set of security.log.report-data-op must be a
+//     namespace and a set of the config.
+//
+// Each error has the referencing key in Field and the missing key in Ref.
+// The errors are sorted by field.
+func ConfReferencesValid(flatConf *Conf) []*ValidationErr {
+	conf := *flatConf
+	vErrs := tlsNameRefsValid(conf)
+	namespaces := sets.NewSet(namedSections(conf, "namespaces")...)
+
+	for _, k := range sortKeys(conf) {
+		switch BaseKey(k) {
+		case KeyName:
+			// xdr.dcs.{dc}.namespaces.{ns}.name
+			tokens := strings.Split(k, sep)
+			if len(tokens) != 6 || tokens[0] != "xdr" || tokens[1] != "dcs" || tokens[3] != "namespaces" {
+				continue
+			}
+
+			ns, _ := conf[k].(string)
+			if namespaces.Contains(ns) {
+				continue
+			}
+
+			ref := fmt.Sprintf("namespaces.{%s}", ns)
+			vErrs = append(vErrs, newRefErr(conf, k, ref, fmt.Sprintf("XDR namespace %s is not defined in namespaces", ns)))
+
+		case keyReportDataOp:
+			vErrs = append(vErrs, reportDataOpRefsValid(conf, k, namespaces)...)
+		}
+	}
+
+	for _, service := range tlsServices {
+		portKey := fmt.Sprintf("network.%s.tls-port", service)
+		nameKey := fmt.Sprintf("network.%s.tls-name", service)
+
+		port, ok := flatNumber(conf, portKey)
+		if !ok || port == 0 {
+			continue
+		}
+
+		if name, _ := conf[nameKey].(string); name == "" {
+			vErrs = append(vErrs, newRefErr(conf, portKey, nameKey,
+				fmt.Sprintf("%s tls-port is set without tls-name", service)))
+		}
+	}
+
+	sort.SliceStable(vErrs, func(i, j int) bool {
+		return vErrs[i].Field < vErrs[j].Field
+	})
+
+	return vErrs
+}
+
+// ReferencesValid checks the references by name between the sections of the
+// config, see ConfReferencesValid. IsValid does not check them, Validate runs
+// both. The error is ErrConfigCrossReference if a referenced section is
+// missing.
+func (cfg *AsConfig) ReferencesValid() (bool, []*ValidationErr, error) {
+	vErrs := ConfReferencesValid(cfg.baseConf)
+	if len(vErrs) == 0 {
+		return true, vErrs, nil
+	}
+
+	for _, vErr := range vErrs {
+		if pos, ok := cfg.Position(vErr.Field); ok {
+			vErr.Position = &pos
+		}
+	}
+
+	return false, vErrs, ErrConfigCrossReference
+}
+
+// newRefErr returns the error of the key field referencing the missing key
+// ref.
+func newRefErr(conf Conf, field, ref, desc string) *ValidationErr {
+	context, _ := splitContextBaseKey(field)
+
+	return &ValidationErr{
+		ErrType:     errTypeReference,
+		Context:     context,
+		Description: desc,
+		Field:       field,
+		Ref:         ref,
+		Value:       conf[field],
+	}
+}
+
+// tlsNameRefsValid checks that every tls-name of the config is a network.tls
+// section. The errors are sorted by field.
+func tlsNameRefsValid(conf Conf) []*ValidationErr {
+	tlsNames := sets.NewSet(namedSections(conf, "network.tls")...)
+	vErrs := make([]*ValidationErr, 0)
+
+	for _, k := range sortKeys(conf) {
+		if BaseKey(k) != "tls-name" {
+			continue
+		}
+
+		name, ok := conf[k].(string)
+		if !ok || name == "" || tlsNames.Contains(name) {
+			continue
+		}
+
+		ref := fmt.Sprintf("network.tls.{%s}", name)
+		vErrs = append(vErrs, newRefErr(conf, k, ref, fmt.Sprintf("tls-name %s is not defined in network.tls", name)))
+	}
+
+	return vErrs
+}
+
+// reportDataOpRefsValid checks the "<namespace> [<set>]" values of the
+// report-data-op key.
+func reportDataOpRefsValid(conf Conf, key string, namespaces sets.Set[string]) []*ValidationErr {
+	values, _ := conf[key].([]string)
+	vErrs := make([]*ValidationErr, 0)
+
+	for _, v := range values {
+		literals := strings.Fields(v)
+		if len(literals) == 0 {
+			continue
+		}
+
+		ns := literals[0]
+
+		if !namespaces.Contains(ns) {
+			vErr := newRefErr(conf, key, fmt.Sprintf("namespaces.{%s}", ns),
+				fmt.Sprintf("report-data-op namespace %s is not defined in namespaces", ns))
+			vErr.Value = v
+			vErrs = append(vErrs, vErr)
+
+			continue
+		}
+
+		if len(literals) < 2 {
+			continue
+		}
+
+		set := literals[1]
+		setKey := fmt.Sprintf("namespaces.{%s}.sets", ns)
+
+		if !sets.NewSet(namedSections(conf, setKey)...).Contains(set) {
+			vErr := newRefErr(conf, key, fmt.Sprintf("%s.{%s}", setKey, set),
+				fmt.Sprintf("report-data-op set %s is not defined in namespace %s", set, ns))
+			vErr.Value = v
+			vErrs = append(vErrs, vErr)
+		}
+	}
+
+	return vErrs
+}
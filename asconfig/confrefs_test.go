@@ -0,0 +1,281 @@
+package asconfig
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type ConfRefsTestSuite struct {
+	suite.Suite
+}
+
+// refsTestConfig is a config with a reference between sections of every kind,
+// all of them defined.
+func refsTestConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"network": map[string]interface{}{
+			"service": map[string]interface{}{
+				"port":     3000,
+				"tls-port": 4333,
+				"tls-name": "tls1",
+			},
+			"heartbeat": map[string]interface{}{
+				"mode":     "mesh",
+				"port":     3002,
+				"tls-port": 3012,
+				"tls-name": "tls1",
+			},
+			"fabric": map[string]interface{}{"port": 3001},
+			"tls": []map[string]interface{}{
+				{"name": "tls1", "ca-file": "/etc/aerospike/ca.pem"},
+			},
+		},
+		"namespaces": []map[string]interface{}{
+			{
+				"name": "test",
+				"sets": []map[string]interface{}{
+					{"name": "set1"},
+				},
+			},
+		},
+		"security": map[string]interface{}{
+			"log": map[string]interface{}{
+				"report-data-op": []string{"test set1"},
+			},
+		},
+		"xdr": map[string]interface{}{
+			"dcs": []map[string]interface{}{
+				{
+					"name":     "dc1",
+					"tls-name": "tls1",
+					"namespaces": []map[string]interface{}{
+						{"name": "test"},
+					},
+				},
+			},
+		},
+	}
+}
+
+// refsTestAsConfig returns the refsTestConfig with the flat keys of set
+// changed and the flat keys of del removed.
+func (s *ConfRefsTestSuite) refsTestAsConfig(set Conf, del []string) *AsConfig {
+	cfg, err := NewMapAsConfig(logr.Discard(), refsTestConfig())
+	s.Require().NoError(err)
+
+	flat := *cfg.baseConf
+
+	for k, v := range set {
+		flat[k] = v
+	}
+
+	for _, k := range del {
+		s.Require().Contains(flat, k)
+		delete(flat, k)
+	}
+
+	return cfg
+}
+
+func (s *ConfRefsTestSuite) TestConfReferencesValid() {
+	testCases := []struct {
+		name     string
+		set      Conf
+		del      []string
+		expected []string
+	}{
+		{
+			name:     "valid references",
+			expected: []string{},
+		},
+		{
+			name: "undefined tls-name",
+			set:  Conf{"network.fabric.tls-name": "tls2", "xdr.dcs.{dc1}.tls-name": "tls3"},
+			expected: []string{
+				"network.fabric.tls-name network.tls.{tls2} tls2",
+				"xdr.dcs.{dc1}.tls-name network.tls.{tls3} tls3",
+			},
+		},
+		{
+			name: "tls-port without tls-name",
+			del:  []string{"network.heartbeat.tls-name"},
+			expected: []string{
+				"network.heartbeat.tls-port network.heartbeat.tls-name 3012",
+			},
+		},
+		{
+			name: "undefined report-data-op namespace and set",
+			set:  Conf{"security.log.report-data-op": []string{"test set1", "test set2", "test", "foo set1"}},
+			expected: []string{
+				"security.log.report-data-op namespaces.{test}.sets.{set2} test set2",
+				"security.log.report-data-op namespaces.{foo} foo set1",
+			},
+		},
+		{
+			name: "undefined xdr namespace",
+			set:  Conf{"xdr.dcs.{dc1}.namespaces.{test}.name": "foo"},
+			expected: []string{
+				"xdr.dcs.{dc1}.namespaces.{test}.name namespaces.{foo} foo",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			vErrs := ConfReferencesValid(s.refsTestAsConfig(tc.set, tc.del).GetFlatMap())
+
+			refs := make([]string, 0, len(vErrs))
+			for _, vErr := range vErrs {
+				s.Assert().Equal(errTypeReference, vErr.ErrType)
+
+				refs = append(refs, fmt.Sprintf("%s %s %v", vErr.Field, vErr.Ref, vErr.Value))
+			}
+
+			s.Assert().Equal(tc.expected, refs)
+		})
+	}
+}
+
+func (s *ConfRefsTestSuite) TestConfReferencesValidContext() {
+	vErrs := ConfReferencesValid(s.refsTestAsConfig(Conf{"network.fabric.tls-name": "tls2"}, nil).GetFlatMap())
+	s.Require().Len(vErrs, 1)
+	s.Assert().Equal("network.fabric", vErrs[0].Context)
+	s.Assert().Equal("tls-name tls2 is not defined in network.tls", vErrs[0].Description)
+}
+
+// refsTestSchemaConfig returns a config valid against the 7.0.0 schema with
+// an undefined tls-name.
+func (s *ConfRefsTestSuite) refsTestSchemaConfig() *AsConfig {
+	cfg, err := NewMapAsConfig(logr.Discard(), map[string]interface{}{
+		"service": map[string]interface{}{"cluster-name": "prod"},
+		"network": map[string]interface{}{
+			"service":   map[string]interface{}{"port": 3000, "tls-name": "tls1"},
+			"heartbeat": map[string]interface{}{"mode": "mesh", "port": 3002},
+			"fabric":    map[string]interface{}{"port": 3001},
+		},
+		"namespaces": []map[string]interface{}{
+			{
+				"name":           "test",
+				"storage-engine": map[string]interface{}{"type": "memory", "data-size": 4294967296},
+			},
+		},
+	})
+	s.Require().NoError(err)
+
+	return cfg
+}
+
+func (s *ConfRefsTestSuite) TestReferencesValid() {
+	cfg := s.refsTestSchemaConfig()
+
+	// the references are not checked against the schema
+	valid, _, err := NewSchemaRegistryFromMap(logr.Discard(), testSchemas).IsValid(logr.Discard(), cfg, "7.0.0")
+	s.Require().NoError(err)
+	s.Assert().True(valid)
+
+	valid, vErrs, err := cfg.ReferencesValid()
+	s.Assert().False(valid)
+	s.Assert().ErrorIs(err, ErrConfigCrossReference)
+	s.Require().Len(vErrs, 1)
+	s.Assert().Equal("network.service.tls-name", vErrs[0].Field)
+	s.Assert().Equal("network.tls.{tls1}", vErrs[0].Ref)
+
+	valid, vErrs, err = s.refsTestAsConfig(nil, nil).ReferencesValid()
+	s.Require().NoError(err)
+	s.Assert().True(valid)
+	s.Assert().Empty(vErrs)
+}
+
+func (s *ConfRefsTestSuite) TestValidate() {
+	registry := NewSchemaRegistryFromMap(logr.Discard(), testSchemas)
+
+	testCases := []struct {
+		name     string
+		set      Conf
+		expected []string
+		err      error
+	}{
+		{
+			name:     "undefined reference",
+			expected: []string{"network.service.tls-name"},
+			err:      ErrConfigCrossReference,
+		},
+		{
+			name:     "schema error first",
+			set:      Conf{"namespaces.{test}.replication-factor": 300},
+			expected: []string{"namespaces.0.replication-factor", "network.service.tls-name"},
+			err:      ErrConfigSchema,
+		},
+		{
+			name: "valid",
+			set: Conf{
+				"network.tls.{tls1}.name":    "tls1",
+				"network.tls.{tls1}.<index>": 0,
+				"network.tls.{tls1}.ca-file": "/etc/aerospike/ca.pem",
+			},
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			cfg := s.refsTestSchemaConfig()
+			for k, v := range tc.set {
+				(*cfg.baseConf)[k] = v
+			}
+
+			valid, vErrs, err := registry.Validate(logr.Discard(), cfg, "7.0.0")
+
+			fields := make([]string, 0, len(vErrs))
+			for _, vErr := range vErrs {
+				fields = append(fields, vErr.Field)
+			}
+
+			s.Assert().Equal(tc.expected, fields)
+			s.Assert().Equal(tc.err == nil, valid)
+
+			if tc.err == nil {
+				s.Assert().NoError(err)
+			} else {
+				s.Assert().ErrorIs(err, tc.err)
+			}
+		})
+	}
+
+	_, _, err := registry.Validate(logr.Discard(), s.refsTestSchemaConfig(), "8.0.0")
+	s.Assert().ErrorContains(err, "failed to get aerospike config schema")
+}
+
+func (s *ConfRefsTestSuite) TestReferencesValidPosition() {
+	conf := `network {
+	service {
+		port 3000
+	}
+	heartbeat {
+		mode mesh
+		port 3002
+	}
+	fabric {
+		port 3001
+		tls-name tls1
+	}
+}
+`
+
+	cfg, err := FromConfFile(logr.Discard(), strings.NewReader(conf))
+	s.Require().NoError(err)
+
+	_, vErrs, err := cfg.ReferencesValid()
+	s.Assert().ErrorIs(err, ErrConfigCrossReference)
+	s.Require().Len(vErrs, 1)
+	s.Require().NotNil(vErrs[0].Position)
+	s.Assert().Equal(11, vErrs[0].Position.Line)
+}
+
+func TestConfRefsTestSuite(t *testing.T) {
+	suite.Run(t, new(ConfRefsTestSuite))
+}
@@ -0,0 +1,127 @@
+package asconfig
+
+import (
+	"bytes"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ToYAML returns the config as YAML, see Marshal. The output is
+// deterministic and loads back to the same config with NewASConfigFromBytes.
+func (cfg *AsConfig) ToYAML() ([]byte, error) {
+	return cfg.Marshal(YAML)
+}
+
+// marshalYAML writes the expanded config as YAML. The keys are in the order
+// of the aerospike.conf written by ToConfFile: the name and type of the list
+// and typed sections, then the fields sorted, then the sections sorted. The
+// keys of lists are plural, see PluralOf.
+func marshalYAML(conf Conf) ([]byte, error) {
+	node, err := confToYAMLNode(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func confToYAMLNode(conf Conf) (*yaml.Node, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, k := range yamlKeyOrder(conf) {
+		v := conf[k]
+		if v == nil {
+			continue
+		}
+
+		key := k
+
+		var (
+			value *yaml.Node
+			err   error
+		)
+
+		switch v := v.(type) {
+		case Conf:
+			value, err = confToYAMLNode(v)
+
+		case []Conf:
+			key = PluralOf(k)
+			value = &yaml.Node{Kind: yaml.SequenceNode}
+
+			for i := range v {
+				item, err := confToYAMLNode(v[i])
+				if err != nil {
+					return nil, err
+				}
+
+				value.Content = append(value.Content, item)
+			}
+
+		case []string:
+			key = PluralOf(k)
+			value = &yaml.Node{}
+			err = value.Encode(v)
+
+		default:
+			value = &yaml.Node{}
+			err = value.Encode(v)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+
+	return node, nil
+}
+
+// yamlKeyOrder returns the keys of conf in the order of writeDotConf, with
+// the name and type first as they are part of the section line in aerospike.conf.
+func yamlKeyOrder(conf Conf) []string {
+	keys := make([]string, 0, len(conf))
+	for k := range conf {
+		keys = append(keys, k)
+	}
+
+	rank := func(k string) int {
+		switch k {
+		case KeyName:
+			return 0
+		case keyType:
+			return 1
+		}
+
+		switch conf[k].(type) {
+		case Conf, []Conf:
+			return 3
+		default:
+			return 2
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		ri, rj := rank(keys[i]), rank(keys[j])
+		if ri != rj {
+			return ri < rj
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
@@ -45,4 +45,6 @@ const (
 	Invalid    Format = ""
 	YAML       Format = "yaml"
 	AeroConfig Format = "asconfig"
+	JSON       Format = "json"
+	TOML       Format = "toml"
 )
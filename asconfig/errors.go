@@ -26,3 +26,18 @@ var ErrConfigTransformUnsupported = fmt.Errorf("unsupported config transform")
 
 // ErrConfigKeyInvalid is invalid config key error
 var ErrConfigKeyInvalid = fmt.Errorf("invalid config key")
+
+// ErrConfigApply is config apply error
+var ErrConfigApply = fmt.Errorf("config apply error")
+
+// ErrConfigReference is unresolved or invalid variable or secret reference error
+var ErrConfigReference = fmt.Errorf("config reference error")
+
+// ErrConfigCrossReference is missing section referenced by another config section error
+var ErrConfigCrossReference = fmt.Errorf("config cross-reference error")
+
+// ErrConfigNetwork is conflicting or invalid network config error
+var ErrConfigNetwork = fmt.Errorf("config network error")
+
+// ErrPipelineStepNotFound is pipeline step not found error
+var ErrPipelineStepNotFound = fmt.Errorf("pipeline step not found")
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -13,11 +14,32 @@ import (
 	"github.com/go-logr/logr"
 )
 
+// Keys of the conf shared by the steps of the GenerateConf pipeline, in
+// addition to info.ConstConfigs and info.ConstMetadata.
 const (
-	expandConfKey     = "expanded_config"
-	flatConfKey       = "flat_config"
-	flatSchemaKey     = "flat_schema"
-	normFlatSchemaKey = "normalized_flat_schema"
+	// ExpandConfKey is the expanded config, the result of the pipeline.
+	ExpandConfKey = "expanded_config"
+	// FlatConfKey is the flat config the steps transform.
+	FlatConfKey = "flat_config"
+	// FlatSchemaKey is the flat schema of the server version. It is a copy
+	// of the schema cached by the registry, the steps can modify it.
+	FlatSchemaKey = "flat_schema"
+	// NormFlatSchemaKey is the flat schema with the keys of the flat config.
+	NormFlatSchemaKey = "normalized_flat_schema"
+)
+
+// Names of the built-in steps of the GenerateConf pipeline, in their order.
+const (
+	StepGetConfig                = "get-config"
+	StepServerVersionCheck       = "server-version-check"
+	StepGetFlatSchema            = "get-flat-schema"
+	StepRenameLoggingContexts    = "rename-logging-contexts"
+	StepFlattenConf              = "flatten-conf"
+	StepCopyEffectiveRackID      = "copy-effective-rack-id"
+	StepRemoveSecurityIfDisabled = "remove-security-if-disabled"
+	StepTransformKeyValues       = "transform-key-values"
+	StepRemoveDefaults           = "remove-defaults"
+	StepExpandConf               = "expand-conf"
 )
 
 // namespaceRe is a regular expression used to match and extract namespace configurations from the config file.
@@ -49,44 +71,78 @@ func newGenConf(conf Conf, version string) *GenConf {
 // Without removeDefaults, the config that is generate will not be valid. Many
 // default values are out of the acceptable range required by the server.
 func GenerateConf(log logr.Logger, confGetter ConfGetter, removeDefaults bool) (*GenConf, error) {
-	log.V(1).Info("Generating config")
+	return defaultRegistry.GenerateConf(log, confGetter, removeDefaults)
+}
 
-	validConfig := Conf{}
+// GenerateConf is GenerateConf using the schemas of the registry.
+func (r *SchemaRegistry) GenerateConf(log logr.Logger, confGetter ConfGetter, removeDefaults bool) (
+	*GenConf, error,
+) {
+	return GenerateConfWithPipeline(log, r.NewGeneratePipeline(log, confGetter, removeDefaults))
+}
 
+// NewGeneratePipeline returns the pipeline run by GenerateConf. Steps can be
+// added, removed or replaced before it is run by GenerateConfWithPipeline.
+func NewGeneratePipeline(log logr.Logger, confGetter ConfGetter, removeDefaults bool) *Pipeline {
+	return defaultRegistry.NewGeneratePipeline(log, confGetter, removeDefaults)
+}
+
+// NewGeneratePipeline is NewGeneratePipeline using the schemas of the registry.
+func (r *SchemaRegistry) NewGeneratePipeline(log logr.Logger, confGetter ConfGetter, removeDefaults bool) *Pipeline {
 	// Flatten the config returned from the server. Then convert it to a map
 	// that is valid according to the schema.
-	p := newPipeline(log, []pipelineStep{
-		newGetConfigStep(log, confGetter),
-		newServerVersionCheckStep(log, isSupportedGenerateVersion),
-		newGetFlatSchemaStep(log),
-		newRenameLoggingContextsStep(log),
-		newFlattenConfStep(log),
-		newCopyEffectiveRackIDStep(log),
-		newRemoveSecurityIfDisabledStep(log),
-		newTransformKeyValuesStep(log),
-	})
+	p := NewPipeline(log,
+		NewGetConfigStep(log, confGetter),
+		NewServerVersionCheckStep(log, r.isSupportedGenerateVersion),
+		NewGetFlatSchemaStep(log, r),
+		NewRenameLoggingContextsStep(log),
+		NewFlattenConfStep(log),
+		NewCopyEffectiveRackIDStep(log),
+		NewRemoveSecurityIfDisabledStep(log),
+		NewTransformKeyValuesStep(log),
+	)
 
 	if removeDefaults {
-		p.addStep(newRemoveDefaultsStep(log))
+		p.Append(NewRemoveDefaultsStep(log))
 	}
 
-	p.addStep(newExpandConfStep(log))
+	p.Append(NewExpandConfStep(log))
+
+	return p
+}
+
+// GenerateConfWithPipeline generates the config with the pipeline p, eg. a
+// pipeline returned by NewGeneratePipeline with custom steps. The pipeline
+// must set info.ConstMetadata and ExpandConfKey.
+func GenerateConfWithPipeline(log logr.Logger, p *Pipeline) (*GenConf, error) {
+	log.V(1).Info("Generating config")
 
-	err := p.execute(validConfig)
+	validConfig := Conf{}
+
+	err := p.Execute(validConfig)
 	if err != nil {
 		log.Error(err, "Error generating config")
 		return nil, err
 	}
 
-	return newGenConf(
-		validConfig[expandConfKey].(Conf),
-		validConfig[info.ConstMetadata].(Conf)[info.MetaBuild].(string),
-	), nil
+	expanded, ok := validConfig[ExpandConfKey].(Conf)
+	if !ok {
+		return nil, fmt.Errorf("pipeline did not set %s", ExpandConfKey)
+	}
+
+	metadata, _ := validConfig[info.ConstMetadata].(Conf)
+
+	build, ok := metadata[info.MetaBuild].(string)
+	if !ok {
+		return nil, fmt.Errorf("pipeline did not set %s.%s", info.ConstMetadata, info.MetaBuild)
+	}
+
+	return newGenConf(expanded, build), nil
 }
 
 // isSupportedGenerateVersion checks if the provided version is supported for generating the config.
-func isSupportedGenerateVersion(version string) (bool, error) {
-	s, err := IsSupportedVersion(version)
+func (r *SchemaRegistry) isSupportedGenerateVersion(version string) (bool, error) {
+	s, err := r.IsSupportedVersion(version)
 	if err != nil {
 		return false, err
 	}
@@ -100,85 +156,218 @@ func isSupportedGenerateVersion(version string) (bool, error) {
 	return cmp >= 0, err
 }
 
-// pipelineStep is an interface that defines a step in the pipeline for generating the config.
-type pipelineStep interface {
-	execute(conf Conf) error
+// PipelineStep is a step of a Pipeline. The steps of GenerateConf share a
+// conf holding the info of the node, the flat config and the flat schema, see
+// FlatConfKey. A custom step can eg. redact or drop fields of the flat config.
+type PipelineStep interface {
+	// Name identifies the step in the Pipeline, the logs and the errors.
+	Name() string
+	// Execute runs the step on the conf shared by the steps.
+	Execute(conf Conf) error
+}
+
+// funcStep is a PipelineStep running a function.
+type funcStep struct {
+	fn   func(conf Conf) error
+	name string
 }
 
-// pipeline represents a pipeline for generating the config.
-type pipeline struct {
+// NewPipelineStep returns a PipelineStep named name running fn.
+func NewPipelineStep(name string, fn func(conf Conf) error) PipelineStep {
+	return &funcStep{
+		name: name,
+		fn:   fn,
+	}
+}
+
+func (s *funcStep) Name() string {
+	return s.name
+}
+
+func (s *funcStep) Execute(conf Conf) error {
+	return s.fn(conf)
+}
+
+// Pipeline runs named steps in order, eg. the steps generating the config
+// in GenerateConf.
+type Pipeline struct {
 	log   logr.Logger
-	steps []pipelineStep
+	steps []PipelineStep
 }
 
-// newPipeline creates a new pipeline with the provided log and steps.
-func newPipeline(log logr.Logger, steps []pipelineStep) *pipeline {
-	return &pipeline{
+// NewPipeline creates a new pipeline with the provided log and steps.
+func NewPipeline(log logr.Logger, steps ...PipelineStep) *Pipeline {
+	return &Pipeline{
 		log:   log,
 		steps: steps,
 	}
 }
 
-func (p *pipeline) addStep(step pipelineStep) {
+// Steps returns the names of the steps in order.
+func (p *Pipeline) Steps() []string {
+	names := make([]string, len(p.steps))
+	for i, step := range p.steps {
+		names[i] = step.Name()
+	}
+
+	return names
+}
+
+// Append adds the step at the end of the pipeline.
+func (p *Pipeline) Append(step PipelineStep) *Pipeline {
 	p.steps = append(p.steps, step)
+	return p
+}
+
+// InsertBefore adds the step before the step named name.
+func (p *Pipeline) InsertBefore(name string, step PipelineStep) error {
+	idx, err := p.index(name)
+	if err != nil {
+		return err
+	}
+
+	p.steps = append(p.steps[:idx], append([]PipelineStep{step}, p.steps[idx:]...)...)
+
+	return nil
+}
+
+// InsertAfter adds the step after the step named name.
+func (p *Pipeline) InsertAfter(name string, step PipelineStep) error {
+	idx, err := p.index(name)
+	if err != nil {
+		return err
+	}
+
+	p.steps = append(p.steps[:idx+1], append([]PipelineStep{step}, p.steps[idx+1:]...)...)
+
+	return nil
+}
+
+// Remove removes the step named name.
+func (p *Pipeline) Remove(name string) error {
+	idx, err := p.index(name)
+	if err != nil {
+		return err
+	}
+
+	p.steps = append(p.steps[:idx], p.steps[idx+1:]...)
+
+	return nil
 }
 
-// execute executes the pipeline steps on the provided config.
-func (p *pipeline) execute(conf Conf) error {
+// Replace replaces the step named name by step.
+func (p *Pipeline) Replace(name string, step PipelineStep) error {
+	idx, err := p.index(name)
+	if err != nil {
+		return err
+	}
+
+	p.steps[idx] = step
+
+	return nil
+}
+
+func (p *Pipeline) index(name string) (int, error) {
+	for i, step := range p.steps {
+		if step.Name() == name {
+			return i, nil
+		}
+	}
+
+	return -1, fmt.Errorf("%w: %s", ErrPipelineStepNotFound, name)
+}
+
+// Execute executes the pipeline steps on the provided config. The error of a
+// step is returned with the name of the step.
+func (p *Pipeline) Execute(conf Conf) error {
 	for _, step := range p.steps {
-		err := step.execute(conf)
-		if err != nil {
-			return err
+		p.log.V(1).Info("Running pipeline step", "step", step.Name())
+
+		if err := step.Execute(conf); err != nil {
+			return fmt.Errorf("step %s: %w", step.Name(), err)
 		}
 	}
 
 	return nil
 }
 
-// GetFlatSchema
+// GetFlatSchemaStep is a pipeline step that gets the flat schema of the server version.
 type GetFlatSchemaStep struct {
-	log logr.Logger
+	registry *SchemaRegistry
+	log      logr.Logger
 }
 
-func newGetFlatSchemaStep(log logr.Logger) *GetFlatSchemaStep {
+// NewGetFlatSchemaStep creates a new GetFlatSchemaStep with the provided log and registry.
+func NewGetFlatSchemaStep(log logr.Logger, registry *SchemaRegistry) *GetFlatSchemaStep {
 	return &GetFlatSchemaStep{
-		log: log,
+		log:      log,
+		registry: registry,
 	}
 }
 
-func (s *GetFlatSchemaStep) execute(conf Conf) error {
+// Name returns StepGetFlatSchema.
+func (s *GetFlatSchemaStep) Name() string {
+	return StepGetFlatSchema
+}
+
+// Execute gets the flat schema of the server version.
+func (s *GetFlatSchemaStep) Execute(conf Conf) error {
 	s.log.V(1).Info("Getting flat schema")
 
 	build := conf[info.ConstMetadata].(Conf)[info.MetaBuild].(string)
 
-	flatSchema, err := getFlatSchema(build)
+	flatSchema, err := s.registry.getFlatSchema(build)
 	if err != nil {
 		s.log.V(-1).Error(err, "Error getting flat schema")
 		return err
 	}
 
-	conf[flatSchemaKey] = flatSchema
-	conf[normFlatSchemaKey] = normalizeFlatSchema(flatSchema)
+	// the cached flat schema is shared, the steps get their own copy
+	flatSchema = copyFlatSchema(flatSchema)
+
+	conf[FlatSchemaKey] = flatSchema
+	conf[NormFlatSchemaKey] = normalizeFlatSchema(flatSchema)
 
 	return nil
 }
 
+// copyFlatSchema returns a copy of the flat schema, with copies of its list
+// values, eg. the enums.
+func copyFlatSchema(flatSchema map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(flatSchema))
+
+	for k, v := range flatSchema {
+		if l, ok := v.([]interface{}); ok {
+			v = slices.Clone(l)
+		}
+
+		res[k] = v
+	}
+
+	return res
+}
+
 // GetConfigStep is a pipeline step that retrieves the configs and metadata.
 type GetConfigStep struct {
 	confGetter ConfGetter
 	log        logr.Logger
 }
 
-// newGetConfigStep creates a new GetConfigStep with the provided log and ConfGetter.
-func newGetConfigStep(log logr.Logger, confGetter ConfGetter) *GetConfigStep {
+// NewGetConfigStep creates a new GetConfigStep with the provided log and ConfGetter.
+func NewGetConfigStep(log logr.Logger, confGetter ConfGetter) *GetConfigStep {
 	return &GetConfigStep{
 		confGetter: confGetter,
 		log:        log,
 	}
 }
 
-// execute retrieves the configs and metadata using the ConfGetter.
-func (s *GetConfigStep) execute(conf Conf) error {
+// Name returns StepGetConfig.
+func (s *GetConfigStep) Name() string {
+	return StepGetConfig
+}
+
+// Execute retrieves the configs and metadata using the ConfGetter.
+func (s *GetConfigStep) Execute(conf Conf) error {
 	s.log.V(1).Info("Getting configs and metadata")
 
 	configs, err := s.confGetter.AllConfigs()
@@ -212,16 +401,21 @@ type ServerVersionCheckStep struct {
 	log       logr.Logger
 }
 
-// newServerVersionCheckStep creates a new ServerVersionCheckStep with the provided log and check function.
-func newServerVersionCheckStep(log logr.Logger, checkFunc func(string) (bool, error)) *ServerVersionCheckStep {
+// NewServerVersionCheckStep creates a new ServerVersionCheckStep with the provided log and check function.
+func NewServerVersionCheckStep(log logr.Logger, checkFunc func(string) (bool, error)) *ServerVersionCheckStep {
 	return &ServerVersionCheckStep{
 		checkFunc: checkFunc,
 		log:       log,
 	}
 }
 
-// execute checks if the server version is supported using the check function.
-func (s *ServerVersionCheckStep) execute(conf Conf) error {
+// Name returns StepServerVersionCheck.
+func (s *ServerVersionCheckStep) Name() string {
+	return StepServerVersionCheck
+}
+
+// Execute checks if the server version is supported using the check function.
+func (s *ServerVersionCheckStep) Execute(conf Conf) error {
 	s.log.V(1).Info("Checking server version")
 
 	build := conf[info.ConstMetadata].(Conf)[info.MetaBuild].(string)
@@ -240,23 +434,28 @@ func (s *ServerVersionCheckStep) execute(conf Conf) error {
 	return nil
 }
 
-// copyEffectiveRackIDStep is a pipeline step that copies the effective rack-id to rack-id.
-type copyEffectiveRackIDStep struct {
+// CopyEffectiveRackIDStep is a pipeline step that copies the effective rack-id to rack-id.
+type CopyEffectiveRackIDStep struct {
 	log logr.Logger
 }
 
-// newCopyEffectiveRackIDStep creates a new copyEffectiveRackIDStep with the provided log.
-func newCopyEffectiveRackIDStep(log logr.Logger) *copyEffectiveRackIDStep {
-	return &copyEffectiveRackIDStep{
+// NewCopyEffectiveRackIDStep creates a new CopyEffectiveRackIDStep with the provided log.
+func NewCopyEffectiveRackIDStep(log logr.Logger) *CopyEffectiveRackIDStep {
+	return &CopyEffectiveRackIDStep{
 		log: log,
 	}
 }
 
+// Name returns StepCopyEffectiveRackID.
+func (s *CopyEffectiveRackIDStep) Name() string {
+	return StepCopyEffectiveRackID
+}
+
 // rackRegex is a regular expression used to match and extract rack IDs.
 var rackRegex = regexp.MustCompile(`rack_(\d+)`)
 
-// execute copies the effective rack-id to rack-id in the config.
-func (s *copyEffectiveRackIDStep) execute(conf Conf) error {
+// Execute copies the effective rack-id to rack-id in the config.
+func (s *CopyEffectiveRackIDStep) Execute(conf Conf) error {
 	s.log.V(1).Info("Copying effective rack-id to rack-id")
 
 	if _, ok := conf[info.ConfigRacksContext]; !ok {
@@ -264,7 +463,7 @@ func (s *copyEffectiveRackIDStep) execute(conf Conf) error {
 		return nil
 	}
 
-	flatConfig := conf[flatConfKey].(Conf)
+	flatConfig := conf[FlatConfKey].(Conf)
 	effectiveRacks := conf[info.ConfigRacksContext].([]Conf)
 	nodeID := conf[info.ConstMetadata].(Conf)["node_id"].(string)
 
@@ -308,20 +507,25 @@ func (s *copyEffectiveRackIDStep) execute(conf Conf) error {
 	return nil
 }
 
-// renameKeysStep is a pipeline step that renames logging contexts in the config.
-type renameKeysStep struct {
+// RenameKeysStep is a pipeline step that renames logging contexts in the config.
+type RenameKeysStep struct {
 	log logr.Logger
 }
 
-// newRenameLoggingContextsStep creates a new renameKeysStep with the provided log.
-func newRenameLoggingContextsStep(log logr.Logger) *renameKeysStep {
-	return &renameKeysStep{
+// NewRenameLoggingContextsStep creates a new RenameKeysStep with the provided log.
+func NewRenameLoggingContextsStep(log logr.Logger) *RenameKeysStep {
+	return &RenameKeysStep{
 		log: log,
 	}
 }
 
-// execute renames logging contexts in the config.
-func (s *renameKeysStep) execute(conf Conf) error {
+// Name returns StepRenameLoggingContexts.
+func (s *RenameKeysStep) Name() string {
+	return StepRenameLoggingContexts
+}
+
+// Execute renames logging contexts in the config.
+func (s *RenameKeysStep) Execute(conf Conf) error {
 	s.log.V(1).Info("Renaming keys")
 
 	config := conf[info.ConstConfigs].(Conf)
@@ -360,18 +564,23 @@ func (s *renameKeysStep) execute(conf Conf) error {
 	return nil
 }
 
-// flattenConfStep is a pipeline step that flattens the config.
-type flattenConfStep struct {
+// FlattenConfStep is a pipeline step that flattens the config.
+type FlattenConfStep struct {
 	log logr.Logger
 }
 
-// newFlattenConfStep creates a new flattenConfStep with the provided log.
-func newFlattenConfStep(log logr.Logger) *flattenConfStep {
-	return &flattenConfStep{
+// NewFlattenConfStep creates a new FlattenConfStep with the provided log.
+func NewFlattenConfStep(log logr.Logger) *FlattenConfStep {
+	return &FlattenConfStep{
 		log: log,
 	}
 }
 
+// Name returns StepFlattenConf.
+func (s *FlattenConfStep) Name() string {
+	return StepFlattenConf
+}
+
 func sortKeys(config Conf) []string {
 	keys := make([]string, len(config))
 	idx := 0
@@ -482,8 +691,8 @@ func convertDictRespToConf(config Conf) {
 	}
 }
 
-// execute flattens the config.
-func (s *flattenConfStep) execute(conf Conf) error {
+// Execute flattens the config.
+func (s *FlattenConfStep) Execute(conf Conf) error {
 	s.log.V(1).Info("Flattening config")
 
 	origConfig := conf[info.ConstConfigs].(Conf)
@@ -495,23 +704,28 @@ func (s *flattenConfStep) execute(conf Conf) error {
 		return err
 	}
 
-	conf[flatConfKey] = flatConf
+	conf[FlatConfKey] = flatConf
 
 	return nil
 }
 
-// transformKeyValuesStep is a pipeline step that transforms key values in the config.
-type transformKeyValuesStep struct {
+// TransformKeyValuesStep is a pipeline step that transforms key values in the config.
+type TransformKeyValuesStep struct {
 	log logr.Logger
 }
 
-// newTransformKeyValuesStep creates a new transformKeyValuesStep with the provided log.
-func newTransformKeyValuesStep(log logr.Logger) *transformKeyValuesStep {
-	return &transformKeyValuesStep{
+// NewTransformKeyValuesStep creates a new TransformKeyValuesStep with the provided log.
+func NewTransformKeyValuesStep(log logr.Logger) *TransformKeyValuesStep {
+	return &TransformKeyValuesStep{
 		log: log,
 	}
 }
 
+// Name returns StepTransformKeyValues.
+func (s *TransformKeyValuesStep) Name() string {
+	return StepTransformKeyValues
+}
+
 func splitContextBaseKey(key string) (contextKey, bKey string) {
 	bKey = BaseKey(key)
 	contextKey = strings.TrimSuffix(key, bKey)
@@ -596,11 +810,11 @@ func parseIndexField(key string) (newKey string, index int, extra string, err er
 	return newKey, index, extra, nil
 }
 
-// execute transforms key values in the config.
-func (s *transformKeyValuesStep) execute(conf Conf) error {
+// Execute transforms key values in the config.
+func (s *TransformKeyValuesStep) Execute(conf Conf) error {
 	s.log.V(1).Info("Transforming key values")
 
-	origFlatConf := conf[flatConfKey].(Conf)
+	origFlatConf := conf[FlatConfKey].(Conf)
 	newFlatConf := make(Conf, len(origFlatConf)) // we will overwrite flat_config
 	sortedKeys := sortKeys(origFlatConf)
 	scNamespaces := []string{}
@@ -696,7 +910,7 @@ func (s *transformKeyValuesStep) execute(conf Conf) error {
 			}
 		}
 
-		nFlatSchema := conf[normFlatSchemaKey].(map[string]interface{})
+		nFlatSchema := conf[NormFlatSchemaKey].(map[string]interface{})
 		normalizedKey := namedRe.ReplaceAllString(key, "_")
 
 		if _, ok := nFlatSchema[normalizedKey+sep+"default"]; !ok && !isInternalField(normalizedKey) {
@@ -716,28 +930,33 @@ func (s *transformKeyValuesStep) execute(conf Conf) error {
 		}
 	}
 
-	conf[flatConfKey] = newFlatConf
+	conf[FlatConfKey] = newFlatConf
 
 	return nil
 }
 
-// removeSecurityIfDisabledStep is a pipeline step that removes security configurations if security is disabled.
-type removeSecurityIfDisabledStep struct {
+// RemoveSecurityIfDisabledStep is a pipeline step that removes security configurations if security is disabled.
+type RemoveSecurityIfDisabledStep struct {
 	log logr.Logger
 }
 
-// newRemoveSecurityIfDisabledStep creates a new removeSecurityIfDisabledStep with the provided log.
-func newRemoveSecurityIfDisabledStep(log logr.Logger) *removeSecurityIfDisabledStep {
-	return &removeSecurityIfDisabledStep{
+// NewRemoveSecurityIfDisabledStep creates a new RemoveSecurityIfDisabledStep with the provided log.
+func NewRemoveSecurityIfDisabledStep(log logr.Logger) *RemoveSecurityIfDisabledStep {
+	return &RemoveSecurityIfDisabledStep{
 		log: log,
 	}
 }
 
-// execute removes security configurations if security is disabled.
-func (s *removeSecurityIfDisabledStep) execute(conf Conf) error {
+// Name returns StepRemoveSecurityIfDisabled.
+func (s *RemoveSecurityIfDisabledStep) Name() string {
+	return StepRemoveSecurityIfDisabled
+}
+
+// Execute removes security configurations if security is disabled.
+func (s *RemoveSecurityIfDisabledStep) Execute(conf Conf) error {
 	s.log.V(1).Info("Removing security configs if security is disabled")
 
-	flatConf := conf[flatConfKey].(Conf)
+	flatConf := conf[FlatConfKey].(Conf)
 	build := conf[info.ConstMetadata].(Conf)[info.MetaBuild].(string)
 
 	if val, ok := flatConf["security.enable-security"]; ok {
@@ -775,16 +994,23 @@ func (s *removeSecurityIfDisabledStep) execute(conf Conf) error {
 	return nil
 }
 
-type removeDefaultsStep struct {
+// RemoveDefaultsStep is a pipeline step that removes the default values from the config.
+type RemoveDefaultsStep struct {
 	log logr.Logger
 }
 
-func newRemoveDefaultsStep(log logr.Logger) *removeDefaultsStep {
-	return &removeDefaultsStep{
+// NewRemoveDefaultsStep creates a new RemoveDefaultsStep with the provided log.
+func NewRemoveDefaultsStep(log logr.Logger) *RemoveDefaultsStep {
+	return &RemoveDefaultsStep{
 		log: log,
 	}
 }
 
+// Name returns StepRemoveDefaults.
+func (s *RemoveDefaultsStep) Name() string {
+	return StepRemoveDefaults
+}
+
 func compareDefaults(log logr.Logger, defVal, confVal interface{}) bool {
 	switch val := defVal.(type) {
 	case []interface{}:
@@ -847,12 +1073,13 @@ func defaultSlice(m map[string][]string, key string) []string {
 	return []string{}
 }
 
-func (s *removeDefaultsStep) execute(conf Conf) error {
+// Execute removes the default values from the config.
+func (s *RemoveDefaultsStep) Execute(conf Conf) error {
 	s.log.V(1).Info("Removing default values")
 
-	flatConf := conf[flatConfKey].(Conf)
-	flatSchema := conf[flatSchemaKey].(map[string]interface{})
-	nFlatSchema := conf[normFlatSchemaKey].(map[string]interface{})
+	flatConf := conf[FlatConfKey].(Conf)
+	flatSchema := conf[FlatSchemaKey].(map[string]interface{})
+	nFlatSchema := conf[NormFlatSchemaKey].(map[string]interface{})
 	defaults := getDefaultSchema(flatSchema)
 
 	// "logging.<file>" -> "log-level" -> list of contexts with that level
@@ -951,26 +1178,31 @@ func (s *removeDefaultsStep) execute(conf Conf) error {
 	return nil
 }
 
-// expandConfStep is a pipeline step that expands the config.
-type expandConfStep struct {
+// ExpandConfStep is a pipeline step that expands the config.
+type ExpandConfStep struct {
 	log logr.Logger
 }
 
-// newExpandConfStep creates a new expandConfStep with the provided log.
-func newExpandConfStep(log logr.Logger) *expandConfStep {
-	return &expandConfStep{
+// NewExpandConfStep creates a new ExpandConfStep with the provided log.
+func NewExpandConfStep(log logr.Logger) *ExpandConfStep {
+	return &ExpandConfStep{
 		log: log,
 	}
 }
 
-// execute expands the config.
-func (s *expandConfStep) execute(conf Conf) error {
+// Name returns StepExpandConf.
+func (s *ExpandConfStep) Name() string {
+	return StepExpandConf
+}
+
+// Execute expands the config.
+func (s *ExpandConfStep) Execute(conf Conf) error {
 	s.log.V(1).Info("Expanding config")
 
-	flatConf := conf[flatConfKey].(Conf)
+	flatConf := conf[FlatConfKey].(Conf)
 	expandedConf := expandConf(s.log, &flatConf, sep)
 
-	conf[expandConfKey] = expandedConf
+	conf[ExpandConfKey] = expandedConf
 
 	return nil
 }
@@ -1,6 +1,7 @@
 package asconfig
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"testing"
@@ -105,6 +106,102 @@ func TestGenerateTestSuiteSuite(t *testing.T) {
 	suite.Run(t, new(GenerateUnitTestSuite))
 }
 
+type GeneratePipelineTestSuite struct {
+	suite.Suite
+	ctrl *gomock.Controller
+}
+
+func (s *GeneratePipelineTestSuite) SetupSuite() {
+	InitFromMap(logr.Discard(), testSchemas)
+}
+
+func (s *GeneratePipelineTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+}
+
+func (s *GeneratePipelineTestSuite) mockGetter() ConfGetter {
+	getter := NewMockConfGetter(s.ctrl)
+	getter.EXPECT().AllConfigs().Return(clusterTestConfigs("A1", "10.0.0.1", 20000, "/dev/xvdb"), nil)
+	getter.EXPECT().GetAsInfo("metadata").Return(Conf{"metadata": Conf{"asd_build": "7.0.0.1"}}, nil)
+
+	return getter
+}
+
+func (s *GeneratePipelineTestSuite) TestNewGeneratePipeline() {
+	p := NewGeneratePipeline(logr.Discard(), nil, true)
+	s.Assert().Equal([]string{
+		StepGetConfig, StepServerVersionCheck, StepGetFlatSchema, StepRenameLoggingContexts, StepFlattenConf,
+		StepCopyEffectiveRackID, StepRemoveSecurityIfDisabled, StepTransformKeyValues, StepRemoveDefaults,
+		StepExpandConf,
+	}, p.Steps())
+
+	p = NewGeneratePipeline(logr.Discard(), nil, false)
+	s.Assert().NotContains(p.Steps(), StepRemoveDefaults)
+}
+
+func (s *GeneratePipelineTestSuite) TestCustomSteps() {
+	p := NewGeneratePipeline(logr.Discard(), s.mockGetter(), true)
+
+	s.Require().NoError(p.InsertAfter(StepTransformKeyValues, NewPipelineStep("pin-node-id", func(conf Conf) error {
+		conf[FlatConfKey].(Conf)["service.node-id"] = "${NODE_ID}"
+		return nil
+	})))
+	s.Require().NoError(p.InsertBefore(StepExpandConf, NewPipelineStep("drop-proto-fd-max", func(conf Conf) error {
+		delete(conf[FlatConfKey].(Conf), "service.proto-fd-max")
+		return nil
+	})))
+	s.Require().NoError(p.Remove(StepServerVersionCheck))
+
+	genConf, err := GenerateConfWithPipeline(logr.Discard(), p)
+	s.Require().NoError(err)
+	s.Assert().Equal(Conf{"cluster-name": "prod", "node-id": "${NODE_ID}"}, genConf.Conf["service"])
+	s.Assert().Equal("7.0.0.1", genConf.Version)
+}
+
+func (s *GeneratePipelineTestSuite) TestStepsCannotCorruptSchemaCache() {
+	p := NewGeneratePipeline(logr.Discard(), s.mockGetter(), true)
+
+	s.Require().NoError(p.InsertAfter(StepGetFlatSchema, NewPipelineStep("mutate-schema", func(conf Conf) error {
+		flatSchema := conf[FlatSchemaKey].(map[string]interface{})
+		for k := range flatSchema {
+			delete(flatSchema, k)
+		}
+
+		return nil
+	})))
+
+	_, err := GenerateConfWithPipeline(logr.Discard(), p)
+	s.Require().NoError(err)
+
+	flatSchema, err := defaultRegistry.getFlatSchema("7.0.0")
+	s.Require().NoError(err)
+	s.Assert().NotEmpty(flatSchema)
+
+	_, err = GenerateConf(logr.Discard(), s.mockGetter(), true)
+	s.Assert().NoError(err)
+}
+
+func (s *GeneratePipelineTestSuite) TestStepErrors() {
+	p := NewGeneratePipeline(logr.Discard(), s.mockGetter(), true)
+
+	s.Require().NoError(p.Replace(StepFlattenConf, NewPipelineStep("broken-flatten", func(Conf) error {
+		return fmt.Errorf("broken")
+	})))
+
+	_, err := GenerateConfWithPipeline(logr.Discard(), p)
+	s.Assert().EqualError(err, "step broken-flatten: broken")
+
+	s.Assert().ErrorIs(p.Remove(StepFlattenConf), ErrPipelineStepNotFound)
+	s.Assert().ErrorIs(p.InsertAfter("missing", NewExpandConfStep(logr.Discard())), ErrPipelineStepNotFound)
+
+	_, err = GenerateConfWithPipeline(logr.Discard(), NewPipeline(logr.Discard()))
+	s.Assert().ErrorContains(err, "pipeline did not set expanded_config")
+}
+
+func TestGeneratePipelineTestSuite(t *testing.T) {
+	suite.Run(t, new(GeneratePipelineTestSuite))
+}
+
 var loggingTC = GenerateTC{
 	"logging",
 	false,
@@ -0,0 +1,206 @@
+package asconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// ClusterGenConf is the config of a cluster generated by GenerateClusterConf.
+type ClusterGenConf struct {
+	// Base is the config common to all the nodes, in the form of GenConf.Conf.
+	Base Conf
+	// NodeDeltas are the flat keys of each node, by node name, whose value
+	// is not the same on all the nodes, eg. "network.service.access-addresses".
+	NodeDeltas map[string]Conf
+	// Versions are the server versions of the nodes, by node name.
+	Versions map[string]string
+	// Drift are the keys of NodeDeltas which are not expected to differ
+	// between the nodes, sorted by key.
+	Drift []ConfDrift
+}
+
+// ConfDrift is a flat key which is not node specific but whose value is not
+// the same on all the nodes.
+type ConfDrift struct {
+	// Values are the values of the key by node name. The nodes not setting
+	// the key are not in Values.
+	Values map[string]interface{}
+	Key    string
+}
+
+// Nodes returns the names of the nodes setting the drifted key, sorted.
+func (d *ConfDrift) Nodes() []string {
+	nodes := make([]string, 0, len(d.Values))
+	for node := range d.Values {
+		nodes = append(nodes, node)
+	}
+
+	sort.Strings(nodes)
+
+	return nodes
+}
+
+// GenerateClusterConf generates the config of every node of a cluster, see
+// GenerateConf, and splits it into the base config common to all the nodes
+// and the per node deltas. The confGetters are the ConfGetter of each node
+// by node name.
+//
+// The node specific fields, see isNodeSpecificField, and the logging
+// contexts are expected to differ between the nodes. Any other key of the
+// deltas is reported in Drift.
+func GenerateClusterConf(log logr.Logger, confGetters map[string]ConfGetter, removeDefaults bool) (
+	*ClusterGenConf, error,
+) {
+	return defaultRegistry.GenerateClusterConf(log, confGetters, removeDefaults)
+}
+
+// GenerateClusterConf is GenerateClusterConf using the schemas of the registry.
+func (r *SchemaRegistry) GenerateClusterConf(log logr.Logger, confGetters map[string]ConfGetter,
+	removeDefaults bool) (*ClusterGenConf, error) {
+	if len(confGetters) == 0 {
+		return nil, fmt.Errorf("no node to generate the config from")
+	}
+
+	nodes := make([]string, 0, len(confGetters))
+	for node := range confGetters {
+		nodes = append(nodes, node)
+	}
+
+	sort.Strings(nodes)
+
+	res := &ClusterGenConf{
+		NodeDeltas: make(map[string]Conf, len(nodes)),
+		Versions:   make(map[string]string, len(nodes)),
+	}
+
+	flatConfs := make(map[string]Conf, len(nodes))
+
+	for _, node := range nodes {
+		genConf, err := r.GenerateConf(log.WithValues("node", node), confGetters[node], removeDefaults)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate config of node %s: %w", node, err)
+		}
+
+		flatConf, err := flattenConf(log, genConf.Conf, sep)
+		if err != nil {
+			return nil, fmt.Errorf("failed to flatten config of node %s: %w", node, err)
+		}
+
+		flatConfs[node] = flatConf
+		res.Versions[node] = genConf.Version
+	}
+
+	base := make(Conf)
+	first := flatConfs[nodes[0]]
+
+	for k, v := range first {
+		if BaseKey(k) == keyIndex {
+			continue
+		}
+
+		common := true
+
+		for _, node := range nodes[1:] {
+			v2, ok := flatConfs[node][k]
+			if !ok || isClusterValueDiff(log, v, v2) {
+				common = false
+				break
+			}
+		}
+
+		if common {
+			base[k] = v
+		}
+	}
+
+	addBaseIndexes(log, base, first)
+
+	res.Base = expandConf(log, &base, sep)
+
+	diverged := make(map[string]map[string]interface{})
+
+	for _, node := range nodes {
+		delta := make(Conf)
+
+		for k, v := range flatConfs[node] {
+			if _, ok := base[k]; ok || isInternalField(k) {
+				continue
+			}
+
+			delta[k] = v
+
+			if isNodeSpecificContext(k) || isNodeSpecificField(BaseKey(k)) {
+				continue
+			}
+
+			if diverged[k] == nil {
+				diverged[k] = make(map[string]interface{})
+			}
+
+			diverged[k][node] = v
+		}
+
+		res.NodeDeltas[node] = delta
+	}
+
+	for k, values := range diverged {
+		res.Drift = append(res.Drift, ConfDrift{Key: k, Values: values})
+	}
+
+	sort.Slice(res.Drift, func(i, j int) bool {
+		return res.Drift[i].Key < res.Drift[j].Key
+	})
+
+	return res, nil
+}
+
+// isClusterValueDiff is isValueDiff on copies of the lists, which isValueDiff
+// still sorts, so that the lists of the configs are left untouched.
+func isClusterValueDiff(log logr.Logger, v1, v2 interface{}) bool {
+	l1, ok1 := v1.([]string)
+	l2, ok2 := v2.([]string)
+
+	if ok1 && ok2 {
+		v1 = append([]string{}, l1...)
+		v2 = append([]string{}, l2...)
+	}
+
+	return isValueDiff(log, v1, v2)
+}
+
+// addBaseIndexes adds the "<index>" keys of the list sections in base. The
+// list sections keep the order they have in flatConf, the indexes are
+// renumbered as some sections may not be in base.
+func addBaseIndexes(log logr.Logger, base, flatConf Conf) {
+	lists := make(map[string][]string)
+
+	for k := range flatConf {
+		if !strings.HasSuffix(k, sep+keyIndex) {
+			continue
+		}
+
+		prefix := strings.TrimSuffix(k, keyIndex)
+
+		for bk := range base {
+			if strings.HasPrefix(bk, prefix) {
+				list := listSectionOf(log, k)
+				lists[list] = append(lists[list], k)
+
+				break
+			}
+		}
+	}
+
+	for _, keys := range lists {
+		sort.Slice(keys, func(i, j int) bool {
+			return flatConf[keys[i]].(int) < flatConf[keys[j]].(int)
+		})
+
+		for i, k := range keys {
+			base[k] = i
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package asconfig
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+)
+
+type GenerateClusterTestSuite struct {
+	suite.Suite
+	ctrl *gomock.Controller
+}
+
+func (s *GenerateClusterTestSuite) SetupSuite() {
+	InitFromMap(logr.Discard(), testSchemas)
+}
+
+func (s *GenerateClusterTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+}
+
+func clusterTestConfigs(nodeID, address string, protoFdMax int64, devices ...string) Conf {
+	namespace := Conf{
+		"replication-factor": int64(2),
+		"rack-id":            int64(0),
+		"storage-engine":     "device",
+	}
+
+	for i, d := range devices {
+		namespace["storage-engine.device["+string(rune('0'+i))+"]"] = d
+	}
+
+	return Conf{
+		"service": Conf{
+			"cluster-name": "prod",
+			"node-id":      nodeID,
+			"proto-fd-max": protoFdMax,
+		},
+		"network": Conf{
+			"service.port":                     int64(3000),
+			"service.access-address":           address,
+			"heartbeat.mode":                   "mesh",
+			"heartbeat.port":                   int64(3002),
+			"heartbeat.interval":               int64(150),
+			"fabric.port":                      int64(3001),
+			"service.tls-port":                 int64(0),
+			"heartbeat.mesh-seed-address-port": "10.0.0.1:3002",
+		},
+		"namespaces": Conf{
+			"test": namespace,
+			"bar": Conf{
+				"replication-factor":       int64(2),
+				"storage-engine":           "memory",
+				"storage-engine.data-size": int64(4294967296),
+			},
+		},
+	}
+}
+
+func (s *GenerateClusterTestSuite) mockGetter(configs Conf) ConfGetter {
+	getter := NewMockConfGetter(s.ctrl)
+	getter.EXPECT().AllConfigs().Return(configs, nil)
+	getter.EXPECT().GetAsInfo("metadata").Return(Conf{"metadata": Conf{"asd_build": "7.0.0.1"}}, nil)
+
+	return getter
+}
+
+func (s *GenerateClusterTestSuite) TestGenerateClusterConf() {
+	getters := map[string]ConfGetter{
+		"A1": s.mockGetter(clusterTestConfigs("A1", "10.0.0.1", 15000, "/dev/xvdb")),
+		"B1": s.mockGetter(clusterTestConfigs("B1", "10.0.0.2", 15000, "/dev/xvdc")),
+		"C1": s.mockGetter(clusterTestConfigs("C1", "10.0.0.3", 20000, "/dev/xvdb")),
+	}
+
+	res, err := GenerateClusterConf(logr.Discard(), getters, false)
+	s.Require().NoError(err)
+
+	s.Assert().Equal(map[string]string{"A1": "7.0.0.1", "B1": "7.0.0.1", "C1": "7.0.0.1"}, res.Versions)
+
+	base, err := flattenConf(logr.Discard(), res.Base, sep)
+	s.Require().NoError(err)
+	s.Assert().Equal("prod", base["service.cluster-name"])
+	s.Assert().EqualValues(2, base["namespaces.{test}.replication-factor"])
+	s.Assert().EqualValues(4294967296, base["namespaces.{bar}.storage-engine.data-size"])
+	s.Assert().NotContains(base, "service.proto-fd-max")
+	s.Assert().NotContains(base, "service.node-id")
+	s.Assert().NotContains(base, "namespaces.{test}.storage-engine.devices")
+
+	s.Assert().Equal(Conf{
+		"service.node-id":                          "A1",
+		"service.proto-fd-max":                     int64(15000),
+		"network.service.access-addresses":         []string{"10.0.0.1"},
+		"namespaces.{test}.storage-engine.devices": []string{"/dev/xvdb"},
+	}, res.NodeDeltas["A1"])
+	s.Assert().Len(res.NodeDeltas["B1"], 4)
+
+	s.Require().Len(res.Drift, 1)
+	s.Assert().Equal("service.proto-fd-max", res.Drift[0].Key)
+	s.Assert().Equal([]string{"A1", "B1", "C1"}, res.Drift[0].Nodes())
+	s.Assert().Equal(int64(20000), res.Drift[0].Values["C1"])
+}
+
+func (s *GenerateClusterTestSuite) TestGenerateClusterConfSingleNode() {
+	getters := map[string]ConfGetter{
+		"A1": s.mockGetter(clusterTestConfigs("A1", "10.0.0.1", 15000, "/dev/xvdb")),
+	}
+
+	res, err := GenerateClusterConf(logr.Discard(), getters, false)
+	s.Require().NoError(err)
+	s.Assert().Empty(res.NodeDeltas["A1"])
+	s.Assert().Empty(res.Drift)
+
+	_, err = GenerateClusterConf(logr.Discard(), nil, false)
+	s.Assert().Error(err)
+}
+
+func TestGenerateClusterTestSuite(t *testing.T) {
+	suite.Run(t, new(GenerateClusterTestSuite))
+}
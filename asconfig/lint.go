@@ -0,0 +1,436 @@
+package asconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sets "github.com/deckarep/golang-set/v2"
+	"github.com/go-logr/logr"
+
+	lib "github.com/aerospike/aerospike-management-lib"
+)
+
+type LintSeverity string
+
+// Enum values for LintSeverity, in the increasing order of severity.
+const (
+	// LintInfo is a suggestion.
+	LintInfo LintSeverity = "info"
+	// LintWarning is likely an operational mistake.
+	LintWarning LintSeverity = "warning"
+	// LintError is an operational mistake which puts the data or the
+	// availability of the cluster at risk.
+	LintError LintSeverity = "error"
+)
+
+var lintSeverityRank = map[LintSeverity]int{
+	LintInfo:    0,
+	LintWarning: 1,
+	LintError:   2,
+}
+
+// IDs of the default lint rules.
+const (
+	LintReplicationFactor     = "replication-factor-above-cluster-size"
+	LintStrongConsistencyRack = "strong-consistency-without-rack-id"
+	LintProtoFdMax            = "proto-fd-max-too-low"
+	LintMeshSeeds             = "mesh-too-few-seeds"
+	LintUndefinedTLSName      = "undefined-tls-name"
+	LintStopWritesSysMemory   = "missing-stop-writes-sys-memory-pct"
+)
+
+// lintMinProtoFdMax is the lowest proto-fd-max not reported by the default
+// rules, the default value of the server.
+const lintMinProtoFdMax = 15000
+
+// ClusterFacts are the facts about the cluster of a config used by the lint
+// rules. The zero value of a field means unknown, the rules needing it are
+// skipped.
+type ClusterFacts struct {
+	// Version is the server version of the nodes, eg. "7.0.0".
+	Version string
+	// NodeCount is the number of nodes of the cluster.
+	NodeCount int
+}
+
+// LintInput is the input of a LintRule.
+type LintInput struct {
+	Config *AsConfig
+	// FlatConf is the flat config of Config, eg. "namespaces.{test}.rack-id".
+	FlatConf Conf
+	Facts    ClusterFacts
+}
+
+// LintFinding is an operational mistake found by a LintRule.
+type LintFinding struct {
+	// Position is the position of Key in the aerospike.conf the config was
+	// read from, nil if the config was not read from a conf file.
+	Position *Position
+	RuleID   string
+	Severity LintSeverity
+	// Key is the flat key the finding is about. A missing key is reported
+	// on the key it is expected at.
+	Key     string
+	Message string
+}
+
+// Describe returns the finding as "severity [rule] key: message", prefixed
+// by its position if known.
+func (f *LintFinding) Describe() string {
+	msg := fmt.Sprintf("%s [%s] %s: %s", f.Severity, f.RuleID, f.Key, f.Message)
+	if f.Position == nil {
+		return msg
+	}
+
+	return f.Position.Describe(msg)
+}
+
+// LintRule checks a config for an operational mistake.
+type LintRule interface {
+	// ID identifies the rule, eg. to suppress it.
+	ID() string
+	// Severity is the severity of the findings of the rule.
+	Severity() LintSeverity
+	// Check returns the findings of the rule. Only Key and Message of the
+	// findings need to be set, the Linter sets the rest.
+	Check(in *LintInput) []*LintFinding
+}
+
+// funcRule is the LintRule returned by NewLintRule.
+type funcRule struct {
+	check    func(in *LintInput) []*LintFinding
+	id       string
+	severity LintSeverity
+}
+
+// NewLintRule returns a LintRule running check.
+func NewLintRule(id string, severity LintSeverity, check func(in *LintInput) []*LintFinding) LintRule {
+	return &funcRule{id: id, severity: severity, check: check}
+}
+
+func (r *funcRule) ID() string {
+	return r.id
+}
+
+func (r *funcRule) Severity() LintSeverity {
+	return r.severity
+}
+
+func (r *funcRule) Check(in *LintInput) []*LintFinding {
+	return r.check(in)
+}
+
+// LintReport is the result of Linter.Lint.
+type LintReport struct {
+	// Findings are sorted by key then rule id.
+	Findings []*LintFinding
+	// Suppressed are the findings matching a suppression, in the same order.
+	Suppressed []*LintFinding
+}
+
+// Severity returns the highest severity of the findings, an empty severity
+// if there is no finding.
+func (r *LintReport) Severity() LintSeverity {
+	var severity LintSeverity
+
+	for _, f := range r.Findings {
+		if severity == "" || lintSeverityRank[f.Severity] > lintSeverityRank[severity] {
+			severity = f.Severity
+		}
+	}
+
+	return severity
+}
+
+// Linter runs lint rules over configs.
+type Linter struct {
+	// suppressions are the suppressed keys by rule id. An empty set
+	// suppresses the rule for all the keys.
+	suppressions map[string]sets.Set[string]
+	rules        []LintRule
+}
+
+// NewLinter returns a Linter running the rules, or DefaultLintRules if no
+// rule is given.
+func NewLinter(rules ...LintRule) *Linter {
+	if len(rules) == 0 {
+		rules = DefaultLintRules()
+	}
+
+	return &Linter{
+		rules:        rules,
+		suppressions: make(map[string]sets.Set[string]),
+	}
+}
+
+// DefaultLintRules returns the best practice rules of the library.
+func DefaultLintRules() []LintRule {
+	return []LintRule{
+		NewLintRule(LintReplicationFactor, LintError, lintReplicationFactor),
+		NewLintRule(LintStrongConsistencyRack, LintWarning, lintStrongConsistencyRack),
+		NewLintRule(LintProtoFdMax, LintWarning, lintProtoFdMax),
+		NewLintRule(LintMeshSeeds, LintWarning, lintMeshSeeds),
+		NewLintRule(LintUndefinedTLSName, LintError, lintUndefinedTLSName),
+		NewLintRule(LintStopWritesSysMemory, LintInfo, lintStopWritesSysMemory),
+	}
+}
+
+// Rules returns the rules of the linter.
+func (l *Linter) Rules() []LintRule {
+	return l.rules
+}
+
+// AddRule adds a rule to the linter, replacing the rule with the same id.
+func (l *Linter) AddRule(rule LintRule) {
+	for i := range l.rules {
+		if l.rules[i].ID() == rule.ID() {
+			l.rules[i] = rule
+			return
+		}
+	}
+
+	l.rules = append(l.rules, rule)
+}
+
+// Suppress suppresses the findings of the rule on the given keys, or on all
+// the keys if no key is given. A key is a flat key, eg.
+// "namespaces.{test}.replication-factor", or a base key, eg. "rack-id".
+func (l *Linter) Suppress(ruleID string, keys ...string) {
+	suppressed, ok := l.suppressions[ruleID]
+	if !ok || len(keys) == 0 {
+		suppressed = sets.NewSet[string]()
+		l.suppressions[ruleID] = suppressed
+	}
+
+	// The rule is already suppressed for all the keys.
+	if ok && suppressed.Cardinality() == 0 {
+		return
+	}
+
+	suppressed.Append(keys...)
+}
+
+func (l *Linter) isSuppressed(f *LintFinding) bool {
+	suppressed, ok := l.suppressions[f.RuleID]
+	if !ok {
+		return false
+	}
+
+	return suppressed.Cardinality() == 0 || suppressed.Contains(f.Key) || suppressed.Contains(BaseKey(f.Key))
+}
+
+// Lint runs the rules of the linter over the config.
+func (l *Linter) Lint(log logr.Logger, cfg *AsConfig, facts ClusterFacts) *LintReport {
+	in := &LintInput{
+		Config:   cfg,
+		FlatConf: *cfg.GetFlatMap(),
+		Facts:    facts,
+	}
+
+	report := &LintReport{
+		Findings:   make([]*LintFinding, 0),
+		Suppressed: make([]*LintFinding, 0),
+	}
+
+	for _, rule := range l.rules {
+		for _, f := range rule.Check(in) {
+			f.RuleID = rule.ID()
+
+			if f.Severity == "" {
+				f.Severity = rule.Severity()
+			}
+
+			if pos, ok := cfg.Position(f.Key); ok {
+				f.Position = &pos
+			}
+
+			log.V(1).Info("Lint finding", "rule", f.RuleID, "key", f.Key, "message", f.Message)
+
+			if l.isSuppressed(f) {
+				report.Suppressed = append(report.Suppressed, f)
+			} else {
+				report.Findings = append(report.Findings, f)
+			}
+		}
+	}
+
+	sortLintFindings(report.Findings)
+	sortLintFindings(report.Suppressed)
+
+	return report
+}
+
+// Lint runs the DefaultLintRules over the config.
+func Lint(log logr.Logger, cfg *AsConfig, facts ClusterFacts) *LintReport {
+	return NewLinter().Lint(log, cfg, facts)
+}
+
+func sortLintFindings(findings []*LintFinding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Key != findings[j].Key {
+			return findings[i].Key < findings[j].Key
+		}
+
+		return findings[i].RuleID < findings[j].RuleID
+	})
+}
+
+// namedSections returns the sorted names of the named sections of the
+// context in the flat config, eg. the namespaces of "namespaces".
+func namedSections(flatConf Conf, context string) []string {
+	names := make([]string, 0)
+	prefix := context + sep + "{"
+
+	for k, v := range flatConf {
+		if !strings.HasPrefix(k, prefix) || !strings.HasSuffix(k, "}"+sep+KeyName) {
+			continue
+		}
+
+		// Only the direct sections of the context.
+		if name, ok := v.(string); ok && k == prefix+name+"}"+sep+KeyName {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// flatNumber returns the value of the flat key as a number.
+func flatNumber(flatConf Conf, key string) (float64, bool) {
+	v, ok := flatConf[key]
+	if !ok {
+		return 0, false
+	}
+
+	return toNumber(BaseKey(key), v, false)
+}
+
+func lintReplicationFactor(in *LintInput) []*LintFinding {
+	if in.Facts.NodeCount == 0 {
+		return nil
+	}
+
+	findings := make([]*LintFinding, 0)
+
+	for _, ns := range namedSections(in.FlatConf, "namespaces") {
+		key := fmt.Sprintf("namespaces.{%s}.replication-factor", ns)
+
+		// The default replication-factor of the server.
+		rf := float64(2)
+		if n, ok := flatNumber(in.FlatConf, key); ok {
+			rf = n
+		}
+
+		if int(rf) > in.Facts.NodeCount {
+			findings = append(findings, &LintFinding{
+				Key: key,
+				Message: fmt.Sprintf("replication-factor %d is greater than the cluster size %d",
+					int(rf), in.Facts.NodeCount),
+			})
+		}
+	}
+
+	return findings
+}
+
+func lintStrongConsistencyRack(in *LintInput) []*LintFinding {
+	findings := make([]*LintFinding, 0)
+
+	for _, ns := range namedSections(in.FlatConf, "namespaces") {
+		prefix := fmt.Sprintf("namespaces.{%s}.", ns)
+
+		if sc, _ := in.FlatConf[prefix+"strong-consistency"].(bool); !sc {
+			continue
+		}
+
+		if rackID, ok := flatNumber(in.FlatConf, prefix+"rack-id"); ok && rackID != 0 {
+			continue
+		}
+
+		findings = append(findings, &LintFinding{
+			Key:     prefix + "rack-id",
+			Message: "strong-consistency namespace has no rack-id, replicas may not be spread across racks",
+		})
+	}
+
+	return findings
+}
+
+func lintProtoFdMax(in *LintInput) []*LintFinding {
+	key := "service.proto-fd-max"
+
+	n, ok := flatNumber(in.FlatConf, key)
+	if !ok || n >= lintMinProtoFdMax {
+		return nil
+	}
+
+	return []*LintFinding{{
+		Key:     key,
+		Message: fmt.Sprintf("proto-fd-max %d is lower than %d, clients may fail to connect", int(n), lintMinProtoFdMax),
+	}}
+}
+
+func lintMeshSeeds(in *LintInput) []*LintFinding {
+	if mode, _ := in.FlatConf["network.heartbeat.mode"].(string); mode != "mesh" {
+		return nil
+	}
+
+	// Without any seed list, the finding is on the mode requiring them.
+	key := "network.heartbeat.mode"
+	seeds := 0
+
+	for _, k := range []string{
+		"network.heartbeat.tls-mesh-seed-address-ports", "network.heartbeat.mesh-seed-address-ports",
+	} {
+		if l, ok := in.FlatConf[k].([]string); ok {
+			key = k
+			seeds += len(l)
+		}
+	}
+
+	if seeds >= 2 {
+		return nil
+	}
+
+	return []*LintFinding{{
+		Key:     key,
+		Message: fmt.Sprintf("mesh heartbeat has %d seed, a node cannot rejoin if its seed is down", seeds),
+	}}
+}
+
+func lintUndefinedTLSName(in *LintInput) []*LintFinding {
+	findings := make([]*LintFinding, 0)
+
+	for _, vErr := range tlsNameRefsValid(in.FlatConf) {
+		findings = append(findings, &LintFinding{Key: vErr.Field, Message: vErr.Description})
+	}
+
+	return findings
+}
+
+func lintStopWritesSysMemory(in *LintInput) []*LintFinding {
+	// stop-writes-sys-memory-pct is available since 7.0.
+	if in.Facts.Version != "" {
+		if cmp, err := lib.CompareVersions(in.Facts.Version, "7.0.0"); err != nil || cmp < 0 {
+			return nil
+		}
+	}
+
+	findings := make([]*LintFinding, 0)
+
+	for _, ns := range namedSections(in.FlatConf, "namespaces") {
+		key := fmt.Sprintf("namespaces.{%s}.stop-writes-sys-memory-pct", ns)
+
+		if _, ok := in.FlatConf[key]; !ok {
+			findings = append(findings, &LintFinding{
+				Key:     key,
+				Message: "stop-writes-sys-memory-pct is not set, writes are not stopped before the system runs out of memory",
+			})
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,259 @@
+package asconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type LintTestSuite struct {
+	suite.Suite
+}
+
+// lintTestConfig is a config of a 3 node cluster without any lint finding.
+func lintTestConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"service": map[string]interface{}{
+			"cluster-name": "prod",
+			"proto-fd-max": 15000,
+		},
+		"network": map[string]interface{}{
+			"heartbeat": map[string]interface{}{
+				"mode":                    "mesh",
+				"port":                    3002,
+				"mesh-seed-address-ports": []string{"10.0.0.2 3002", "10.0.0.3 3002"},
+				"tls-name":                "tls1",
+			},
+			"tls": []map[string]interface{}{
+				{"name": "tls1", "ca-file": "/etc/aerospike/ca.pem"},
+			},
+		},
+		"namespaces": []map[string]interface{}{
+			{
+				"name":                       "test",
+				"replication-factor":         2,
+				"rack-id":                    1,
+				"strong-consistency":         true,
+				"stop-writes-sys-memory-pct": 90,
+			},
+			{
+				"name":                       "mem",
+				"replication-factor":         3,
+				"stop-writes-sys-memory-pct": 90,
+			},
+		},
+	}
+}
+
+// lintTestAsConfig returns the lintTestConfig with the flat keys of set
+// changed and the flat keys of del removed.
+func (s *LintTestSuite) lintTestAsConfig(set Conf, del []string) *AsConfig {
+	cfg, err := NewMapAsConfig(logr.Discard(), lintTestConfig())
+	s.Require().NoError(err)
+
+	flat := *cfg.baseConf
+
+	for k, v := range set {
+		flat[k] = v
+	}
+
+	for _, k := range del {
+		s.Require().Contains(flat, k)
+		delete(flat, k)
+	}
+
+	return cfg
+}
+
+func lintFindingIDs(findings []*LintFinding) []string {
+	ids := make([]string, 0, len(findings))
+	for _, f := range findings {
+		ids = append(ids, f.RuleID+" "+f.Key)
+	}
+
+	return ids
+}
+
+func (s *LintTestSuite) TestLintDefaultRules() {
+	facts := ClusterFacts{Version: "7.0.0", NodeCount: 3}
+
+	testCases := []struct {
+		name     string
+		set      Conf
+		del      []string
+		facts    ClusterFacts
+		expected []string
+	}{
+		{
+			name:     "no finding",
+			facts:    facts,
+			expected: []string{},
+		},
+		{
+			name:     "replication-factor above cluster size",
+			facts:    ClusterFacts{Version: "7.0.0", NodeCount: 2},
+			expected: []string{LintReplicationFactor + " namespaces.{mem}.replication-factor"},
+		},
+		{
+			name:     "replication-factor with unknown cluster size",
+			set:      Conf{"namespaces.{mem}.replication-factor": 5},
+			facts:    ClusterFacts{Version: "7.0.0"},
+			expected: []string{},
+		},
+		{
+			name:     "strong-consistency without rack-id",
+			del:      []string{"namespaces.{test}.rack-id"},
+			facts:    facts,
+			expected: []string{LintStrongConsistencyRack + " namespaces.{test}.rack-id"},
+		},
+		{
+			name:     "proto-fd-max too low",
+			set:      Conf{"service.proto-fd-max": 1000},
+			facts:    facts,
+			expected: []string{LintProtoFdMax + " service.proto-fd-max"},
+		},
+		{
+			name:     "single mesh seed",
+			set:      Conf{"network.heartbeat.mesh-seed-address-ports": []string{"10.0.0.2 3002"}},
+			facts:    facts,
+			expected: []string{LintMeshSeeds + " network.heartbeat.mesh-seed-address-ports"},
+		},
+		{
+			name:     "single tls mesh seed",
+			set:      Conf{"network.heartbeat.tls-mesh-seed-address-ports": []string{"10.0.0.2 3012"}},
+			del:      []string{"network.heartbeat.mesh-seed-address-ports"},
+			facts:    facts,
+			expected: []string{LintMeshSeeds + " network.heartbeat.tls-mesh-seed-address-ports"},
+		},
+		{
+			name:     "no mesh seed",
+			del:      []string{"network.heartbeat.mesh-seed-address-ports"},
+			facts:    facts,
+			expected: []string{LintMeshSeeds + " network.heartbeat.mode"},
+		},
+		{
+			name:     "undefined tls-name",
+			set:      Conf{"network.heartbeat.tls-name": "tls2"},
+			facts:    facts,
+			expected: []string{LintUndefinedTLSName + " network.heartbeat.tls-name"},
+		},
+		{
+			name:     "missing stop-writes-sys-memory-pct",
+			del:      []string{"namespaces.{mem}.stop-writes-sys-memory-pct"},
+			facts:    facts,
+			expected: []string{LintStopWritesSysMemory + " namespaces.{mem}.stop-writes-sys-memory-pct"},
+		},
+		{
+			name:     "missing stop-writes-sys-memory-pct before 7.0",
+			del:      []string{"namespaces.{mem}.stop-writes-sys-memory-pct"},
+			facts:    ClusterFacts{Version: "6.4.0", NodeCount: 3},
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			report := Lint(logr.Discard(), s.lintTestAsConfig(tc.set, tc.del), tc.facts)
+			s.Assert().Equal(tc.expected, lintFindingIDs(report.Findings))
+			s.Assert().Empty(report.Suppressed)
+		})
+	}
+}
+
+func (s *LintTestSuite) TestLintSeverity() {
+	cfg := s.lintTestAsConfig(Conf{"service.proto-fd-max": 1000}, nil)
+	s.Assert().Equal(LintWarning, Lint(logr.Discard(), cfg, ClusterFacts{}).Severity())
+
+	cfg = s.lintTestAsConfig(Conf{"service.proto-fd-max": 1000, "network.heartbeat.tls-name": "tls2"}, nil)
+	s.Assert().Equal(LintError, Lint(logr.Discard(), cfg, ClusterFacts{}).Severity())
+
+	s.Assert().Equal(LintSeverity(""), Lint(logr.Discard(), s.lintTestAsConfig(nil, nil), ClusterFacts{}).Severity())
+}
+
+func (s *LintTestSuite) TestLintSuppress() {
+	cfg := s.lintTestAsConfig(Conf{
+		"service.proto-fd-max":       1000,
+		"network.service.tls-name":   "tls2",
+		"network.heartbeat.tls-name": "tls2",
+	}, []string{
+		"namespaces.{test}.rack-id",
+		"network.heartbeat.mesh-seed-address-ports",
+	})
+
+	linter := NewLinter()
+	linter.Suppress(LintProtoFdMax)
+	linter.Suppress(LintStrongConsistencyRack, "rack-id")
+	linter.Suppress(LintUndefinedTLSName, "network.heartbeat.tls-name")
+
+	report := linter.Lint(logr.Discard(), cfg, ClusterFacts{})
+
+	s.Assert().Equal([]string{
+		LintMeshSeeds + " network.heartbeat.mode",
+		LintUndefinedTLSName + " network.service.tls-name",
+	}, lintFindingIDs(report.Findings))
+	s.Assert().Equal([]string{
+		LintStrongConsistencyRack + " namespaces.{test}.rack-id",
+		LintUndefinedTLSName + " network.heartbeat.tls-name",
+		LintProtoFdMax + " service.proto-fd-max",
+	}, lintFindingIDs(report.Suppressed))
+}
+
+func (s *LintTestSuite) TestLintCustomRule() {
+	cfg := s.lintTestAsConfig(nil, []string{"service.cluster-name"})
+
+	linter := NewLinter(NewLintRule("cluster-name", LintWarning, func(in *LintInput) []*LintFinding {
+		if _, ok := in.FlatConf["service.cluster-name"]; ok {
+			return nil
+		}
+
+		return []*LintFinding{{Key: "service.cluster-name", Message: "cluster-name is not set"}}
+	}))
+
+	report := linter.Lint(logr.Discard(), cfg, ClusterFacts{})
+	s.Require().Len(report.Findings, 1)
+	s.Assert().Equal("cluster-name", report.Findings[0].RuleID)
+	s.Assert().Equal(LintWarning, report.Findings[0].Severity)
+	s.Assert().Nil(report.Findings[0].Position)
+	s.Assert().Equal("warning [cluster-name] service.cluster-name: cluster-name is not set",
+		report.Findings[0].Describe())
+
+	linter.AddRule(NewLintRule("cluster-name", LintInfo, func(*LintInput) []*LintFinding { return nil }))
+	s.Assert().Len(linter.Rules(), 1)
+	s.Assert().Empty(linter.Lint(logr.Discard(), cfg, ClusterFacts{}).Findings)
+}
+
+func (s *LintTestSuite) TestLintPosition() {
+	conf := `service {
+	proto-fd-max 1000
+}
+network {
+	service {
+		port 3000
+	}
+	heartbeat {
+		mode mesh
+		port 3002
+	}
+	fabric {
+		port 3001
+	}
+}
+`
+
+	cfg, err := FromConfFile(logr.Discard(), strings.NewReader(conf))
+	s.Require().NoError(err)
+
+	report := Lint(logr.Discard(), cfg, ClusterFacts{})
+	s.Require().Len(report.Findings, 2)
+	s.Assert().Equal(LintMeshSeeds, report.Findings[0].RuleID)
+	s.Require().NotNil(report.Findings[0].Position)
+	s.Assert().Equal(9, report.Findings[0].Position.Line)
+	s.Require().NotNil(report.Findings[1].Position)
+	s.Assert().Equal(2, report.Findings[1].Position.Line)
+}
+
+func TestLintTestSuite(t *testing.T) {
+	suite.Run(t, new(LintTestSuite))
+}
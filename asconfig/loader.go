@@ -2,10 +2,13 @@ package asconfig
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 
+	"github.com/BurntSushi/toml"
 	"github.com/go-logr/logr"
 	"gopkg.in/yaml.v3"
 
@@ -15,17 +18,28 @@ import (
 
 var ErrInvalidFormat = fmt.Errorf("invalid config format")
 
-func NewASConfigFromBytes(log logr.Logger, src []byte, srcFmt Format) (*AsConfig, error) {
+// NewASConfigFromBytes loads the config in src of the format srcFmt. The
+// options add stages to the loading, eg. WithResolver.
+func NewASConfigFromBytes(log logr.Logger, src []byte, srcFmt Format, opts ...LoadOption) (*AsConfig, error) {
 	var (
 		err error
 		cfg *AsConfig
 	)
 
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	switch srcFmt {
 	case YAML:
 		cfg, err = loadYAML(log, src)
 	case AeroConfig:
 		cfg, err = loadAsConf(log, src)
+	case JSON:
+		cfg, err = loadJSON(log, src)
+	case TOML:
+		cfg, err = loadTOML(log, src)
 	case Invalid:
 		return nil, fmt.Errorf("%w %s", ErrInvalidFormat, srcFmt)
 	default:
@@ -36,6 +50,12 @@ func NewASConfigFromBytes(log logr.Logger, src []byte, srcFmt Format) (*AsConfig
 		return nil, err
 	}
 
+	if o.resolver != nil {
+		if err = o.resolver.expandConf(cfg); err != nil {
+			return nil, err
+		}
+	}
+
 	// recreate the management lib config
 	// with a sorted config map so that output
 	// is always in the same order
@@ -47,12 +67,25 @@ func NewASConfigFromBytes(log logr.Logger, src []byte, srcFmt Format) (*AsConfig
 		return nil, fmt.Errorf("failed to sort config map: %w", err)
 	}
 
+	positions := cfg.positions
+
 	cfg, err = NewMapAsConfig(
 		log,
 		*cmap,
 	)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.positions = positions
+
+	if o.resolver != nil {
+		if _, err := o.resolver.resolveConf(cfg, o.resolver.ResolvePrefixed); err != nil {
+			return nil, err
+		}
+	}
 
-	return cfg, err
+	return cfg, nil
 }
 
 func loadYAML(log logr.Logger, src []byte) (*AsConfig, error) {
@@ -74,6 +107,155 @@ func loadYAML(log logr.Logger, src []byte) (*AsConfig, error) {
 	return c, nil
 }
 
+func loadJSON(log logr.Logger, src []byte) (*AsConfig, error) {
+	var data map[string]any
+
+	// numbers are decoded as json.Number so that large integers are not
+	// rounded through float64
+	d := json.NewDecoder(bytes.NewReader(src))
+	d.UseNumber()
+
+	if err := d.Decode(&data); err != nil {
+		return nil, err
+	}
+
+	c, err := NewMapAsConfig(
+		log,
+		jsonNumbersToValues(data).(map[string]any),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize asconfig from json: %w", err)
+	}
+
+	return c, nil
+}
+
+// jsonNumbersToValues replaces the json.Number values in v by int64, uint64
+// or float64 values.
+func jsonNumbersToValues(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k := range v {
+			v[k] = jsonNumbersToValues(v[k])
+		}
+
+		return v
+
+	case []any:
+		for i := range v {
+			v[i] = jsonNumbersToValues(v[i])
+		}
+
+		return v
+
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+
+		var u uint64
+		if _, err := fmt.Sscan(v.String(), &u); err == nil {
+			return u
+		}
+
+		f, _ := v.Float64()
+
+		return f
+
+	default:
+		return v
+	}
+}
+
+func loadTOML(log logr.Logger, src []byte) (*AsConfig, error) {
+	var data map[string]any
+
+	if err := toml.Unmarshal(src, &data); err != nil {
+		return nil, err
+	}
+
+	c, err := NewMapAsConfig(
+		log,
+		data,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize asconfig from toml: %w", err)
+	}
+
+	return c, nil
+}
+
+// Marshal returns the config in the format. Lists of sections are sorted as
+// by NewASConfigFromBytes, so that the output of a config loaded with
+// NewASConfigFromBytes loads back to the same config. TOML integers are
+// int64, configs with larger values can not be written as TOML.
+func (cfg *AsConfig) Marshal(format Format) ([]byte, error) {
+	if format == AeroConfig {
+		return []byte(cfg.ToConfFile()), nil
+	}
+
+	cmap := *cfg.ToMap()
+
+	if err := mutateMap(cmap, []mapping{
+		sortLists,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to sort config map: %w", err)
+	}
+
+	switch format { //nolint:exhaustive // AeroConfig is written by ToConfFile above
+	case YAML:
+		return marshalYAML(cmap)
+
+	case JSON:
+		return json.MarshalIndent(cmap, "", "  ")
+
+	case TOML:
+		if key := findTOMLOverflow(cmap, ""); key != "" {
+			return nil, fmt.Errorf("%w: %s is out of the int64 range of toml", ErrInvalidFormat, key)
+		}
+
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(cmap); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+
+	case Invalid:
+		return nil, fmt.Errorf("%w %s", ErrInvalidFormat, format)
+
+	default:
+		return nil, fmt.Errorf("%w %s", ErrInvalidFormat, format)
+	}
+}
+
+// findTOMLOverflow returns the key of the first uint64 value of v which toml
+// integers, int64, can not hold. It returns "" if there is none.
+func findTOMLOverflow(v any, key string) string {
+	switch v := v.(type) {
+	case Conf:
+		for _, k := range sortKeys(v) {
+			if res := findTOMLOverflow(v[k], key+sep+k); res != "" {
+				return res
+			}
+		}
+
+	case []Conf:
+		for i := range v {
+			if res := findTOMLOverflow(v[i], fmt.Sprintf("%s%s%d", key, sep, i)); res != "" {
+				return res
+			}
+		}
+
+	case uint64:
+		if v > math.MaxInt64 {
+			return strings.TrimPrefix(key, sep)
+		}
+	}
+
+	return ""
+}
+
 func loadAsConf(log logr.Logger, src []byte) (*AsConfig, error) {
 	reader := bytes.NewReader(src)
 
@@ -103,6 +285,8 @@ func loadAsConf(log logr.Logger, src []byte) (*AsConfig, error) {
 
 	cmap[info.ConfigLoggingContext] = lib.DeepCopy(logging)
 
+	positions := c.positions
+
 	c, err = NewMapAsConfig(
 		log,
 		cmap,
@@ -111,6 +295,8 @@ func loadAsConf(log logr.Logger, src []byte) (*AsConfig, error) {
 		return nil, err
 	}
 
+	c.positions = positions
+
 	return c, nil
 }
 
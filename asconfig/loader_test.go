@@ -0,0 +1,200 @@
+package asconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type LoaderTestSuite struct {
+	suite.Suite
+}
+
+const loaderTestConf = `
+service {
+	cluster-name test
+	feature-key-file /etc/aerospike/features.conf
+	proto-fd-max 15000
+}
+
+logging {
+	console {
+		context any info
+	}
+}
+
+network {
+	service {
+		address any
+		port 3000
+	}
+
+	heartbeat {
+		mode mesh
+		port 3002
+		mesh-seed-address-port 10.0.0.1 3002
+		mesh-seed-address-port 10.0.0.2 3002
+	}
+
+	fabric {
+		port 3001
+	}
+}
+
+namespace bar {
+	replication-factor 2
+	storage-engine memory {
+		data-size 4G
+	}
+}
+
+namespace test {
+	default-ttl 30d
+	storage-engine device {
+		device /dev/xvdb
+		device /dev/xvdc
+		filesize 16G
+	}
+}
+`
+
+func (s *LoaderTestSuite) TestMarshalRoundTrip() {
+	cfg, err := NewASConfigFromBytes(logr.Discard(), []byte(loaderTestConf), AeroConfig)
+	s.Require().NoError(err)
+
+	expected, err := cfg.Marshal(JSON)
+	s.Require().NoError(err)
+
+	for _, format := range []Format{JSON, TOML, YAML, AeroConfig} {
+		out, err := cfg.Marshal(format)
+		s.Require().NoError(err, format)
+
+		loaded, err := NewASConfigFromBytes(logr.Discard(), out, format)
+		s.Require().NoError(err, format)
+
+		again, err := loaded.Marshal(format)
+		s.Require().NoError(err, format)
+		s.Assert().Equal(string(out), string(again), format)
+
+		asJSON, err := loaded.Marshal(JSON)
+		s.Require().NoError(err, format)
+		s.Assert().JSONEq(string(expected), string(asJSON), format)
+	}
+}
+
+func (s *LoaderTestSuite) TestLoadJSON() {
+	src := `{
+		"service": {"proto-fd-max": 15000, "feature-key-files": ["/etc/aerospike/features.conf"]},
+		"namespaces": [
+			{"name": "test", "replication-factor": 2, "storage-engine": {"type": "memory", "data-size": 4294967296}}
+		]
+	}`
+
+	cfg, err := NewASConfigFromBytes(logr.Discard(), []byte(src), JSON)
+	s.Require().NoError(err)
+
+	flat := *cfg.GetFlatMap()
+	s.Assert().EqualValues(15000, flat["service.proto-fd-max"])
+	s.Assert().EqualValues(4294967296, flat["namespaces.{test}.storage-engine.data-size"])
+	s.Assert().Equal([]string{"/etc/aerospike/features.conf"}, flat["service.feature-key-files"])
+
+	_, err = NewASConfigFromBytes(logr.Discard(), []byte("{"), JSON)
+	s.Assert().Error(err)
+}
+
+func (s *LoaderTestSuite) TestLoadTOML() {
+	src := `
+[service]
+proto-fd-max = 15000
+
+[[namespaces]]
+name = "test"
+replication-factor = 2
+
+[namespaces.storage-engine]
+type = "memory"
+data-size = 4294967296
+`
+
+	cfg, err := NewASConfigFromBytes(logr.Discard(), []byte(src), TOML)
+	s.Require().NoError(err)
+
+	flat := *cfg.GetFlatMap()
+	s.Assert().EqualValues(15000, flat["service.proto-fd-max"])
+	s.Assert().EqualValues(2, flat["namespaces.{test}.replication-factor"])
+	s.Assert().Equal("memory", flat["namespaces.{test}.storage-engine.type"])
+
+	_, err = NewASConfigFromBytes(logr.Discard(), []byte("[service"), TOML)
+	s.Assert().Error(err)
+}
+
+func (s *LoaderTestSuite) TestMarshalSortsLists() {
+	cfg, err := NewMapAsConfig(logr.Discard(), map[string]interface{}{
+		"namespaces": []Conf{{"name": "a"}, {"name": "b"}},
+	})
+	s.Require().NoError(err)
+
+	out, err := cfg.Marshal(JSON)
+	s.Require().NoError(err)
+
+	// same order as sortLists
+	s.Assert().Less(strings.Index(string(out), `"b"`), strings.Index(string(out), `"a"`))
+
+	huge, err := NewMapAsConfig(logr.Discard(), map[string]interface{}{
+		"namespaces": []Conf{{"name": "a", "storage-engine": Conf{"type": "device", "filesize": uint64(1) << 63}}},
+	})
+	s.Require().NoError(err)
+
+	_, err = huge.Marshal(TOML)
+	s.Assert().ErrorIs(err, ErrInvalidFormat)
+
+	_, err = cfg.Marshal(Invalid)
+	s.Assert().ErrorIs(err, ErrInvalidFormat)
+}
+
+func (s *LoaderTestSuite) TestToYAML() {
+	cfg, err := FromConfFile(logr.Discard(), strings.NewReader(`
+namespace test {
+	storage-engine device {
+		filesize 16G
+		device /dev/xvdb
+	}
+	replication-factor 2
+}
+
+service {
+	proto-fd-max 15000
+	feature-key-file /etc/aerospike/features.conf
+}
+`))
+	s.Require().NoError(err)
+
+	out, err := cfg.ToYAML()
+	s.Require().NoError(err)
+	s.Assert().Equal(`namespaces:
+    - name: test
+      replication-factor: 2
+      storage-engine:
+        type: device
+        devices:
+            - /dev/xvdb
+        filesize: 17179869184
+service:
+    feature-key-files:
+        - /etc/aerospike/features.conf
+    proto-fd-max: 15000
+`, string(out))
+
+	loaded, err := NewASConfigFromBytes(logr.Discard(), out, YAML)
+	s.Require().NoError(err)
+
+	again, err := loaded.ToYAML()
+	s.Require().NoError(err)
+	s.Assert().Equal(string(out), string(again))
+}
+
+func TestLoaderTestSuite(t *testing.T) {
+	suite.Run(t, new(LoaderTestSuite))
+}
@@ -0,0 +1,494 @@
+package asconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+
+	lib "github.com/aerospike/aerospike-management-lib"
+	"github.com/aerospike/aerospike-management-lib/info"
+)
+
+type MigrationAction string
+
+// Enum values for MigrationAction
+const (
+	// MigrationRewritten means the key was renamed or moved, possibly with a converted value.
+	MigrationRewritten MigrationAction = "rewritten"
+	// MigrationDropped means the key has no equivalent in the target version and was removed.
+	MigrationDropped MigrationAction = "dropped"
+	// MigrationDefaulted means a key required by the target version was added with a default value.
+	MigrationDefaulted MigrationAction = "defaulted"
+)
+
+// MigrationChange is a single key change done while migrating a config.
+type MigrationChange struct {
+	OldValue interface{}
+	NewValue interface{}
+	Action   MigrationAction
+	// Key is the flat key in the source config, NewKey the flat key in the migrated config.
+	// Key is empty for MigrationDefaulted and NewKey is empty for MigrationDropped.
+	Key    string
+	NewKey string
+	// Version is the server version which introduced the change.
+	Version string
+	Reason  string
+}
+
+// MigrationReport lists all the changes done by MigrateConfig.
+type MigrationReport struct {
+	FromVersion string
+	ToVersion   string
+	Changes     []MigrationChange
+	// ValidationErrs are the schema errors of the migrated config, if any.
+	ValidationErrs []*ValidationErr
+}
+
+func (r *MigrationReport) add(change MigrationChange) {
+	r.Changes = append(r.Changes, change)
+}
+
+// ByAction returns the changes with the given action.
+func (r *MigrationReport) ByAction(action MigrationAction) []MigrationChange {
+	res := make([]MigrationChange, 0)
+
+	for _, c := range r.Changes {
+		if c.Action == action {
+			res = append(res, c)
+		}
+	}
+
+	return res
+}
+
+// migrationRule rewrites the expanded config for the server version which
+// introduced an incompatible config change.
+type migrationRule struct {
+	apply func(m *migration, conf Conf)
+	// version is the first server version which needs the rule.
+	version string
+	name    string
+}
+
+// migration holds the state of a single MigrateConfig call.
+type migration struct {
+	log    logr.Logger
+	report *MigrationReport
+	// rule is the rule being applied.
+	rule *migrationRule
+}
+
+func (m *migration) rewritten(key, newKey string, oldValue, newValue interface{}, reason string) {
+	m.report.add(MigrationChange{
+		Action: MigrationRewritten, Key: key, NewKey: newKey, OldValue: oldValue, NewValue: newValue,
+		Version: m.rule.version, Reason: reason,
+	})
+}
+
+func (m *migration) dropped(key string, oldValue interface{}, reason string) {
+	m.report.add(MigrationChange{
+		Action: MigrationDropped, Key: key, OldValue: oldValue, Version: m.rule.version, Reason: reason,
+	})
+}
+
+func (m *migration) defaulted(newKey string, newValue interface{}, reason string) {
+	m.report.add(MigrationChange{
+		Action: MigrationDefaulted, NewKey: newKey, NewValue: newValue, Version: m.rule.version, Reason: reason,
+	})
+}
+
+// migrationRules is the list of rules, it should be in ascending order of version.
+var migrationRules = []migrationRule{
+	{version: "5.0.0", name: "xdr restructure", apply: migrateXDR50},
+	{version: "7.0.0", name: "namespace memory and storage rework", apply: migrateNamespaces70},
+}
+
+// checkMigration returns ErrConfigTransformUnsupported if cfg cannot be
+// migrated from fromVersion to toVersion: a downgrade, a toVersion without a
+// schema in the registry or a version change not allowed by IsValidUpgrade.
+// Unlike IsValidUpgrade, fromVersion needs no schema as cfg is only read.
+func (r *SchemaRegistry) checkMigration(fromVersion, toVersion string) error {
+	cmp, err := lib.CompareVersions(fromVersion, toVersion)
+	if err != nil {
+		return fmt.Errorf("%w: failed to compare versions %s and %s: %v", ErrConfigTransformUnsupported,
+			fromVersion, toVersion, err)
+	}
+
+	if cmp > 0 {
+		return fmt.Errorf("%w: downgrade from %s to %s", ErrConfigTransformUnsupported, fromVersion, toVersion)
+	}
+
+	if valid, err := r.IsSupportedVersion(toVersion); !valid || err != nil {
+		return fmt.Errorf("%w: unsupported aerospike version %s", ErrConfigTransformUnsupported, toVersion)
+	}
+
+	fromBaseVersion, err := BaseVersion(fromVersion)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfigTransformUnsupported, err)
+	}
+
+	toBaseVersion, err := BaseVersion(toVersion)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrConfigTransformUnsupported, err)
+	}
+
+	if err := checkUpgradeJump(fromBaseVersion, toBaseVersion); err != nil {
+		return fmt.Errorf("%w: %v", ErrConfigTransformUnsupported, err)
+	}
+
+	return nil
+}
+
+// MigrateConfig rewrites cfg, written for fromVersion, into a config valid for
+// toVersion. Renamed and moved keys are rewritten, removed keys are dropped and
+// new keys required in place of removed ones are defaulted, all according to
+// the rule sets of the server versions between fromVersion (exclusive) and
+// toVersion (inclusive). Finally, keys not present in the schema of toVersion
+// are dropped and the result is validated with IsValid.
+//
+// The migrated config and the report are returned even if the migrated config
+// is not valid, in which case the error is ErrConfigSchema and the validation
+// errors are in the report. A downgrade, a toVersion without a schema or a
+// version change not allowed by IsValidUpgrade is ErrConfigTransformUnsupported.
+func MigrateConfig(log logr.Logger, cfg *AsConfig, fromVersion, toVersion string) (
+	*AsConfig, *MigrationReport, error,
+) {
+	return defaultRegistry.MigrateConfig(log, cfg, fromVersion, toVersion)
+}
+
+// MigrateConfig is MigrateConfig using the schema of toVersion in the registry.
+func (r *SchemaRegistry) MigrateConfig(log logr.Logger, cfg *AsConfig, fromVersion, toVersion string) (
+	*AsConfig, *MigrationReport, error,
+) {
+	if err := r.checkMigration(fromVersion, toVersion); err != nil {
+		return nil, nil, err
+	}
+
+	report := &MigrationReport{
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+	}
+	m := &migration{
+		log:    log,
+		report: report,
+	}
+
+	conf := *cfg.ToMap()
+
+	for i := range migrationRules {
+		rule := &migrationRules[i]
+
+		r1, err := lib.CompareVersions(fromVersion, rule.version)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		r2, err := lib.CompareVersions(toVersion, rule.version)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if r1 < 0 && r2 >= 0 {
+			log.V(1).Info("Applying config migration rule", "rule", rule.name, "version", rule.version)
+
+			m.rule = rule
+			rule.apply(m, conf)
+		}
+	}
+
+	migrated, err := NewMapAsConfig(log, conf)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m.rule = &migrationRule{version: toVersion}
+	if err := m.dropUnknownKeys(r, migrated, toVersion); err != nil {
+		return nil, nil, err
+	}
+
+	valid, vErrs, err := r.IsValid(log, migrated, toVersion)
+	if !valid {
+		report.ValidationErrs = vErrs
+		return migrated, report, err
+	}
+
+	return migrated, report, nil
+}
+
+// dropUnknownKeys removes all the keys not present in the schema of the version.
+func (m *migration) dropUnknownKeys(r *SchemaRegistry, cfg *AsConfig, version string) error {
+	flatSchema, err := r.getFlatSchema(version)
+	if err != nil {
+		return err
+	}
+
+	nFlatSchema := normalizeFlatSchema(flatSchema)
+	flatConf := cfg.baseConf
+
+	for _, key := range sortKeys(*flatConf) {
+		normalizedKey := namedRe.ReplaceAllString(key, "_")
+		if isInternalField(normalizedKey) {
+			continue
+		}
+
+		if _, ok := nFlatSchema[normalizedKey+sep+schemaKeyType]; ok {
+			continue
+		}
+
+		if _, ok := nFlatSchema[normalizedKey+sep+"default"]; ok {
+			continue
+		}
+
+		m.dropped(key, (*flatConf)[key], fmt.Sprintf("not a config in version %s", version))
+		delete(*flatConf, key)
+	}
+
+	return nil
+}
+
+func flatKeyOf(tokens ...string) string {
+	return strings.Join(tokens, sep)
+}
+
+func namedToken(name interface{}) string {
+	return fmt.Sprintf("%c%v%c", SectionNameStartChar, name, SectionNameEndChar)
+}
+
+// migrateXDR50 moves the pre 5.0 xdr datacenters and the namespace level xdr
+// configs to the xdr dc sections introduced in 5.0.
+func migrateXDR50(m *migration, conf Conf) {
+	xdr, _ := conf[info.ConfigXDRContext].(Conf)
+	dcByName := make(map[string]Conf)
+	dcs := make([]Conf, 0)
+
+	if xdr != nil {
+		// datacenter is not a list section, so the expanded config keeps the
+		// datacenters as a map of named sections.
+		oldDCs, _ := xdr[PluralOf("datacenter")].(Conf)
+		for _, dcKey := range sortKeys(oldDCs) {
+			oldDC, ok := oldDCs[dcKey].(Conf)
+			if !ok {
+				continue
+			}
+
+			name, _ := oldDC[KeyName].(string)
+			oldCtx := flatKeyOf(info.ConfigXDRContext, PluralOf("datacenter"), namedToken(name))
+			newCtx := flatKeyOf(info.ConfigXDRContext, info.ConfigDCContext, namedToken(name))
+			dc := Conf{KeyName: name}
+
+			for _, k := range sortKeys(oldDC) {
+				if isInternalField(k) {
+					continue
+				}
+
+				newK := strings.TrimPrefix(k, "dc-")
+				if newK == "node-address-ports" || newK == "use-alternate-access-address" ||
+					newK == "int-ext-ipmap" || newK == "connections" {
+					dc[newK] = oldDC[k]
+					m.rewritten(flatKeyOf(oldCtx, k), flatKeyOf(newCtx, newK), oldDC[k], oldDC[k],
+						"xdr datacenter is replaced by dc")
+
+					continue
+				}
+
+				m.dropped(flatKeyOf(oldCtx, k), oldDC[k], "not supported in xdr dc")
+			}
+
+			dcByName[name] = dc
+			dcs = append(dcs, dc)
+		}
+
+		delete(xdr, PluralOf("datacenter"))
+	}
+
+	namespaces, _ := conf[info.ConfigNamespaceContext].([]Conf)
+	for _, ns := range namespaces {
+		nsName, _ := ns[KeyName].(string)
+		nsCtx := flatKeyOf(info.ConfigNamespaceContext, namedToken(nsName))
+
+		remoteDCs := make([]string, 0)
+
+		for _, k := range []string{"xdr-remote-datacenter", "xdr-remote-datacenters"} {
+			switch v := ns[k].(type) {
+			case string:
+				remoteDCs = append(remoteDCs, v)
+			case []string:
+				remoteDCs = append(remoteDCs, v...)
+			default:
+				continue
+			}
+
+			oldValue := ns[k]
+			delete(ns, k)
+
+			for _, dcName := range remoteDCs {
+				dc, ok := dcByName[dcName]
+				if !ok {
+					dc = Conf{KeyName: dcName}
+					dcByName[dcName] = dc
+					dcs = append(dcs, dc)
+				}
+
+				dcNamespaces, _ := dc[info.ConfigNamespaceContext].([]Conf)
+				dc[info.ConfigNamespaceContext] = append(dcNamespaces, Conf{KeyName: nsName})
+
+				m.rewritten(flatKeyOf(nsCtx, k),
+					flatKeyOf(info.ConfigXDRContext, info.ConfigDCContext, namedToken(dcName),
+						info.ConfigNamespaceContext, namedToken(nsName), KeyName),
+					oldValue, nsName, "namespace is shipped by adding it to the xdr dc")
+			}
+		}
+	}
+
+	if len(dcs) == 0 {
+		return
+	}
+
+	if xdr == nil {
+		xdr = Conf{}
+		conf[info.ConfigXDRContext] = xdr
+	}
+
+	existing, _ := xdr[info.ConfigDCContext].([]Conf)
+	xdr[info.ConfigDCContext] = append(existing, dcs...)
+}
+
+// namespaceRenames70 are the namespace level keys renamed in 7.0. The value
+// is the path of the new key relative to the namespace.
+var namespaceRenames70 = map[string][]string{
+	"high-water-disk-pct":                    {keyStorageEngine, "evict-used-pct"},
+	keyStorageEngine + sep + "min-avail-pct": {keyStorageEngine, "stop-writes-avail-pct"},
+	keyStorageEngine + sep + "max-used-pct":  {keyStorageEngine, "stop-writes-used-pct"},
+	"index-type" + sep + "mounts-size-limit": {"index-type", "mounts-budget"},
+	"index-type" + sep + "mounts-high-water-pct": {
+		"index-type", "evict-mounts-pct",
+	},
+	"sindex-type" + sep + "mounts-size-limit": {"sindex-type", "mounts-budget"},
+	"sindex-type" + sep + "mounts-high-water-pct": {
+		"sindex-type", "evict-mounts-pct",
+	},
+}
+
+// namespaceDrops70 are the namespace level keys removed in 7.0 without an equivalent.
+var namespaceDrops70 = map[string]string{
+	"high-water-memory-pct": "use evict-sys-memory-pct to evict based on system memory",
+	"stop-writes-pct":       "use stop-writes-sys-memory-pct to stop writes based on system memory",
+	"single-bin":            "single-bin namespaces are not supported",
+	"data-in-index":         "storing single-bin integer and float values in the primary index is not supported",
+	keyStorageEngine + sep + "data-in-memory": "in-memory namespaces with persistence use storage-engine " +
+		"memory backed by the devices or files",
+}
+
+// defaultMemorySize is the memory-size default of the 6.x servers.
+const defaultMemorySize = uint64(4 * 1024 * 1024 * 1024)
+
+// migrateNamespaces70 moves memory-size into storage-engine memory data-size,
+// converts data-in-memory namespaces to storage-engine memory and renames the
+// eviction and stop-writes thresholds.
+func migrateNamespaces70(m *migration, conf Conf) {
+	namespaces, _ := conf[info.ConfigNamespaceContext].([]Conf)
+	for _, ns := range namespaces {
+		nsName, _ := ns[KeyName].(string)
+		nsCtx := flatKeyOf(info.ConfigNamespaceContext, namedToken(nsName))
+
+		storage, ok := ns[keyStorageEngine].(Conf)
+		if !ok {
+			storage = Conf{keyType: ns[keyStorageEngine]}
+			if ns[keyStorageEngine] == nil {
+				storage[keyType] = "memory"
+			}
+
+			ns[keyStorageEngine] = storage
+		}
+
+		memorySize, hasMemorySize := ns["memory-size"]
+		delete(ns, "memory-size")
+
+		storageType, _ := storage[keyType].(string)
+		dataInMemory, _ := storage["data-in-memory"].(bool)
+
+		switch {
+		case storageType == "memory":
+			newKey := flatKeyOf(nsCtx, keyStorageEngine, "data-size")
+			if hasMemorySize {
+				storage["data-size"] = memorySize
+				m.rewritten(flatKeyOf(nsCtx, "memory-size"), newKey, memorySize, memorySize,
+					"memory-size of in-memory namespace is the data-size of storage-engine memory")
+			} else {
+				storage["data-size"] = defaultMemorySize
+				m.defaulted(newKey, defaultMemorySize,
+					"storage-engine memory requires data-size, using the memory-size default of the older version")
+			}
+
+		case dataInMemory:
+			storage[keyType] = "memory"
+			m.rewritten(flatKeyOf(nsCtx, keyStorageEngine, keyType), flatKeyOf(nsCtx, keyStorageEngine, keyType),
+				storageType, "memory", "data-in-memory namespace uses storage-engine memory backed by "+storageType)
+
+			fallthrough
+
+		default:
+			if hasMemorySize {
+				m.dropped(flatKeyOf(nsCtx, "memory-size"), memorySize,
+					"memory-size is removed, use indexes-memory-budget to limit the primary index memory")
+			}
+		}
+
+		renameNamespaceKeys70(m, ns, nsCtx)
+	}
+}
+
+func renameNamespaceKeys70(m *migration, ns Conf, nsCtx string) {
+	flatNs, err := flattenConf(m.log, ns, sep)
+	if err != nil {
+		return
+	}
+
+	keys := make([]string, 0, len(namespaceRenames70)+len(namespaceDrops70))
+	for k := range namespaceRenames70 {
+		keys = append(keys, k)
+	}
+
+	for k := range namespaceDrops70 {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v, ok := flatNs[k]
+		if !ok {
+			continue
+		}
+
+		tokens := strings.Split(k, sep)
+		parent := ns
+
+		if len(tokens) == 2 {
+			parent, _ = ns[tokens[0]].(Conf)
+		}
+
+		delete(parent, tokens[len(tokens)-1])
+
+		if reason, ok := namespaceDrops70[k]; ok {
+			m.dropped(flatKeyOf(nsCtx, k), v, reason)
+			continue
+		}
+
+		newPath := namespaceRenames70[k]
+
+		target := ns
+		if len(newPath) == 2 {
+			target, ok = ns[newPath[0]].(Conf)
+			if !ok {
+				target = Conf{}
+				ns[newPath[0]] = target
+			}
+		}
+
+		target[newPath[len(newPath)-1]] = v
+		m.rewritten(flatKeyOf(nsCtx, k), flatKeyOf(append([]string{nsCtx}, newPath...)...), v, v, "renamed")
+	}
+}
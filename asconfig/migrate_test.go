@@ -0,0 +1,180 @@
+package asconfig
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrateTestSuite struct {
+	suite.Suite
+}
+
+func (s *MigrateTestSuite) SetupSuite() {
+	InitFromMap(logr.Discard(), testSchemas)
+}
+
+func migrateTestNetwork() Conf {
+	return Conf{
+		"service":   Conf{"port": 3000},
+		"heartbeat": Conf{"mode": "mesh", "port": 3002},
+		"fabric":    Conf{"port": 3001},
+	}
+}
+
+func (s *MigrateTestSuite) TestMigrateNamespaces70() {
+	cfg, err := NewMapAsConfig(logr.Discard(), map[string]interface{}{
+		"network": migrateTestNetwork(),
+		"namespaces": []Conf{
+			{
+				"name":                  "mem",
+				"memory-size":           2147483648,
+				"high-water-memory-pct": 60,
+				"storage-engine":        Conf{"type": "memory"},
+			},
+			{
+				"name":                "ssd",
+				"memory-size":         1073741824,
+				"high-water-disk-pct": 50,
+				"storage-engine": Conf{
+					"type":          "device",
+					"devices":       []string{"/dev/xvdb"},
+					"min-avail-pct": 10,
+				},
+			},
+			{
+				"name":           "def",
+				"storage-engine": Conf{"type": "memory"},
+			},
+		},
+	})
+	s.Require().NoError(err)
+
+	migrated, report, err := MigrateConfig(logr.Discard(), cfg, "6.4.0", "7.0.0")
+	s.Require().NoError(err)
+	s.Assert().Empty(report.ValidationErrs)
+
+	flat := *migrated.GetFlatMap()
+	s.Assert().EqualValues(2147483648, flat["namespaces.{mem}.storage-engine.data-size"])
+	s.Assert().EqualValues(50, flat["namespaces.{ssd}.storage-engine.evict-used-pct"])
+	s.Assert().EqualValues(10, flat["namespaces.{ssd}.storage-engine.stop-writes-avail-pct"])
+	s.Assert().EqualValues(defaultMemorySize, flat["namespaces.{def}.storage-engine.data-size"])
+	s.Assert().NotContains(flat, "namespaces.{ssd}.memory-size")
+	s.Assert().NotContains(flat, "namespaces.{mem}.high-water-memory-pct")
+
+	actions := make(map[string]MigrationAction)
+	for _, c := range report.Changes {
+		s.Assert().Equal("7.0.0", c.Version)
+		s.Assert().NotEmpty(c.Reason)
+
+		key := c.Key
+		if key == "" {
+			key = c.NewKey
+		}
+
+		actions[key] = c.Action
+	}
+
+	s.Assert().Equal(map[string]MigrationAction{
+		"namespaces.{mem}.memory-size":                  MigrationRewritten,
+		"namespaces.{mem}.high-water-memory-pct":        MigrationDropped,
+		"namespaces.{ssd}.memory-size":                  MigrationDropped,
+		"namespaces.{ssd}.high-water-disk-pct":          MigrationRewritten,
+		"namespaces.{ssd}.storage-engine.min-avail-pct": MigrationRewritten,
+		"namespaces.{def}.storage-engine.data-size":     MigrationDefaulted,
+	}, actions)
+}
+
+func (s *MigrateTestSuite) TestMigrateXDR50() {
+	cfg, err := NewMapAsConfig(logr.Discard(), map[string]interface{}{
+		"network": migrateTestNetwork(),
+		"xdr": Conf{
+			"enable-xdr":         true,
+			"xdr-digestlog-path": "/opt/aerospike/digestlog 100G",
+			"datacenters": []Conf{
+				{
+					"name":                  "DC1",
+					"dc-node-address-ports": []string{"10.0.0.1 3000"},
+				},
+			},
+		},
+		"namespaces": []Conf{
+			{
+				"name":                   "test",
+				"memory-size":            1073741824,
+				"enable-xdr":             true,
+				"xdr-remote-datacenters": []string{"DC1"},
+				"storage-engine":         Conf{"type": "memory"},
+			},
+		},
+	})
+	s.Require().NoError(err)
+
+	migrated, report, err := MigrateConfig(logr.Discard(), cfg, "4.9.0", "7.0.0")
+	s.Require().NoError(err)
+
+	flat := *migrated.GetFlatMap()
+	s.Assert().Equal([]string{"10.0.0.1 3000"}, flat["xdr.dcs.{DC1}.node-address-ports"])
+	s.Assert().Equal("test", flat["xdr.dcs.{DC1}.namespaces.{test}.name"])
+	s.Assert().NotContains(flat, "xdr.enable-xdr")
+	s.Assert().NotContains(flat, "namespaces.{test}.enable-xdr")
+
+	dropped := make([]string, 0)
+	for _, c := range report.ByAction(MigrationDropped) {
+		dropped = append(dropped, c.Key)
+	}
+
+	s.Assert().ElementsMatch([]string{"xdr.enable-xdr", "xdr.xdr-digestlog-path", "namespaces.{test}.enable-xdr"},
+		dropped)
+	s.Assert().Len(report.ByAction(MigrationRewritten), 3)
+}
+
+func (s *MigrateTestSuite) TestMigrateInvalid() {
+	cfg, err := NewMapAsConfig(logr.Discard(), map[string]interface{}{
+		"network": migrateTestNetwork(),
+		"namespaces": []Conf{
+			{
+				"name":           "test",
+				"memory-size":    1024,
+				"storage-engine": Conf{"type": "memory"},
+			},
+		},
+	})
+	s.Require().NoError(err)
+
+	migrated, report, err := MigrateConfig(logr.Discard(), cfg, "6.4.0", "7.0.0")
+	s.Require().ErrorIs(err, ErrConfigSchema)
+	s.Assert().NotNil(migrated)
+	s.Assert().NotEmpty(report.ValidationErrs)
+
+}
+
+func (s *MigrateTestSuite) TestMigrateUnsupported() {
+	cfg, err := NewMapAsConfig(logr.Discard(), map[string]interface{}{"network": migrateTestNetwork()})
+	s.Require().NoError(err)
+
+	testCases := []struct {
+		name        string
+		fromVersion string
+		toVersion   string
+		err         string
+	}{
+		{name: "downgrade", fromVersion: "7.0.0", toVersion: "6.4.0", err: "downgrade from 7.0.0 to 6.4.0"},
+		{name: "no schema", fromVersion: "6.4.0", toVersion: "8.0.0", err: "unsupported aerospike version 8.0.0"},
+		{name: "invalid version", fromVersion: "x.4.0", toVersion: "7.0.0", err: "failed to compare versions"},
+		{name: "jump", fromVersion: "4.2.0", toVersion: "7.0.0", err: "jump required to version 4.3"},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			_, _, err := MigrateConfig(logr.Discard(), cfg, tc.fromVersion, tc.toVersion)
+			s.Assert().ErrorIs(err, ErrConfigTransformUnsupported)
+			s.Assert().ErrorContains(err, tc.err)
+		})
+	}
+}
+
+func TestMigrateTestSuite(t *testing.T) {
+	suite.Run(t, new(MigrateTestSuite))
+}
@@ -0,0 +1,376 @@
+package asconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sets "github.com/deckarep/golang-set/v2"
+	"github.com/go-logr/logr"
+)
+
+// ListMergeMode is how the list field values of a layer are merged with the
+// values of the layers below, see ConfLayer.
+type ListMergeMode string
+
+const (
+	// ListReplace replaces the values of the layers below.
+	ListReplace ListMergeMode = "replace"
+	// ListAppend adds the values which are not already in the list.
+	ListAppend ListMergeMode = "append"
+	// ListRemove removes the values from the list.
+	ListRemove ListMergeMode = "remove"
+)
+
+// SectionMergeMode is how a section of a layer is merged with the section of
+// the layers below, see ConfLayer.
+type SectionMergeMode string
+
+const (
+	// SectionMerge merges the fields of the section with the fields of the
+	// layers below.
+	SectionMerge SectionMergeMode = "merge"
+	// SectionReplace drops the fields of the section of the layers below, eg.
+	// to switch the type of a storage-engine.
+	SectionReplace SectionMergeMode = "replace"
+	// SectionDelete removes the section, eg. a namespace, from the layers
+	// below. The layer must not set the section.
+	SectionDelete SectionMergeMode = "delete"
+)
+
+// ConfLayer is a layer of a layered config, eg. the base config of a
+// cluster, the overrides of a rack or of a node.
+type ConfLayer struct {
+	// Conf is the config of the layer, in the form passed to NewMapAsConfig.
+	Conf Conf
+	// ListModes are the merge modes of the list fields, eg. "devices", by
+	// flat key, eg. "namespaces.{test}.storage-engine.devices". The list
+	// fields without mode are replaced.
+	ListModes map[string]ListMergeMode
+	// SectionModes are the merge modes of the sections by flat key, eg.
+	// "namespaces.{test}.storage-engine" or "namespaces.{bar}". The sections
+	// without mode are merged.
+	SectionModes map[string]SectionMergeMode
+	// Name identifies the layer in MergedConfig.Origins, eg. "rack-1".
+	Name string
+}
+
+// MergedConfig is the result of MergeLayers.
+type MergedConfig struct {
+	// Config is the merged config.
+	Config *AsConfig
+	// Origins are the names of the layers the value of each flat key of
+	// Config came from. List fields merged with ListAppend or ListRemove can
+	// come from several layers, the other keys come from the last layer
+	// setting them.
+	Origins map[string][]string
+	// ValidationErrs are the schema errors of the merged config.
+	ValidationErrs []*ValidationErr
+}
+
+// MergeLayers merges the layers in order, the values of a layer override the
+// values of the layers before it. The named list sections, eg. namespaces,
+// xdr dcs, logging and tls, are merged by name. The list fields, see
+// isListField, are merged as set in the layer ListModes, and the sections as
+// set in the layer SectionModes.
+//
+// The merged config is validated against the schema of version. If it is
+// not valid, the merged config is returned with its ValidationErrs and
+// ErrConfigSchema.
+func MergeLayers(log logr.Logger, version string, layers ...*ConfLayer) (*MergedConfig, error) {
+	return defaultRegistry.MergeLayers(log, version, layers...)
+}
+
+// MergeLayers merges the layers and validates the merged config against
+// the schema of version in the registry.
+func (r *SchemaRegistry) MergeLayers(log logr.Logger, version string, layers ...*ConfLayer) (*MergedConfig, error) {
+	merged := make(Conf)
+	origins := make(map[string][]string)
+
+	for _, layer := range layers {
+		if err := mergeLayer(log, merged, origins, layer); err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := NewMapAsConfig(log, expandConf(log, &merged, sep))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &MergedConfig{
+		Config:  cfg,
+		Origins: origins,
+	}
+
+	valid, vErrs, err := r.IsValid(log, cfg, version)
+	if !valid {
+		res.ValidationErrs = vErrs
+		return res, err
+	}
+
+	return res, nil
+}
+
+// mergeLayer merges the flat config of the layer into merged.
+func mergeLayer(log logr.Logger, merged Conf, origins map[string][]string, layer *ConfLayer) error {
+	cfg, err := NewMapAsConfig(log, layer.Conf)
+	if err != nil {
+		return fmt.Errorf("failed to load config layer %s: %w", layer.Name, err)
+	}
+
+	flatConf := *cfg.baseConf
+
+	for k, mode := range layer.ListModes {
+		if mode != ListReplace && mode != ListAppend && mode != ListRemove {
+			return fmt.Errorf("%w: invalid list merge mode %s for %s in layer %s", ErrConfigKeyInvalid, mode, k,
+				layer.Name)
+		}
+
+		if ok, _ := isListField(BaseKey(k)); !ok {
+			return fmt.Errorf("%w: %s is not a list field in layer %s", ErrConfigKeyInvalid, k, layer.Name)
+		}
+	}
+
+	if err := dropSections(log, merged, origins, flatConf, layer); err != nil {
+		return err
+	}
+
+	// indexes of the new list sections follow the ones of the layers below
+	nextIndex := make(map[string]int)
+
+	for k, v := range merged {
+		if strings.HasSuffix(k, sep+keyIndex) {
+			list := listSectionOf(log, k)
+			if idx, ok := v.(int); ok && idx >= nextIndex[list] {
+				nextIndex[list] = idx + 1
+			}
+		}
+	}
+
+	// the new list sections are added in their order in the layer
+	var newSections []string
+
+	for _, k := range sortKeys(flatConf) {
+		v := flatConf[k]
+
+		if strings.HasSuffix(k, sep+keyIndex) {
+			if _, ok := merged[k]; !ok {
+				newSections = append(newSections, k)
+			}
+
+			continue
+		}
+
+		values, isList := v.([]string)
+		if ok, _ := isListField(BaseKey(k)); !ok || !isList {
+			merged[k] = v
+
+			if !isInternalField(k) {
+				origins[k] = []string{layer.Name}
+			}
+
+			continue
+		}
+
+		existing, _ := merged[k].([]string)
+
+		switch layer.ListModes[k] {
+		case ListAppend:
+			present := sets.NewSet(existing...)
+			res := append([]string{}, existing...)
+
+			for _, value := range values {
+				if !present.Contains(value) {
+					res = append(res, value)
+					present.Add(value)
+				}
+			}
+
+			merged[k] = res
+			origins[k] = appendOrigin(origins[k], layer.Name)
+
+		case ListRemove:
+			removed := sets.NewSet(values...)
+			res := make([]string, 0, len(existing))
+
+			for _, value := range existing {
+				if !removed.Contains(value) {
+					res = append(res, value)
+				}
+			}
+
+			merged[k] = res
+			origins[k] = appendOrigin(origins[k], layer.Name)
+
+		case ListReplace, "":
+			merged[k] = values
+			origins[k] = []string{layer.Name}
+		}
+	}
+
+	sort.SliceStable(newSections, func(i, j int) bool {
+		a, _ := flatConf[newSections[i]].(int)
+		b, _ := flatConf[newSections[j]].(int)
+
+		return a < b
+	})
+
+	for _, k := range newSections {
+		list := listSectionOf(log, k)
+		merged[k] = nextIndex[list]
+		nextIndex[list]++
+	}
+
+	return nil
+}
+
+// dropSections removes from merged the sections replaced or deleted by the
+// layer, and renumbers the list sections whose entries were removed.
+func dropSections(log logr.Logger, merged Conf, origins map[string][]string, flatConf Conf,
+	layer *ConfLayer) error {
+	sections := make([]string, 0, len(layer.SectionModes))
+
+	for k, mode := range layer.SectionModes {
+		if mode != SectionMerge && mode != SectionReplace && mode != SectionDelete {
+			return fmt.Errorf("%w: invalid section merge mode %s for %s in layer %s", ErrConfigKeyInvalid, mode, k,
+				layer.Name)
+		}
+
+		_, inMerged := merged[k]
+		_, inLayer := flatConf[k]
+
+		if inMerged || inLayer {
+			return fmt.Errorf("%w: %s is not a section in layer %s", ErrConfigKeyInvalid, k, layer.Name)
+		}
+
+		if mode == SectionDelete && hasSectionKey(flatConf, k) {
+			return fmt.Errorf("%w: deleted section %s is set in layer %s", ErrConfigKeyInvalid, k, layer.Name)
+		}
+
+		sections = append(sections, k)
+	}
+
+	sort.Strings(sections)
+
+	renumber := false
+
+	for _, section := range sections {
+		mode := layer.SectionModes[section]
+		if mode == SectionMerge {
+			continue
+		}
+
+		indexKey := section + sep + keyIndex
+		index, hasIndex := merged[indexKey]
+
+		for k := range merged {
+			if strings.HasPrefix(k, section+sep) {
+				delete(merged, k)
+				delete(origins, k)
+			}
+		}
+
+		// a replaced list section keeps its place in the list
+		if _, ok := flatConf[indexKey]; hasIndex && ok && mode == SectionReplace {
+			merged[indexKey] = index
+			continue
+		}
+
+		renumber = renumber || hasIndex
+	}
+
+	if renumber {
+		renumberLists(log, merged)
+	}
+
+	return nil
+}
+
+// hasSectionKey returns true if conf has a flat key in the section.
+func hasSectionKey(conf Conf, section string) bool {
+	for k := range conf {
+		if strings.HasPrefix(k, section+sep) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// renumberLists numbers the list sections of merged from 0 in their order, so
+// that the lists have no gap once entries are removed.
+func renumberLists(log logr.Logger, merged Conf) {
+	lists := make(map[string][]string)
+
+	for k := range merged {
+		if strings.HasSuffix(k, sep+keyIndex) {
+			list := listSectionOf(log, k)
+			lists[list] = append(lists[list], k)
+		}
+	}
+
+	for _, keys := range lists {
+		sort.Strings(keys)
+		sort.SliceStable(keys, func(i, j int) bool {
+			a, _ := merged[keys[i]].(int)
+			b, _ := merged[keys[j]].(int)
+
+			return a < b
+		})
+
+		for i, k := range keys {
+			merged[k] = i
+		}
+	}
+}
+
+// listSectionOf returns the flat key of the list of the "<index>" key, eg.
+// "namespaces" for "namespaces.{test}.<index>".
+func listSectionOf(log logr.Logger, indexKey string) string {
+	tokens := SplitKey(log, indexKey, sep)
+	if len(tokens) < 2 {
+		return ""
+	}
+
+	return strings.Join(tokens[:len(tokens)-2], sep)
+}
+
+func appendOrigin(origins []string, name string) []string {
+	for _, o := range origins {
+		if o == name {
+			return origins
+		}
+	}
+
+	return append(origins, name)
+}
+
+// OriginOf returns the name of the last layer which set the flat key, "" if
+// no layer set it.
+func (m *MergedConfig) OriginOf(flatKey string) string {
+	origins := m.Origins[flatKey]
+	if len(origins) == 0 {
+		return ""
+	}
+
+	return origins[len(origins)-1]
+}
+
+// LayerKeys returns the sorted flat keys whose value came from the layer.
+func (m *MergedConfig) LayerKeys(name string) []string {
+	keys := make([]string, 0)
+
+	for k, origins := range m.Origins {
+		for _, o := range origins {
+			if o == name {
+				keys = append(keys, k)
+				break
+			}
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
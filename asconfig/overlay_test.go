@@ -0,0 +1,241 @@
+package asconfig
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type OverlayTestSuite struct {
+	suite.Suite
+}
+
+func (s *OverlayTestSuite) SetupSuite() {
+	InitFromMap(logr.Discard(), testSchemas)
+}
+
+func overlayTestBase() *ConfLayer {
+	network := migrateTestNetwork()
+	network["tls"] = []Conf{{"name": "tls1", "cert-file": "/etc/aerospike/cert.pem"}}
+
+	return &ConfLayer{
+		Name: "base",
+		Conf: Conf{
+			"service": Conf{"proto-fd-max": 15000},
+			"logging": []Conf{{"name": "console", "any": "info"}},
+			"network": network,
+			"namespaces": []Conf{
+				{
+					"name":               "test",
+					"replication-factor": 2,
+					"storage-engine": Conf{
+						"type":    "device",
+						"devices": []string{"/dev/xvdb", "/dev/xvdc"},
+					},
+				},
+				{
+					"name":               "bar",
+					"replication-factor": 2,
+					"storage-engine":     Conf{"type": "memory", "data-size": 4294967296},
+				},
+			},
+		},
+	}
+}
+
+func (s *OverlayTestSuite) TestMergeLayers() {
+	rack := &ConfLayer{
+		Name: "rack-1",
+		Conf: Conf{
+			"logging": []Conf{{"name": "/var/log/aerospike.log", "any": "info"}},
+			"namespaces": []Conf{
+				{
+					"name":           "test",
+					"rack-id":        1,
+					"storage-engine": Conf{"type": "device", "devices": []string{"/dev/xvdd"}},
+				},
+				{"name": "baz", "storage-engine": Conf{"type": "memory", "data-size": 1073741824}},
+			},
+		},
+		ListModes: map[string]ListMergeMode{"namespaces.{test}.storage-engine.devices": ListAppend},
+	}
+	node := &ConfLayer{
+		Name: "node-a",
+		Conf: Conf{
+			"service": Conf{"node-id": "a1"},
+			"network": Conf{
+				"service": Conf{"access-addresses": []string{"10.0.0.1"}},
+				"tls":     []Conf{{"name": "tls1", "key-file": "/etc/aerospike/key.pem"}},
+			},
+			"namespaces": []Conf{
+				{"name": "test", "storage-engine": Conf{"type": "device", "devices": []string{"/dev/xvdc"}}},
+			},
+		},
+		ListModes: map[string]ListMergeMode{"namespaces.{test}.storage-engine.devices": ListRemove},
+	}
+
+	merged, err := MergeLayers(logr.Discard(), "7.0.0", overlayTestBase(), rack, node)
+	s.Require().NoError(err)
+	s.Assert().Empty(merged.ValidationErrs)
+
+	flat := *merged.Config.GetFlatMap()
+	s.Assert().EqualValues(2, flat["namespaces.{test}.replication-factor"])
+	s.Assert().EqualValues(1, flat["namespaces.{test}.rack-id"])
+	s.Assert().Equal([]string{"/dev/xvdb", "/dev/xvdd"}, flat["namespaces.{test}.storage-engine.devices"])
+	s.Assert().Equal("a1", flat["service.node-id"])
+	s.Assert().EqualValues(15000, flat["service.proto-fd-max"])
+	s.Assert().Equal("/etc/aerospike/cert.pem", flat["network.tls.{tls1}.cert-file"])
+	s.Assert().Equal("/etc/aerospike/key.pem", flat["network.tls.{tls1}.key-file"])
+	s.Assert().Equal("info", flat["logging.{console}.any"])
+	s.Assert().Equal("info", flat["logging.{/var/log/aerospike.log}.any"])
+
+	// new list sections are after the ones of the layers below
+	s.Assert().Equal(2, flat["namespaces.{baz}.<index>"])
+	s.Assert().Equal(1, flat["logging.{/var/log/aerospike.log}.<index>"])
+
+	s.Assert().Equal("base", merged.OriginOf("namespaces.{test}.replication-factor"))
+	s.Assert().Equal("rack-1", merged.OriginOf("namespaces.{test}.rack-id"))
+	s.Assert().Equal("node-a", merged.OriginOf("network.service.access-addresses"))
+	s.Assert().Equal([]string{"base", "rack-1", "node-a"}, merged.Origins["namespaces.{test}.storage-engine.devices"])
+	s.Assert().Equal("", merged.OriginOf("service.cluster-name"))
+	s.Assert().Equal([]string{
+		"namespaces.{test}.storage-engine.devices",
+		"namespaces.{test}.storage-engine.type",
+		"network.service.access-addresses",
+		"network.tls.{tls1}.key-file",
+		"service.node-id",
+	}, merged.LayerKeys("node-a"))
+}
+
+func (s *OverlayTestSuite) TestMergeLayersReplace() {
+	rack := &ConfLayer{
+		Name: "rack-1",
+		Conf: Conf{
+			"namespaces": []Conf{
+				{"name": "test", "storage-engine": Conf{"type": "device", "devices": []string{"/dev/nvme0n1"}}},
+			},
+		},
+	}
+
+	merged, err := MergeLayers(logr.Discard(), "7.0.0", overlayTestBase(), rack)
+	s.Require().NoError(err)
+
+	flat := *merged.Config.GetFlatMap()
+	s.Assert().Equal([]string{"/dev/nvme0n1"}, flat["namespaces.{test}.storage-engine.devices"])
+	s.Assert().Equal([]string{"rack-1"}, merged.Origins["namespaces.{test}.storage-engine.devices"])
+}
+
+func (s *OverlayTestSuite) TestMergeLayersSections() {
+	base := overlayTestBase()
+	base.Conf["namespaces"] = append(base.Conf["namespaces"].([]Conf), Conf{
+		"name":           "baz",
+		"storage-engine": Conf{"type": "memory", "data-size": 1073741824},
+	})
+
+	node := &ConfLayer{
+		Name: "node-a",
+		Conf: Conf{
+			"namespaces": []Conf{
+				{"name": "test", "storage-engine": Conf{"type": "memory", "data-size": 8589934592}},
+			},
+		},
+		SectionModes: map[string]SectionMergeMode{
+			"namespaces.{test}.storage-engine": SectionReplace,
+			"namespaces.{bar}":                 SectionDelete,
+		},
+	}
+
+	merged, err := MergeLayers(logr.Discard(), "7.0.0", base, node)
+	s.Require().NoError(err)
+	s.Assert().Empty(merged.ValidationErrs)
+
+	flat := *merged.Config.GetFlatMap()
+	s.Assert().Equal("memory", flat["namespaces.{test}.storage-engine.type"])
+	s.Assert().NotContains(flat, "namespaces.{test}.storage-engine.devices")
+	s.Assert().EqualValues(2, flat["namespaces.{test}.replication-factor"])
+	s.Assert().NotContains(flat, "namespaces.{bar}.name")
+	s.Assert().NotContains(merged.Origins, "namespaces.{bar}.replication-factor")
+	s.Assert().NotContains(merged.Origins, "namespaces.{test}.storage-engine.devices")
+	s.Assert().Equal("node-a", merged.OriginOf("namespaces.{test}.storage-engine.data-size"))
+
+	// the namespaces after the deleted one move up
+	s.Assert().Equal(0, flat["namespaces.{test}.<index>"])
+	s.Assert().Equal(1, flat["namespaces.{baz}.<index>"])
+
+	// a replaced namespace keeps its place
+	merged, err = MergeLayers(logr.Discard(), "7.0.0", base, &ConfLayer{
+		Name: "node-a",
+		Conf: Conf{
+			"namespaces": []Conf{
+				{"name": "test", "storage-engine": Conf{"type": "memory", "data-size": 8589934592}},
+			},
+		},
+		SectionModes: map[string]SectionMergeMode{"namespaces.{test}": SectionReplace},
+	})
+	s.Require().NoError(err)
+
+	flat = *merged.Config.GetFlatMap()
+	s.Assert().NotContains(flat, "namespaces.{test}.replication-factor")
+	s.Assert().Equal(0, flat["namespaces.{test}.<index>"])
+	s.Assert().Equal(2, flat["namespaces.{baz}.<index>"])
+}
+
+func (s *OverlayTestSuite) TestMergeLayersInvalid() {
+	node := &ConfLayer{
+		Name: "node-a",
+		Conf: Conf{"namespaces": []Conf{{"name": "test", "replication-factor": 300}}},
+	}
+
+	merged, err := MergeLayers(logr.Discard(), "7.0.0", overlayTestBase(), node)
+	s.Assert().ErrorIs(err, ErrConfigSchema)
+	s.Require().NotNil(merged)
+	s.Assert().NotEmpty(merged.ValidationErrs)
+
+	_, err = MergeLayers(logr.Discard(), "7.0.0", overlayTestBase(), &ConfLayer{
+		Name:      "bad",
+		ListModes: map[string]ListMergeMode{"service.proto-fd-max": ListAppend},
+	})
+	s.Assert().ErrorIs(err, ErrConfigKeyInvalid)
+
+	_, err = MergeLayers(logr.Discard(), "7.0.0", overlayTestBase(), &ConfLayer{
+		Name:      "bad",
+		ListModes: map[string]ListMergeMode{"namespaces.{test}.storage-engine.devices": "merge"},
+	})
+	s.Assert().ErrorIs(err, ErrConfigKeyInvalid)
+
+	testCases := []struct {
+		name  string
+		conf  Conf
+		modes map[string]SectionMergeMode
+	}{
+		{
+			name:  "invalid section merge mode",
+			modes: map[string]SectionMergeMode{"namespaces.{test}": "append"},
+		},
+		{
+			name:  "field as section",
+			modes: map[string]SectionMergeMode{"service.proto-fd-max": SectionReplace},
+		},
+		{
+			name:  "deleted section set",
+			conf:  Conf{"namespaces": []Conf{{"name": "bar", "replication-factor": 1}}},
+			modes: map[string]SectionMergeMode{"namespaces.{bar}": SectionDelete},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			_, err := MergeLayers(logr.Discard(), "7.0.0", overlayTestBase(), &ConfLayer{
+				Name:         "bad",
+				Conf:         tc.conf,
+				SectionModes: tc.modes,
+			})
+			s.Assert().ErrorIs(err, ErrConfigKeyInvalid)
+		})
+	}
+}
+
+func TestOverlayTestSuite(t *testing.T) {
+	suite.Run(t, new(OverlayTestSuite))
+}
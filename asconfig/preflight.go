@@ -0,0 +1,251 @@
+package asconfig
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+)
+
+// Preflight error types.
+const (
+	errTypePathNotFound      = "path_not_found"
+	errTypeNotBlockDevice    = "not_block_device"
+	errTypeNotDirectory      = "not_directory"
+	errTypeNotWritable       = "not_writable"
+	errTypeNotReadable       = "not_readable"
+	errTypeInsufficientSpace = "insufficient_space"
+)
+
+// HostFS is the filesystem of the host running asd, as checked by Preflight.
+// The names are the paths of the config without the leading "/", as in
+// fs.FS, eg. "dev/xvdb" for /dev/xvdb. An fstest.MapFS can be used as the
+// fs.StatFS of a fake host.
+type HostFS interface {
+	fs.StatFS
+	// FreeSpace returns the bytes available in the filesystem of the
+	// directory name.
+	FreeSpace(name string) (uint64, error)
+}
+
+// osHostFS is the HostFS of the local host.
+type osHostFS struct {
+	fs.StatFS
+}
+
+// NewOSHostFS returns the HostFS of the local host.
+func NewOSHostFS() HostFS {
+	return &osHostFS{StatFS: os.DirFS("/").(fs.StatFS)}
+}
+
+func (h *osHostFS) FreeSpace(name string) (uint64, error) {
+	return freeSpace("/" + name)
+}
+
+// Preflight checks the paths of the config against the filesystem of the
+// host which will run asd:
+//   - the storage devices exist and are block devices.
+//   - the directories of the storage files exist and are writable. This is a
+//     permission bit heuristic only: a directory with any write bit set is
+//     writable, its owner is not compared with the user running asd.
+//   - the files of a namespace, at their filesize, fit in the free space of
+//     their directory. Existing files use the space they already have.
+//   - the work-directory and the mod-lua paths exist.
+//   - the feature-key files and the TLS cert, key and CA files are readable.
+//     The env:, env-b64: and secrets: values are resolved by the server and
+//     are not paths, they are skipped.
+//
+// The paths are found with getSystemProperty. The errors have the config key
+// in Field and the path in Value, they are sorted by field. The error is
+// returned if the free space of a directory cannot be read.
+func Preflight(log logr.Logger, cfg *AsConfig, hostFS HostFS) ([]*ValidationErr, error) {
+	conf := *cfg.GetFlatMap()
+	vErrs := make([]*ValidationErr, 0)
+
+	// bytes needed by the storage files of each directory
+	needed := make(map[string]uint64)
+	neededBy := make(map[string]string)
+
+	for _, k := range sortKeys(conf) {
+		stype, paths := getSystemProperty(log, conf, k)
+
+		for _, p := range paths {
+			if p == "" || isRefValue(k, p) {
+				continue
+			}
+
+			var vErr *ValidationErr
+
+			switch {
+			case stype == DEVICE:
+				vErr = checkDevice(hostFS, p)
+
+			case stype == FSPATH && SingularOf(BaseKey(k)) == keyFile:
+				var size uint64
+
+				size, vErr = checkStorageFile(hostFS, conf, k, p)
+				if vErr == nil && size > 0 {
+					dir := hostPath(path.Dir(p))
+					needed[dir] += size
+					neededBy[dir] = k
+				}
+
+			case stype == FSPATH && isPreflightDir(BaseKey(k)):
+				vErr = checkDir(hostFS, p, false)
+
+			case stype == FSPATH:
+				vErr = checkReadable(hostFS, p)
+			}
+
+			if vErr != nil {
+				vErr.Context, _ = splitContextBaseKey(k)
+				vErr.Field = k
+				vErr.Value = p
+				vErrs = append(vErrs, vErr)
+			}
+		}
+	}
+
+	dirs := make([]string, 0, len(needed))
+	for dir := range needed {
+		dirs = append(dirs, dir)
+	}
+
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		free, err := hostFS.FreeSpace(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get free space of /%s: %w", dir, err)
+		}
+
+		if needed[dir] > free {
+			k := neededBy[dir]
+			context, _ := splitContextBaseKey(k)
+
+			vErrs = append(vErrs, &ValidationErr{
+				ErrType: errTypeInsufficientSpace,
+				Context: context,
+				Description: fmt.Sprintf("files need %s more in /%s, only %s is free",
+					humanizeSize(needed[dir]), dir, humanizeSize(free)),
+				Field: k,
+				Value: "/" + dir,
+			})
+		}
+	}
+
+	for _, vErr := range vErrs {
+		if pos, ok := cfg.Position(vErr.Field); ok {
+			vErr.Position = &pos
+		}
+	}
+
+	sort.SliceStable(vErrs, func(i, j int) bool {
+		return vErrs[i].Field < vErrs[j].Field
+	})
+
+	return vErrs, nil
+}
+
+// hostPath returns the name of the path of the config in a HostFS.
+func hostPath(p string) string {
+	p = strings.TrimPrefix(path.Clean(p), "/")
+	if p == "" {
+		return "."
+	}
+
+	return p
+}
+
+// isPreflightDir returns true if the path of the key is a directory which
+// must exist.
+func isPreflightDir(baseKey string) bool {
+	switch baseKey {
+	case "work-directory", "system-path", "user-path", "ca-path":
+		return true
+	default:
+		return false
+	}
+}
+
+func checkDevice(hostFS HostFS, p string) *ValidationErr {
+	info, err := hostFS.Stat(hostPath(p))
+	if err != nil {
+		return &ValidationErr{ErrType: errTypePathNotFound, Description: fmt.Sprintf("device %s not found", p)}
+	}
+
+	if info.Mode()&fs.ModeDevice == 0 || info.Mode()&fs.ModeCharDevice != 0 {
+		return &ValidationErr{ErrType: errTypeNotBlockDevice, Description: fmt.Sprintf("%s is not a block device", p)}
+	}
+
+	return nil
+}
+
+// checkStorageFile checks the directory of the storage file p and returns the
+// bytes the file still needs to reach the filesize of its namespace.
+func checkStorageFile(hostFS HostFS, conf Conf, key, p string) (uint64, *ValidationErr) {
+	if vErr := checkDir(hostFS, path.Dir(p), true); vErr != nil {
+		return 0, vErr
+	}
+
+	context, _ := splitContextBaseKey(key)
+
+	filesize, ok := flatNumber(conf, context+sep+"filesize")
+	if !ok {
+		return 0, nil
+	}
+
+	var size uint64
+	if info, err := hostFS.Stat(hostPath(p)); err == nil {
+		size = uint64(max(info.Size(), 0)) //nolint:gosec // size is non-negative
+	}
+
+	if uint64(filesize) <= size {
+		return 0, nil
+	}
+
+	return uint64(filesize) - size, nil
+}
+
+// checkDir checks that the directory p exists and, if writable is set, that
+// any of its write permission bits is set.
+func checkDir(hostFS HostFS, p string, writable bool) *ValidationErr {
+	info, err := hostFS.Stat(hostPath(p))
+	if err != nil {
+		return &ValidationErr{ErrType: errTypePathNotFound, Description: fmt.Sprintf("directory %s not found", p)}
+	}
+
+	if !info.IsDir() {
+		return &ValidationErr{ErrType: errTypeNotDirectory, Description: fmt.Sprintf("%s is not a directory", p)}
+	}
+
+	if writable && info.Mode().Perm()&0o222 == 0 {
+		return &ValidationErr{ErrType: errTypeNotWritable, Description: fmt.Sprintf("directory %s is not writable", p)}
+	}
+
+	return nil
+}
+
+func checkReadable(hostFS HostFS, p string) *ValidationErr {
+	info, err := hostFS.Stat(hostPath(p))
+	if err != nil {
+		return &ValidationErr{ErrType: errTypePathNotFound, Description: fmt.Sprintf("file %s not found", p)}
+	}
+
+	if info.IsDir() || info.Mode().Perm()&0o444 == 0 {
+		return &ValidationErr{ErrType: errTypeNotReadable, Description: fmt.Sprintf("file %s is not readable", p)}
+	}
+
+	f, err := hostFS.Open(hostPath(p))
+	if err != nil {
+		return &ValidationErr{ErrType: errTypeNotReadable, Description: fmt.Sprintf("file %s is not readable", p)}
+	}
+
+	_ = f.Close()
+
+	return nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package asconfig
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// freeSpace is not supported on this platform.
+func freeSpace(string) (uint64, error) {
+	return 0, fmt.Errorf("free space is not supported on %s", runtime.GOOS)
+}
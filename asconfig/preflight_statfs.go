@@ -0,0 +1,16 @@
+//go:build linux || darwin
+
+package asconfig
+
+import "syscall"
+
+// freeSpace returns the bytes available to unprivileged users in the
+// filesystem of the directory dir.
+func freeSpace(dir string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+
+	return st.Bavail * uint64(st.Bsize), nil
+}
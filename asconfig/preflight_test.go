@@ -0,0 +1,232 @@
+package asconfig
+
+import (
+	"fmt"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+// fakeHostFS is a HostFS over an fstest.MapFS with the free space of every
+// directory.
+type fakeHostFS struct {
+	fstest.MapFS
+	free map[string]uint64
+}
+
+func (h *fakeHostFS) FreeSpace(name string) (uint64, error) {
+	free, ok := h.free[name]
+	if !ok {
+		return 0, fmt.Errorf("no filesystem for %s", name)
+	}
+
+	return free, nil
+}
+
+type PreflightTestSuite struct {
+	suite.Suite
+}
+
+// preflightTestHost is the filesystem of a host with all the paths of the
+// preflightTestConfig.
+func preflightTestHost() *fakeHostFS {
+	return &fakeHostFS{
+		MapFS: fstest.MapFS{
+			"dev/xvdb":                    {Mode: fs.ModeDevice | 0o660},
+			"dev/xvdc":                    {Mode: fs.ModeDevice | 0o660},
+			"dev/tty0":                    {Mode: fs.ModeDevice | fs.ModeCharDevice | 0o620},
+			"opt/aerospike":               {Mode: fs.ModeDir | 0o755},
+			"opt/aerospike/data":          {Mode: fs.ModeDir | 0o755},
+			"opt/aerospike/data/bar.dat":  {Data: make([]byte, 1024), Mode: 0o644},
+			"opt/aerospike/ro":            {Mode: fs.ModeDir | 0o555},
+			"etc/aerospike/features.conf": {Data: []byte("feature-key-version 2"), Mode: 0o644},
+			"etc/aerospike/tls/cert.pem":  {Data: []byte("cert"), Mode: 0o644},
+			"etc/aerospike/tls/key.pem":   {Data: []byte("key"), Mode: 0o600},
+			"etc/aerospike/tls/ca":        {Mode: fs.ModeDir | 0o755},
+		},
+		free: map[string]uint64{"opt/aerospike/data": 8 * 1024 * 1024 * 1024},
+	}
+}
+
+func preflightTestConfig() map[string]interface{} {
+	return map[string]interface{}{
+		"service": map[string]interface{}{
+			"work-directory":    "/opt/aerospike",
+			"feature-key-files": []string{"/etc/aerospike/features.conf"},
+		},
+		"network": map[string]interface{}{
+			"tls": []map[string]interface{}{
+				{
+					"name":      "tls1",
+					"cert-file": "/etc/aerospike/tls/cert.pem",
+					"key-file":  "/etc/aerospike/tls/key.pem",
+					"ca-path":   "/etc/aerospike/tls/ca",
+				},
+			},
+		},
+		"namespaces": []map[string]interface{}{
+			{
+				"name": "test",
+				"storage-engine": map[string]interface{}{
+					"type":    "device",
+					"devices": []string{"/dev/xvdb", "/dev/xvdc"},
+				},
+			},
+			{
+				"name": "bar",
+				"storage-engine": map[string]interface{}{
+					"type":     "device",
+					"files":    []string{"/opt/aerospike/data/bar.dat"},
+					"filesize": 4 * 1024 * 1024 * 1024,
+				},
+			},
+		},
+	}
+}
+
+// preflightTestAsConfig returns the preflightTestConfig with the flat keys of
+// set changed.
+func (s *PreflightTestSuite) preflightTestAsConfig(set Conf) *AsConfig {
+	cfg, err := NewMapAsConfig(logr.Discard(), preflightTestConfig())
+	s.Require().NoError(err)
+
+	for k, v := range set {
+		(*cfg.baseConf)[k] = v
+	}
+
+	return cfg
+}
+
+func (s *PreflightTestSuite) TestPreflight() {
+	testCases := []struct {
+		name     string
+		set      Conf
+		host     func(h *fakeHostFS)
+		expected []string
+		// desc is the description of the first error, if set.
+		desc string
+	}{
+		{
+			name:     "no error",
+			expected: []string{},
+		},
+		{
+			name: "devices",
+			set: Conf{
+				"namespaces.{test}.storage-engine.devices": []string{"/dev/xvdb", "/dev/xvdc:/dev/tty0", "/dev/xvdd"},
+			},
+			expected: []string{
+				"not_block_device namespaces.{test}.storage-engine.devices /dev/tty0",
+				"path_not_found namespaces.{test}.storage-engine.devices /dev/xvdd",
+			},
+		},
+		{
+			name: "read-only file directory",
+			set: Conf{
+				"namespaces.{bar}.storage-engine.files": []string{
+					"/opt/aerospike/data/bar.dat", "/opt/aerospike/ro/bar.dat",
+				},
+			},
+			expected: []string{
+				"not_writable namespaces.{bar}.storage-engine.files /opt/aerospike/ro/bar.dat",
+			},
+		},
+		{
+			name: "insufficient space",
+			set: Conf{
+				"namespaces.{bar}.storage-engine.files": []string{
+					"/opt/aerospike/data/bar.dat", "/opt/aerospike/data/bar2.dat",
+				},
+			},
+			host: func(h *fakeHostFS) {
+				h.free["opt/aerospike/data"] = 4 * 1024 * 1024 * 1024
+			},
+			expected: []string{
+				"insufficient_space namespaces.{bar}.storage-engine.files /opt/aerospike/data",
+			},
+			desc: "files need 8388607K more in /opt/aerospike/data, only 4G is free",
+		},
+		{
+			name: "unreadable key file",
+			host: func(h *fakeHostFS) {
+				h.MapFS["etc/aerospike/tls/key.pem"].Mode = 0o200
+			},
+			expected: []string{
+				"not_readable network.tls.{tls1}.key-file /etc/aerospike/tls/key.pem",
+			},
+		},
+		{
+			name: "values resolved by the server",
+			set: Conf{
+				"service.feature-key-files":    []string{"env-b64:FEATURES"},
+				"network.tls.{tls1}.cert-file": "env:CERT",
+				"network.tls.{tls1}.key-file":  "secrets:certs:key",
+				"service.work-directory":       "env:WORK_DIR",
+			},
+			expected: []string{
+				"path_not_found service.work-directory env:WORK_DIR",
+			},
+		},
+		{
+			name: "missing paths",
+			set:  Conf{"service.work-directory": "/var/lib/aerospike"},
+			host: func(h *fakeHostFS) {
+				delete(h.MapFS, "etc/aerospike/tls/key.pem")
+			},
+			expected: []string{
+				"path_not_found network.tls.{tls1}.key-file /etc/aerospike/tls/key.pem",
+				"path_not_found service.work-directory /var/lib/aerospike",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		s.Run(tc.name, func() {
+			host := preflightTestHost()
+			if tc.host != nil {
+				tc.host(host)
+			}
+
+			vErrs, err := Preflight(logr.Discard(), s.preflightTestAsConfig(tc.set), host)
+			s.Require().NoError(err)
+
+			res := make([]string, 0, len(vErrs))
+			for _, vErr := range vErrs {
+				res = append(res, fmt.Sprintf("%s %s %v", vErr.ErrType, vErr.Field, vErr.Value))
+			}
+
+			s.Assert().Equal(tc.expected, res)
+
+			if tc.desc != "" {
+				s.Assert().Equal(tc.desc, vErrs[0].Description)
+			}
+		})
+	}
+}
+
+func (s *PreflightTestSuite) TestPreflightFreeSpaceError() {
+	host := preflightTestHost()
+	host.free = nil
+
+	_, err := Preflight(logr.Discard(), s.preflightTestAsConfig(nil), host)
+	s.Assert().ErrorContains(err, "failed to get free space of /opt/aerospike/data")
+}
+
+func (s *PreflightTestSuite) TestOSHostFS() {
+	host := NewOSHostFS()
+
+	info, err := host.Stat("tmp")
+	s.Require().NoError(err)
+	s.Assert().True(info.IsDir())
+
+	free, err := host.FreeSpace("tmp")
+	s.Require().NoError(err)
+	s.Assert().Positive(free)
+}
+
+func TestPreflightTestSuite(t *testing.T) {
+	suite.Run(t, new(PreflightTestSuite))
+}
@@ -0,0 +1,396 @@
+package asconfig
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	sets "github.com/deckarep/golang-set/v2"
+)
+
+// Value prefixes resolved by the server when it reads the config.
+const (
+	envPrefix     = "env:"
+	envB64Prefix  = "env-b64:"
+	secretsPrefix = "secrets:"
+)
+
+// refFields are the fields whose values can use the env:, env-b64: and
+// secrets: prefixes.
+var refFields = sets.NewSet(
+	keyFeatureKeyFile, "ca-file", "cert-file", "key-file", "key-file-password",
+	"encryption-key-file", "encryption-old-key-file", "auth-password-file",
+	"default-password-file", "query-user-password-file",
+)
+
+// varRe matches the ${VAR} references, $${VAR} is an escaped reference.
+var varRe = regexp.MustCompile(`\$?\$\{([^}]*)\}`)
+
+var varNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SecretProvider returns the values of the secrets: references, eg. the
+// secrets of a secret manager.
+type SecretProvider interface {
+	// GetSecret returns the value of the key of the secret resource.
+	GetSecret(resource, key string) (string, error)
+}
+
+// FileSecretProvider is a SecretProvider reading the secrets from files.
+// The resource is the path of a JSON file in FS holding the keys of the
+// secret, eg. {"password": "admin123"}.
+type FileSecretProvider struct {
+	FS fs.FS
+}
+
+// NewFileSecretProvider returns a FileSecretProvider reading the secrets
+// from the files of the directory dir.
+func NewFileSecretProvider(dir string) *FileSecretProvider {
+	return &FileSecretProvider{FS: os.DirFS(dir)}
+}
+
+// GetSecret returns the value of the key in the JSON file resource.
+func (p *FileSecretProvider) GetSecret(resource, key string) (string, error) {
+	data, err := fs.ReadFile(p.FS, path.Clean(resource))
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s: %v", resource, err)
+	}
+
+	secret := make(map[string]interface{})
+	if err := json.Unmarshal(data, &secret); err != nil {
+		return "", fmt.Errorf("failed to parse secret %s: %v", resource, err)
+	}
+
+	value, ok := secret[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no key %s", resource, key)
+	}
+
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+
+	return fmt.Sprint(value), nil
+}
+
+// Resolver resolves the references in a config while it is loaded, see
+// WithResolver.
+//
+// The env:, env-b64: and secrets: values are kept in the config: they are on
+// file fields, eg. feature-key-file, which the server reads itself from the
+// environment or the secret manager. Their resolved values, eg. the content
+// of a key file, are only returned by ResolveRefs, so that they are never
+// written back to a config file.
+type Resolver struct {
+	// Lookup returns the values of the ${VAR} references, and of the env: and
+	// env-b64: values, eg. os.LookupEnv.
+	Lookup func(name string) (string, bool)
+	// Secrets returns the values of the secrets: values.
+	Secrets SecretProvider
+	// ResolvePrefixed checks while loading that the env:, env-b64: and
+	// secrets: values can be resolved. By default they are only validated.
+	ResolvePrefixed bool
+}
+
+// ResolvedRefs are the resolved values of the env:, env-b64: and secrets:
+// values of a config by flat key, one per value for list fields. Values
+// without a prefix are not in ResolvedRefs.
+type ResolvedRefs map[string][]string
+
+// LoadOption is an option of NewASConfigFromBytes.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	resolver *Resolver
+}
+
+// WithResolver adds the resolver stage to NewASConfigFromBytes. Once the
+// source is parsed, the ${VAR} references in its values and section names are
+// expanded with the resolver Lookup, see Resolver.expandConf. The env:,
+// env-b64: and secrets: values are then validated, they are only allowed on
+// the fields supporting them, eg. feature-key-file, and checked to resolve if
+// the resolver ResolvePrefixed is set. Unresolved references fail with
+// ErrConfigReference.
+func WithResolver(r *Resolver) LoadOption {
+	return func(o *loadOptions) {
+		o.resolver = r
+	}
+}
+
+// ResolveRefs returns the resolved values of the env:, env-b64: and
+// secrets: values of cfg. cfg is not modified. Unresolved references fail
+// with ErrConfigReference.
+func (r *Resolver) ResolveRefs(cfg *AsConfig) (ResolvedRefs, error) {
+	return r.resolveConf(cfg, true)
+}
+
+// expandConf replaces the ${VAR} references in the values and the section
+// names of the parsed cfg by their values. The references are expanded after
+// parsing, so that the references in comments are ignored and the values need
+// no escaping in any format. An expanded value is typed as the aerospike.conf
+// parser types the values of its key, eg. port ${PORT} is an integer.
+func (r *Resolver) expandConf(cfg *AsConfig) error {
+	conf := *cfg.baseConf
+	expanded := make(Conf, len(conf))
+
+	var errs []string
+
+	for _, k := range sortKeys(conf) {
+		// the references of a section name are reported on its name key
+		key, _ := r.expandVars(k)
+		value := conf[k]
+
+		switch v := value.(type) {
+		case string:
+			s, vErrs := r.expandVars(v)
+			if s != v {
+				value = typedValue(key, s)
+			}
+
+			errs = append(errs, refErrs(cfg, k, vErrs)...)
+
+		case []string:
+			values := make([]string, len(v))
+
+			for i := range v {
+				var vErrs []string
+
+				values[i], vErrs = r.expandVars(v[i])
+				errs = append(errs, refErrs(cfg, k, vErrs)...)
+			}
+
+			value = values
+		}
+
+		expanded[key] = value
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %s", ErrConfigReference, strings.Join(errs, "; "))
+	}
+
+	positions := make(map[string]Position, len(cfg.positions))
+
+	for k, pos := range cfg.positions {
+		key, _ := r.expandVars(k)
+		positions[key] = pos
+	}
+
+	*cfg.baseConf = expanded
+	cfg.positions = positions
+
+	return nil
+}
+
+// expandVars replaces the ${VAR} references of s by their values and
+// returns the errors of the invalid and unset references.
+func (r *Resolver) expandVars(s string) (string, []string) {
+	var errs []string
+
+	res := varRe.ReplaceAllStringFunc(s, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+
+		name := ref[2 : len(ref)-1]
+		if !varNameRe.MatchString(name) {
+			errs = append(errs, "invalid variable name in "+ref)
+			return ref
+		}
+
+		if r.Lookup != nil {
+			if value, ok := r.Lookup(name); ok {
+				return value
+			}
+		}
+
+		errs = append(errs, ref+" is not set")
+
+		return ref
+	})
+
+	return res, errs
+}
+
+// refErrs prefixes the reference errors of the flat key with the key and its
+// position in cfg, if known.
+func refErrs(cfg *AsConfig, key string, errs []string) []string {
+	if pos, ok := cfg.Position(key); ok {
+		key = pos.String() + ": " + key
+	}
+
+	res := make([]string, 0, len(errs))
+	for _, err := range errs {
+		res = append(res, key+": "+err)
+	}
+
+	return res
+}
+
+// typedValue returns the string s of the flat key typed as the aerospike.conf
+// parser types the values of the key.
+func typedValue(key, s string) interface{} {
+	baseKey := BaseKey(key)
+
+	if ok, humanizeFn := isSizeOrTime(baseKey); ok {
+		if v, err := humanizeFn(s); err == nil {
+			return v
+		}
+
+		return s
+	}
+
+	return parseValue(baseKey, s)
+}
+
+// resolveConf validates the prefixed values of cfg, and returns their
+// values if resolve is set.
+func (r *Resolver) resolveConf(cfg *AsConfig, resolve bool) (ResolvedRefs, error) {
+	conf := *cfg.baseConf
+	keys := make([]string, 0, len(conf))
+
+	for k := range conf {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	var errs []string
+
+	refs := make(ResolvedRefs)
+
+	addErr := func(key, msg string) {
+		if pos, ok := cfg.Position(key); ok {
+			key = pos.String() + ": " + key
+		}
+
+		errs = append(errs, key+": "+msg)
+	}
+
+	for _, k := range keys {
+		var values []string
+
+		switch v := conf[k].(type) {
+		case string:
+			values = []string{v}
+		case []string:
+			values = v
+		default:
+			continue
+		}
+
+		resolved := make([]string, len(values))
+		isRef := false
+
+		for i := range values {
+			value, ok, err := r.resolveValue(k, values[i], resolve)
+			if err != nil {
+				addErr(k, err.Error())
+			}
+
+			resolved[i] = value
+			isRef = isRef || ok
+		}
+
+		if resolve && isRef {
+			refs[k] = resolved
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrConfigReference, strings.Join(errs, "; "))
+	}
+
+	return refs, nil
+}
+
+// refPrefix returns the env:, env-b64: or secrets: prefix of the value, ""
+// if it has none.
+func refPrefix(value string) string {
+	for _, p := range []string{envPrefix, envB64Prefix, secretsPrefix} {
+		if strings.HasPrefix(value, p) {
+			return p
+		}
+	}
+
+	return ""
+}
+
+// isRefValue returns true if the value of the flat key is resolved by the
+// server, and is not the value itself, eg. a path.
+func isRefValue(key, value string) bool {
+	return refPrefix(value) != "" && refFields.Contains(SingularOf(BaseKey(key)))
+}
+
+// resolveValue validates the value of the flat key and returns its resolved
+// value if resolve is set, and true if the value has a prefix.
+func (r *Resolver) resolveValue(key, value string, resolve bool) (string, bool, error) {
+	prefix := refPrefix(value)
+	if prefix == "" {
+		return value, false, nil
+	}
+
+	if !refFields.Contains(SingularOf(BaseKey(key))) {
+		return value, true, fmt.Errorf("%s values are not supported for %s", strings.TrimSuffix(prefix, ":"),
+			BaseKey(key))
+	}
+
+	ref := strings.TrimPrefix(value, prefix)
+
+	switch prefix {
+	case envPrefix, envB64Prefix:
+		if !varNameRe.MatchString(ref) {
+			return value, true, fmt.Errorf("invalid environment variable name in %s", value)
+		}
+
+		if !resolve {
+			return value, true, nil
+		}
+
+		env, ok := "", false
+		if r.Lookup != nil {
+			env, ok = r.Lookup(ref)
+		}
+
+		if !ok {
+			return value, true, fmt.Errorf("environment variable %s of %s is not set", ref, value)
+		}
+
+		if prefix == envPrefix {
+			return env, true, nil
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(env)
+		if err != nil {
+			return value, true, fmt.Errorf("environment variable %s of %s is not base64: %v", ref, value, err)
+		}
+
+		return string(decoded), true, nil
+
+	default:
+		idx := strings.LastIndex(ref, ":")
+		if idx <= 0 || idx == len(ref)-1 {
+			return value, true, fmt.Errorf("%s is not of the form secrets:<resource>:<key>", value)
+		}
+
+		if !resolve {
+			return value, true, nil
+		}
+
+		if r.Secrets == nil {
+			return value, true, fmt.Errorf("no secret provider to resolve %s", value)
+		}
+
+		secret, err := r.Secrets.GetSecret(ref[:idx], ref[idx+1:])
+		if err != nil {
+			return value, true, fmt.Errorf("failed to resolve %s: %v", value, err)
+		}
+
+		return secret, true, nil
+	}
+}
@@ -3,8 +3,7 @@ package asconfig
 import (
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -16,9 +15,6 @@ import (
 	"github.com/aerospike/aerospike-management-lib/info"
 )
 
-// map of version to schema
-var schemas map[string]string
-
 var validVersionRe = regexp.MustCompile(`(\d+\.){2}\d+`)
 var defRegex = regexp.MustCompile("(.*).default$")
 var dynRegex = regexp.MustCompile("(.*).dynamic$")
@@ -26,54 +22,42 @@ var reqRegex = regexp.MustCompile(`(.*?)\.?required$`)
 
 // var storageRegex = regexp.MustCompile("(.*).storage-engine$")
 
-// Init initializes aerospike schemas.
-// Init needs to be called before using this package.
+// Init initializes aerospike schemas of the default SchemaRegistry.
+// Init needs to be called before using the package level functions.
 //
 // schemaDir is the path to directory having the aerospike config schemas.
 func Init(log logr.Logger, schemaDir string) error {
 	log.V(1).Info("Config schema dir", "dir", schemaDir)
 
-	schemas = make(map[string]string)
-
-	fileInfo, err := os.ReadDir(schemaDir)
+	schemaMap, err := readSchemaDir(schemaDir)
 	if err != nil {
 		return err
 	}
 
-	if len(fileInfo) == 0 {
-		return fmt.Errorf("no config schema file available in %s", schemaDir)
-	}
-
-	for _, file := range fileInfo {
-		if file.IsDir() {
-			// no need to check recursively
-			continue
-		}
+	defaultRegistry.load(log, schemaMap)
 
-		schema, err := os.ReadFile(filepath.Clean(filepath.Join(schemaDir, file.Name()))) //nolint:gosec // file names come from os.ReadDir, not user input
-		if err != nil {
-			return fmt.Errorf("wrong config schema file %s: %v", file.Name(), err)
-		}
-
-		schemas[versionFormat(file.Name())] = string(schema)
+	return nil
+}
 
-		log.V(1).Info("Config schema added", "version", versionFormat(file.Name()))
+// InitFromFS initializes aerospike schemas of the default SchemaRegistry from
+// the directory dir of fsys, eg. an embed.FS. Unlike Init, files without the
+// .json extension are skipped.
+func InitFromFS(log logr.Logger, fsys fs.FS, dir string) error {
+	schemaMap, err := readSchemaFS(fsys, dir, true)
+	if err != nil {
+		return fmt.Errorf("failed to read config schema dir %s: %w", dir, err)
 	}
 
+	defaultRegistry.load(log, schemaMap)
+
 	return nil
 }
 
-// InitFromMap init schema map from a map.
+// InitFromMap init schema map of the default SchemaRegistry from a map.
 // Map key format -> 4.1.0
 // Map value format -> string of json schema
 func InitFromMap(log logr.Logger, schemaMap map[string]string) {
-	schemas = make(map[string]string)
-
-	for name, schema := range schemaMap {
-		log.V(1).Info("Config schema added", "version", name)
-
-		schemas[name] = schema
-	}
+	defaultRegistry.load(log, schemaMap)
 }
 
 func versionFormat(filename string) string {
@@ -93,33 +77,7 @@ func versionFormat(filename string) string {
 
 // isSupportedVersion returns true if server version supported by ACC
 func isSupportedVersion(ver string) (bool, error) {
-	baseVersion, err := baseVersion(ver)
-	if err != nil {
-		return false, err
-	}
-
-	_, ok := schemas[baseVersion]
-	if ok {
-		return true, nil
-	}
-
-	return false, nil
-}
-
-// getSchema returns JSON schema string based on the passed in version.
-// return nil if not supported.
-func getSchema(ver string) (string, error) {
-	baseVersion, err := baseVersion(ver)
-	if err != nil {
-		return baseVersion, err
-	}
-
-	schema, ok := schemas[baseVersion]
-	if ok {
-		return schema, nil
-	}
-
-	return "", fmt.Errorf("unsupported version")
+	return defaultRegistry.IsSupportedVersion(ver)
 }
 
 // BaseVersion returns baseVersion for ver
@@ -135,12 +93,7 @@ func baseVersion(ver string) (string, error) {
 // GetDynamic return the map of values which are dynamic
 // values.
 func GetDynamic(ver string) (sets.Set[string], error) {
-	flatSchema, err := getFlatSchema(ver)
-	if err != nil {
-		return nil, err
-	}
-
-	return getDynamicSchema(flatSchema), nil
+	return defaultRegistry.GetDynamic(ver)
 }
 
 func normalizeFlatSchema(flatSchema map[string]interface{}) map[string]interface{} {
@@ -177,7 +130,14 @@ func getDynamicSchema(flatSchema map[string]interface{}) sets.Set[string] {
 
 // IsAllDynamicConfig returns true if all the fields in the given configMap are dynamically configured.
 func IsAllDynamicConfig(log logr.Logger, configMap DynamicConfigMap, version string) (bool, error) {
-	dynamic, err := GetDynamic(version)
+	return defaultRegistry.IsAllDynamicConfig(log, configMap, version)
+}
+
+// IsAllDynamicConfig returns true if all the fields in the given configMap are dynamically configured.
+func (r *SchemaRegistry) IsAllDynamicConfig(log logr.Logger, configMap DynamicConfigMap, version string) (
+	bool, error,
+) {
+	dynamic, err := r.GetDynamic(version)
 	if err != nil {
 		// retry error fall back to rolling restart.
 		return false, err
@@ -234,12 +194,7 @@ func getDefaultValue(defaultMap map[string]interface{}, conf string) interface{}
 
 // GetDefault return the map of default values.
 func GetDefault(ver string) (map[string]interface{}, error) {
-	flatSchema, err := getFlatSchema(ver)
-	if err != nil {
-		return nil, err
-	}
-
-	return getDefaultSchema(flatSchema), nil
+	return defaultRegistry.GetDefault(ver)
 }
 
 // getDefaultSchema return the map of values which are default
@@ -390,20 +345,3 @@ func eval(v interface{}) interface{} {
 		return v
 	}
 }
-
-func getFlatSchema(ver string) (map[string]interface{}, error) {
-	schemaJSON, err := getSchema(ver)
-	if err != nil {
-		return nil, err
-	}
-
-	schema := make(map[string]interface{})
-	d := json.NewDecoder(strings.NewReader(schemaJSON))
-	d.UseNumber()
-
-	if err := d.Decode(&schema); err != nil {
-		return nil, err
-	}
-
-	return flattenSchema(schema, sep), nil
-}
@@ -0,0 +1,255 @@
+package asconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	sets "github.com/deckarep/golang-set/v2"
+)
+
+// SchemaValueChange is a property of a config key which differs between two schemas.
+type SchemaValueChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+	Key string      `json:"key"`
+}
+
+// SchemaDiff is the difference between the config schemas of two server versions.
+// Keys are flat schema keys, with "_" in place of the names of named sections,
+// eg. "namespaces._.storage-engine.data-size".
+type SchemaDiff struct {
+	FromVersion string `json:"from-version"`
+	ToVersion   string `json:"to-version"`
+	// Added are the keys only present in the ToVersion schema.
+	Added []string `json:"added"`
+	// Removed are the keys only present in the FromVersion schema.
+	Removed []string `json:"removed"`
+	// DefaultChanged are the keys whose default value changed.
+	DefaultChanged []SchemaValueChange `json:"default-changed"`
+	// DynamicChanged are the keys which changed between static and dynamic.
+	// Old and New are the dynamic flags.
+	DynamicChanged []SchemaValueChange `json:"dynamic-changed"`
+	// TypeChanged are the keys whose json type changed.
+	TypeChanged []SchemaValueChange `json:"type-changed"`
+	// RangeChanged are the keys whose minimum, maximum or allowed values changed.
+	RangeChanged []SchemaValueChange `json:"range-changed"`
+}
+
+// schemaKeyInfo is the part of the schema of a key compared by DiffSchemas.
+type schemaKeyInfo struct {
+	typ      string
+	valRange string
+	dynamic  bool
+}
+
+// DiffSchemas compares the config schemas of fromVersion and toVersion.
+// Both the schemas should be loaded with Init or InitFromMap.
+func DiffSchemas(fromVersion, toVersion string) (*SchemaDiff, error) {
+	return defaultRegistry.DiffSchemas(fromVersion, toVersion)
+}
+
+// DiffSchemas compares the config schemas of fromVersion and toVersion in the registry.
+func (r *SchemaRegistry) DiffSchemas(fromVersion, toVersion string) (*SchemaDiff, error) {
+	fromFlatSchema, err := r.getFlatSchema(fromVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	toFlatSchema, err := r.getFlatSchema(toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	fromKeys := getSchemaKeyInfo(fromFlatSchema)
+	toKeys := getSchemaKeyInfo(toFlatSchema)
+	fromDefaults := getDefaultSchema(fromFlatSchema)
+	toDefaults := getDefaultSchema(toFlatSchema)
+
+	diff := &SchemaDiff{
+		FromVersion:    fromVersion,
+		ToVersion:      toVersion,
+		Added:          make([]string, 0),
+		Removed:        make([]string, 0),
+		DefaultChanged: make([]SchemaValueChange, 0),
+		DynamicChanged: make([]SchemaValueChange, 0),
+		TypeChanged:    make([]SchemaValueChange, 0),
+		RangeChanged:   make([]SchemaValueChange, 0),
+	}
+
+	for _, key := range sortedSchemaKeys(fromKeys) {
+		if _, ok := toKeys[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+
+	for _, key := range sortedSchemaKeys(toKeys) {
+		to := toKeys[key]
+
+		from, ok := fromKeys[key]
+		if !ok {
+			diff.Added = append(diff.Added, key)
+			continue
+		}
+
+		if from.typ != to.typ {
+			diff.TypeChanged = append(diff.TypeChanged, SchemaValueChange{Key: key, Old: from.typ, New: to.typ})
+		}
+
+		if from.valRange != to.valRange {
+			diff.RangeChanged = append(diff.RangeChanged,
+				SchemaValueChange{Key: key, Old: from.valRange, New: to.valRange})
+		}
+
+		if from.dynamic != to.dynamic {
+			diff.DynamicChanged = append(diff.DynamicChanged,
+				SchemaValueChange{Key: key, Old: from.dynamic, New: to.dynamic})
+		}
+
+		fromDefault, fromOK := fromDefaults[key]
+		toDefault, toOK := toDefaults[key]
+
+		if fromOK && toOK && !reflect.DeepEqual(fromDefault, toDefault) {
+			diff.DefaultChanged = append(diff.DefaultChanged,
+				SchemaValueChange{Key: key, Old: fromDefault, New: toDefault})
+		}
+	}
+
+	return diff, nil
+}
+
+// getSchemaKeyInfo returns the type, range and dynamic flag of all the keys
+// of the flat schema, sections included. The items of arrays are skipped as
+// they are described by the array key itself.
+func getSchemaKeyInfo(flatSchema map[string]interface{}) map[string]*schemaKeyInfo {
+	dynamic := getDynamicSchema(flatSchema)
+	res := make(map[string]*schemaKeyInfo)
+
+	for key, constraints := range getValueConstraints(flatSchema) {
+		if key == "" || key == "_" || strings.HasSuffix(key, sep+"_") {
+			continue
+		}
+
+		types := sets.NewSet[string]()
+		ranges := sets.NewSet[string]()
+
+		for _, c := range constraints {
+			if c.typ != "" {
+				types.Add(c.typ)
+			}
+
+			if r := c.rangeString(); r != "" {
+				ranges.Add(r)
+			}
+		}
+
+		res[key] = &schemaKeyInfo{
+			typ:      joinSorted(types, "|"),
+			valRange: joinSorted(ranges, " | "),
+			dynamic:  dynamic.Contains(key),
+		}
+	}
+
+	return res
+}
+
+// rangeString returns the minimum, maximum and enum of the constraint in a
+// human-readable form, eg. "[0, 100]" or "{mesh, multicast}".
+func (c *valueConstraint) rangeString() string {
+	parts := make([]string, 0, 2)
+
+	if c.minimum != nil || c.maximum != nil {
+		bound := func(f *float64) string {
+			if f == nil {
+				return ""
+			}
+
+			return fmt.Sprintf("%v", *f)
+		}
+
+		parts = append(parts, fmt.Sprintf("[%s, %s]", bound(c.minimum), bound(c.maximum)))
+	}
+
+	if len(c.enum) > 0 {
+		parts = append(parts, "{"+strings.Join(c.enum, ", ")+"}")
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func joinSorted(s sets.Set[string], sep string) string {
+	l := s.ToSlice()
+	sort.Strings(l)
+
+	return strings.Join(l, sep)
+}
+
+func sortedSchemaKeys(m map[string]*schemaKeyInfo) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// JSON renders the diff as indented JSON.
+func (d *SchemaDiff) JSON() ([]byte, error) {
+	return json.MarshalIndent(d, "", "  ")
+}
+
+// Markdown renders the diff as a Markdown document. Sections without any
+// change are left out.
+func (d *SchemaDiff) Markdown() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# Config schema changes from %s to %s\n", d.FromVersion, d.ToVersion)
+
+	writeList := func(title string, keys []string) {
+		if len(keys) == 0 {
+			return
+		}
+
+		fmt.Fprintf(&sb, "\n## %s\n\n", title)
+
+		for _, k := range keys {
+			fmt.Fprintf(&sb, "- `%s`\n", k)
+		}
+	}
+
+	writeTable := func(title string, changes []SchemaValueChange) {
+		if len(changes) == 0 {
+			return
+		}
+
+		fmt.Fprintf(&sb, "\n## %s\n\n", title)
+		fmt.Fprintf(&sb, "| Key | %s | %s |\n", d.FromVersion, d.ToVersion)
+		sb.WriteString("| --- | --- | --- |\n")
+
+		for _, c := range changes {
+			fmt.Fprintf(&sb, "| `%s` | %s | %s |\n", c.Key, markdownValue(c.Old), markdownValue(c.New))
+		}
+	}
+
+	writeList("Added keys", d.Added)
+	writeList("Removed keys", d.Removed)
+	writeTable("Default changes", d.DefaultChanged)
+	writeTable("Static/dynamic changes", d.DynamicChanged)
+	writeTable("Type changes", d.TypeChanged)
+	writeTable("Range changes", d.RangeChanged)
+
+	return sb.String()
+}
+
+func markdownValue(v interface{}) string {
+	s := fmt.Sprintf("%v", v)
+	if s == "" {
+		return ""
+	}
+
+	return "`" + strings.ReplaceAll(s, "|", "\\|") + "`"
+}
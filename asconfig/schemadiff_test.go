@@ -0,0 +1,69 @@
+package asconfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type SchemaDiffTestSuite struct {
+	suite.Suite
+}
+
+func (s *SchemaDiffTestSuite) SetupSuite() {
+	InitFromMap(logr.Discard(), testSchemas)
+}
+
+func (s *SchemaDiffTestSuite) TestDiffSchemas() {
+	diff, err := DiffSchemas("6.4.0", "7.0.0")
+	s.Require().NoError(err)
+
+	s.Assert().Contains(diff.Added, "namespaces._.indexes-memory-budget")
+	s.Assert().Contains(diff.Added, "namespaces._.storage-engine.data-size")
+	s.Assert().Contains(diff.Removed, "namespaces._.memory-size")
+	s.Assert().Contains(diff.Removed, "namespaces._.storage-engine.min-avail-pct")
+	s.Assert().NotContains(diff.Added, "namespaces._.name")
+	s.Assert().Equal([]SchemaValueChange{
+		{Key: "namespaces._.storage-engine.write-block-size", Old: uint64(131072), New: uint64(1048576)},
+	}, diff.DefaultChanged)
+	s.Assert().Equal([]SchemaValueChange{{Key: "xdr.dcs._.tls-name", Old: false, New: true}}, diff.DynamicChanged)
+	s.Assert().Equal([]SchemaValueChange{{Key: "service.node-id", Old: "integer", New: "string"}}, diff.TypeChanged)
+	s.Assert().Equal([]SchemaValueChange{
+		{Key: "namespaces._.replication-factor", Old: "[1, 128]", New: "[1, 256]"},
+	}, diff.RangeChanged)
+
+	same, err := DiffSchemas("7.0.0", "7.0.0")
+	s.Require().NoError(err)
+	s.Assert().Empty(same.Added)
+	s.Assert().Empty(same.Removed)
+	s.Assert().Empty(same.DefaultChanged)
+	s.Assert().Empty(same.RangeChanged)
+
+	_, err = DiffSchemas("6.4.0", "5.0.0")
+	s.Assert().Error(err)
+}
+
+func (s *SchemaDiffTestSuite) TestRender() {
+	diff, err := DiffSchemas("6.4.0", "7.0.0")
+	s.Require().NoError(err)
+
+	md := diff.Markdown()
+	s.Assert().Contains(md, "# Config schema changes from 6.4.0 to 7.0.0\n")
+	s.Assert().Contains(md, "- `namespaces._.memory-size`\n")
+	s.Assert().Contains(md, "| `service.node-id` | `integer` | `string` |\n")
+
+	b, err := diff.JSON()
+	s.Require().NoError(err)
+
+	decoded := &SchemaDiff{}
+	s.Require().NoError(json.Unmarshal(b, decoded))
+	s.Assert().Equal(diff.Added, decoded.Added)
+	s.Assert().Equal(diff.Removed, decoded.Removed)
+	s.Assert().Equal("service.node-id", decoded.TypeChanged[0].Key)
+}
+
+func TestSchemaDiffTestSuite(t *testing.T) {
+	suite.Run(t, new(SchemaDiffTestSuite))
+}
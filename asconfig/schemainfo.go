@@ -0,0 +1,220 @@
+package asconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sets "github.com/deckarep/golang-set/v2"
+	"github.com/go-logr/logr"
+)
+
+// KeyDescription describes a config key as defined in the config schema of a
+// server version.
+type KeyDescription struct {
+	// Default is the default value, nil if the schema does not have a single
+	// default for the key, eg. when oneOf variants have different defaults.
+	Default interface{}
+	// Minimum and Maximum are nil if the key has no such bound. For keys defined
+	// in several oneOf/anyOf variants they are the widest bounds.
+	Minimum *float64
+	Maximum *float64
+	// Key is the flat schema key, with "_" in place of the names of named
+	// sections, eg. "namespaces._.default-ttl".
+	Key string
+	// Type is the json type of the key, types of several variants are joined with "|".
+	Type        string
+	Description string
+	// Sections are the flat schema keys of the sections containing the key,
+	// outermost first.
+	Sections []string
+	// Enum are the allowed values, empty if any value of Type is allowed.
+	Enum []string
+	// Dynamic keys can be changed at runtime with set-config.
+	Dynamic bool
+	// Required keys must be present in their section. For oneOf/anyOf
+	// sections it is enough for the key to be required by one variant.
+	Required bool
+	// IsSection is true for the keys containing other keys, eg. "network.heartbeat".
+	IsSection bool
+}
+
+// DescribeKey returns the description of a config key in the schema of the
+// given version. flatKey can be a flat config key, eg. "namespaces.{test}.default-ttl",
+// or a flat schema key, eg. "namespaces._.default-ttl".
+func DescribeKey(log logr.Logger, version, flatKey string) (*KeyDescription, error) {
+	return defaultRegistry.DescribeKey(log, version, flatKey)
+}
+
+// DescribeKey returns the description of a config key in the schema of the
+// given version in the registry.
+func (r *SchemaRegistry) DescribeKey(log logr.Logger, version, flatKey string) (*KeyDescription, error) {
+	descs, err := r.getKeyDescriptions(version)
+	if err != nil {
+		return nil, err
+	}
+
+	key := toSchemaKey(log, flatKey)
+
+	desc, ok := descs[key]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a config in version %s", ErrConfigKeyInvalid, flatKey, version)
+	}
+
+	return desc, nil
+}
+
+// ListKeys returns the descriptions of the keys directly under the given
+// context, sorted by key. An empty context lists the top level sections.
+// Keys of list sections are listed for the section itself, eg. both "namespaces"
+// and "namespaces.{test}" list the namespace configs. Sections are returned as
+// well, callers can list their keys in turn.
+func ListKeys(log logr.Logger, version, context string) ([]*KeyDescription, error) {
+	return defaultRegistry.ListKeys(log, version, context)
+}
+
+// ListKeys returns the descriptions of the keys directly under the given
+// context in the schema of the version in the registry.
+func (r *SchemaRegistry) ListKeys(log logr.Logger, version, context string) ([]*KeyDescription, error) {
+	descs, err := r.getKeyDescriptions(version)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := ""
+
+	if context != "" {
+		prefix = strings.TrimSuffix(toSchemaKey(log, context), sep+"_")
+
+		if desc, ok := descs[prefix+sep+"_"]; ok && desc.IsSection {
+			prefix += sep + "_"
+		}
+
+		if desc, ok := descs[prefix]; !ok || !desc.IsSection {
+			return nil, fmt.Errorf("%w: %s is not a config section in version %s", ErrConfigKeyInvalid, context,
+				version)
+		}
+
+		prefix += sep
+	}
+
+	res := make([]*KeyDescription, 0)
+
+	for key, desc := range descs {
+		rest, ok := strings.CutPrefix(key, prefix)
+		if !ok || rest == "" || strings.Contains(rest, sep) {
+			continue
+		}
+
+		res = append(res, desc)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+
+	return res, nil
+}
+
+func toSchemaKey(log logr.Logger, flatKey string) string {
+	return GetFlatKey(SplitKey(log, flatKey, sep))
+}
+
+// getKeyDescriptions returns the descriptions of all the keys in the schema
+// of the version. The items of arrays are described by the array key itself,
+// except for the items of list sections which are kept as the "_" key so that
+// their configs can be found, eg. "namespaces._".
+func (r *SchemaRegistry) getKeyDescriptions(version string) (map[string]*KeyDescription, error) {
+	flatSchema, err := r.getFlatSchema(version)
+	if err != nil {
+		return nil, err
+	}
+
+	nFlatSchema := normalizeFlatSchema(flatSchema)
+	defaults := getDefaultSchema(flatSchema)
+	dynamic := getDynamicSchema(flatSchema)
+	required := getRequiredSchema(flatSchema)
+	res := make(map[string]*KeyDescription)
+
+	for key, constraints := range getValueConstraints(flatSchema) {
+		if key == "" || key == "_" {
+			continue
+		}
+
+		desc := &KeyDescription{
+			Key:     key,
+			Dynamic: dynamic.Contains(key),
+		}
+
+		types := sets.NewSet[string]()
+		enum := sets.NewSet[string]()
+
+		for _, c := range constraints {
+			if c.typ != "" {
+				types.Add(c.typ)
+			}
+
+			if c.minimum != nil && (desc.Minimum == nil || *c.minimum < *desc.Minimum) {
+				desc.Minimum = c.minimum
+			}
+
+			if c.maximum != nil && (desc.Maximum == nil || *c.maximum > *desc.Maximum) {
+				desc.Maximum = c.maximum
+			}
+
+			enum.Append(c.enum...)
+		}
+
+		desc.Type = joinSorted(types, "|")
+		desc.IsSection = types.Contains(schemaTypeObject)
+		desc.Enum = enum.ToSlice()
+		sort.Strings(desc.Enum)
+
+		if d, ok := defaults[key]; ok {
+			desc.Default = d
+		}
+
+		if d, ok := nFlatSchema[key+sep+"description"].(string); ok {
+			desc.Description = d
+		}
+
+		desc.Sections, desc.Required = keySections(key, required)
+
+		// Items of the arrays of scalars are not keys on their own.
+		if strings.HasSuffix(key, sep+"_") && !desc.IsSection {
+			continue
+		}
+
+		res[key] = desc
+	}
+
+	return res, nil
+}
+
+// keySections returns the sections containing the key and whether the key is
+// required in its parent section.
+func keySections(key string, required map[string][][]string) (sections []string, isRequired bool) {
+	tokens := strings.Split(key, sep)
+	sections = make([]string, 0, len(tokens))
+
+	for i := 1; i < len(tokens); i++ {
+		if tokens[i-1] == "_" {
+			continue
+		}
+
+		sections = append(sections, strings.Join(tokens[:i], sep))
+	}
+
+	parent := strings.Join(tokens[:len(tokens)-1], sep)
+	base := tokens[len(tokens)-1]
+
+	for _, reqKeys := range required[parent] {
+		for _, k := range reqKeys {
+			if k == base {
+				isRequired = true
+			}
+		}
+	}
+
+	return sections, isRequired
+}
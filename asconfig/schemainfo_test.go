@@ -0,0 +1,88 @@
+package asconfig
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type SchemaInfoTestSuite struct {
+	suite.Suite
+}
+
+func (s *SchemaInfoTestSuite) SetupSuite() {
+	InitFromMap(logr.Discard(), testSchemas)
+}
+
+func (s *SchemaInfoTestSuite) TestDescribeKey() {
+	desc, err := DescribeKey(logr.Discard(), "7.0.0", "namespaces.{test}.default-ttl")
+	s.Require().NoError(err)
+	s.Assert().Equal("namespaces._.default-ttl", desc.Key)
+	s.Assert().Equal("integer", desc.Type)
+	s.Assert().Equal(uint64(0), desc.Default)
+	s.Assert().Equal(0.0, *desc.Minimum)
+	s.Assert().Equal(315360000.0, *desc.Maximum)
+	s.Assert().True(desc.Dynamic)
+	s.Assert().False(desc.Required)
+	s.Assert().False(desc.IsSection)
+	s.Assert().Equal([]string{"namespaces"}, desc.Sections)
+
+	desc, err = DescribeKey(logr.Discard(), "7.0.0", "namespaces._.storage-engine.type")
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"device", "memory"}, desc.Enum)
+	s.Assert().Equal("", desc.Default)
+	s.Assert().True(desc.Required)
+	s.Assert().False(desc.Dynamic)
+	s.Assert().Equal([]string{"namespaces", "namespaces._.storage-engine"}, desc.Sections)
+
+	desc, err = DescribeKey(logr.Discard(), "7.0.0", "network.heartbeat")
+	s.Require().NoError(err)
+	s.Assert().True(desc.IsSection)
+	s.Assert().True(desc.Required)
+	s.Assert().Equal([]string{"network"}, desc.Sections)
+
+	_, err = DescribeKey(logr.Discard(), "7.0.0", "namespaces.{test}.memory-size")
+	s.Assert().ErrorIs(err, ErrConfigKeyInvalid)
+
+	desc, err = DescribeKey(logr.Discard(), "6.4.0", "namespaces.{test}.memory-size")
+	s.Require().NoError(err)
+	s.Assert().Equal(uint64(4294967296), desc.Default)
+}
+
+func (s *SchemaInfoTestSuite) TestListKeys() {
+	keysOf := func(descs []*KeyDescription) []string {
+		keys := make([]string, 0, len(descs))
+		for _, d := range descs {
+			keys = append(keys, d.Key)
+		}
+
+		return keys
+	}
+
+	descs, err := ListKeys(logr.Discard(), "7.0.0", "")
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"logging", "namespaces", "network", "security", "service", "xdr"}, keysOf(descs))
+
+	descs, err = ListKeys(logr.Discard(), "7.0.0", "network.heartbeat")
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{
+		"network.heartbeat.interval", "network.heartbeat.mesh-seed-address-ports", "network.heartbeat.mode",
+		"network.heartbeat.port", "network.heartbeat.tls-name",
+	}, keysOf(descs))
+
+	for _, context := range []string{"namespaces", "namespaces.{test}"} {
+		descs, err = ListKeys(logr.Discard(), "7.0.0", context)
+		s.Require().NoError(err)
+		s.Assert().Contains(keysOf(descs), "namespaces._.default-ttl")
+		s.Assert().Contains(keysOf(descs), "namespaces._.storage-engine")
+		s.Assert().NotContains(keysOf(descs), "namespaces._.storage-engine.type")
+	}
+
+	_, err = ListKeys(logr.Discard(), "7.0.0", "service.proto-fd-max")
+	s.Assert().ErrorIs(err, ErrConfigKeyInvalid)
+}
+
+func TestSchemaInfoTestSuite(t *testing.T) {
+	suite.Run(t, new(SchemaInfoTestSuite))
+}
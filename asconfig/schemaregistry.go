@@ -0,0 +1,363 @@
+package asconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	sets "github.com/deckarep/golang-set/v2"
+	"github.com/go-logr/logr"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// SchemaRegistry holds the aerospike config schemas of a set of server
+// versions and caches their compiled and flattened forms.
+//
+// A SchemaRegistry is safe for concurrent use. Components of a process can
+// use different registries to work with different sets of schemas. The
+// package level functions, eg. GetDynamic or GenerateConf, use the default
+// registry filled by Init or InitFromMap.
+type SchemaRegistry struct {
+	// schemas is the map of base version to json schema.
+	schemas map[string]string
+	// flatSchemas is the cache of flattened schemas, filled lazily.
+	flatSchemas map[string]map[string]interface{}
+	// compiledSchemas is the cache of the schemas compiled for validation, filled lazily.
+	compiledSchemas map[string]*gojsonschema.Schema
+	// derivedSchemas is the cache of the dynamic and default values and of the
+	// value constraints, filled lazily.
+	derivedSchemas map[string]*derivedSchema
+	mu             sync.RWMutex
+}
+
+// derivedSchema holds the values computed from a flat schema.
+type derivedSchema struct {
+	dynamic     sets.Set[string]
+	defaults    map[string]interface{}
+	constraints valueConstraints
+}
+
+var defaultRegistry = NewSchemaRegistry()
+
+// DefaultSchemaRegistry returns the registry used by the package level functions.
+func DefaultSchemaRegistry() *SchemaRegistry {
+	return defaultRegistry
+}
+
+// NewSchemaRegistry returns an empty SchemaRegistry.
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		schemas:         make(map[string]string),
+		flatSchemas:     make(map[string]map[string]interface{}),
+		compiledSchemas: make(map[string]*gojsonschema.Schema),
+		derivedSchemas:  make(map[string]*derivedSchema),
+	}
+}
+
+// NewSchemaRegistryFromMap returns a SchemaRegistry with the schemas of the map.
+// Map key format -> 4.1.0
+// Map value format -> string of json schema
+func NewSchemaRegistryFromMap(log logr.Logger, schemaMap map[string]string) *SchemaRegistry {
+	r := NewSchemaRegistry()
+	r.load(log, schemaMap)
+
+	return r
+}
+
+// NewSchemaRegistryFromDir returns a SchemaRegistry with the schemas of the
+// directory. File names are the versions, eg. 7_0_0.json. As with Init, every
+// file of the directory is read as a schema.
+func NewSchemaRegistryFromDir(log logr.Logger, schemaDir string) (*SchemaRegistry, error) {
+	log.V(1).Info("Config schema dir", "dir", schemaDir)
+
+	schemaMap, err := readSchemaDir(schemaDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSchemaRegistryFromMap(log, schemaMap), nil
+}
+
+// NewSchemaRegistryFromFS returns a SchemaRegistry with the schemas of the
+// directory dir of fsys, eg. an embed.FS. File names are the versions,
+// eg. 7_0_0.json. Files without the .json extension are skipped.
+func NewSchemaRegistryFromFS(log logr.Logger, fsys fs.FS, dir string) (*SchemaRegistry, error) {
+	schemaMap, err := readSchemaFS(fsys, dir, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config schema dir %s: %w", dir, err)
+	}
+
+	return NewSchemaRegistryFromMap(log, schemaMap), nil
+}
+
+// readSchemaDir reads the schemas of the directory schemaDir of the local
+// filesystem.
+func readSchemaDir(schemaDir string) (map[string]string, error) {
+	if schemaDir == "" {
+		return nil, fmt.Errorf("no config schema dir given")
+	}
+
+	schemaMap, err := readSchemaFS(os.DirFS(schemaDir), ".", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config schema dir %s: %w", schemaDir, err)
+	}
+
+	return schemaMap, nil
+}
+
+// readSchemaFS reads the schemas of the directory dir of fsys. If jsonOnly is
+// true the files without the .json extension, eg. a README of an embedded
+// schema dir, are skipped.
+func readSchemaFS(fsys fs.FS, dir string, jsonOnly bool) (map[string]string, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaMap := make(map[string]string)
+
+	for _, entry := range entries {
+		// no need to check recursively
+		if entry.IsDir() || jsonOnly && path.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		schema, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("wrong config schema file %s: %v", entry.Name(), err)
+		}
+
+		schemaMap[versionFormat(entry.Name())] = string(schema)
+	}
+
+	if len(schemaMap) == 0 {
+		return nil, fmt.Errorf("no config schema file available")
+	}
+
+	return schemaMap, nil
+}
+
+// load replaces all the schemas of the registry.
+func (r *SchemaRegistry) load(log logr.Logger, schemaMap map[string]string) {
+	schemas := make(map[string]string, len(schemaMap))
+
+	for name, schema := range schemaMap {
+		log.V(1).Info("Config schema added", "version", name)
+
+		schemas[name] = schema
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.schemas = schemas
+	r.flatSchemas = make(map[string]map[string]interface{})
+	r.compiledSchemas = make(map[string]*gojsonschema.Schema)
+	r.derivedSchemas = make(map[string]*derivedSchema)
+}
+
+// Add adds or replaces the schema of a version.
+// Version format -> 4.1.0
+func (r *SchemaRegistry) Add(version, schema string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.schemas[version] = schema
+	delete(r.flatSchemas, version)
+	delete(r.compiledSchemas, version)
+	delete(r.derivedSchemas, version)
+}
+
+// Versions returns the sorted versions of the schemas in the registry.
+func (r *SchemaRegistry) Versions() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := make([]string, 0, len(r.schemas))
+	for v := range r.schemas {
+		versions = append(versions, v)
+	}
+
+	sort.Strings(versions)
+
+	return versions
+}
+
+// IsSupportedVersion returns true if the registry has a schema for the version.
+func (r *SchemaRegistry) IsSupportedVersion(ver string) (bool, error) {
+	baseVersion, err := baseVersion(ver)
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.schemas[baseVersion]
+
+	return ok, nil
+}
+
+// getSchema returns JSON schema string based on the passed in version.
+func (r *SchemaRegistry) getSchema(ver string) (string, error) {
+	baseVersion, err := baseVersion(ver)
+	if err != nil {
+		return baseVersion, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	schema, ok := r.schemas[baseVersion]
+	if ok {
+		return schema, nil
+	}
+
+	return "", fmt.Errorf("unsupported version")
+}
+
+// getFlatSchema returns the flattened schema of the version. The returned
+// map is shared by all the callers and must not be modified.
+func (r *SchemaRegistry) getFlatSchema(ver string) (map[string]interface{}, error) {
+	baseVersion, err := baseVersion(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	flatSchema, ok := r.flatSchemas[baseVersion]
+	r.mu.RUnlock()
+
+	if ok {
+		return flatSchema, nil
+	}
+
+	schemaJSON, err := r.getSchema(baseVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := make(map[string]interface{})
+	d := json.NewDecoder(strings.NewReader(schemaJSON))
+	d.UseNumber()
+
+	if err := d.Decode(&schema); err != nil {
+		return nil, err
+	}
+
+	flatSchema = flattenSchema(schema, sep)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The schema could have been replaced while it was being flattened.
+	if r.schemas[baseVersion] == schemaJSON {
+		r.flatSchemas[baseVersion] = flatSchema
+	}
+
+	return flatSchema, nil
+}
+
+// getCompiledSchema returns the schema of the version compiled for validation.
+// The returned schema is shared by all the callers, it is safe for concurrent
+// validations.
+func (r *SchemaRegistry) getCompiledSchema(ver string) (*gojsonschema.Schema, error) {
+	baseVersion, err := baseVersion(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	compiled, ok := r.compiledSchemas[baseVersion]
+	r.mu.RUnlock()
+
+	if ok {
+		return compiled, nil
+	}
+
+	schemaJSON, err := r.getSchema(baseVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled, err = gojsonschema.NewSchema(gojsonschema.NewStringLoader(schemaJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The schema could have been replaced while it was being compiled.
+	if r.schemas[baseVersion] == schemaJSON {
+		r.compiledSchemas[baseVersion] = compiled
+	}
+
+	return compiled, nil
+}
+
+// GetDynamic return the set of the flat schema keys which are dynamic.
+// The returned set is a copy, callers can modify it.
+func (r *SchemaRegistry) GetDynamic(ver string) (sets.Set[string], error) {
+	derived, err := r.getDerivedSchema(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	return derived.dynamic.Clone(), nil
+}
+
+// GetDefault return the map of default values of the flat schema keys.
+// The returned map is a shallow copy, callers can add or remove keys.
+func (r *SchemaRegistry) GetDefault(ver string) (map[string]interface{}, error) {
+	derived, err := r.getDerivedSchema(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	return maps.Clone(derived.defaults), nil
+}
+
+func (r *SchemaRegistry) getDerivedSchema(ver string) (*derivedSchema, error) {
+	baseVersion, err := baseVersion(ver)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	derived, ok := r.derivedSchemas[baseVersion]
+	r.mu.RUnlock()
+
+	if ok {
+		return derived, nil
+	}
+
+	flatSchema, err := r.getFlatSchema(baseVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	derived = &derivedSchema{
+		dynamic:     getDynamicSchema(flatSchema),
+		defaults:    getDefaultSchema(flatSchema),
+		constraints: getValueConstraints(flatSchema),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Only cache if the flat schema has not been dropped from the cache by a schema change.
+	if cached, ok := r.flatSchemas[baseVersion]; ok && reflect.ValueOf(cached).Pointer() ==
+		reflect.ValueOf(flatSchema).Pointer() {
+		r.derivedSchemas[baseVersion] = derived
+	}
+
+	return derived, nil
+}
@@ -0,0 +1,266 @@
+package asconfig
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type SchemaRegistryTestSuite struct {
+	suite.Suite
+}
+
+func (s *SchemaRegistryTestSuite) TestIndependentRegistries() {
+	r1 := NewSchemaRegistryFromMap(logr.Discard(), map[string]string{"7.0.0": testSchema700})
+	r2 := NewSchemaRegistryFromMap(logr.Discard(), map[string]string{"6.4.0": testSchema640})
+
+	s.Assert().Equal([]string{"7.0.0"}, r1.Versions())
+	s.Assert().Equal([]string{"6.4.0"}, r2.Versions())
+
+	ok, err := r1.IsSupportedVersion("7.0.0.1")
+	s.Require().NoError(err)
+	s.Assert().True(ok)
+
+	ok, err = r2.IsSupportedVersion("7.0.0.1")
+	s.Require().NoError(err)
+	s.Assert().False(ok)
+
+	_, err = r2.GetDynamic("7.0.0")
+	s.Assert().Error(err)
+
+	defaults, err := r2.GetDefault("6.4.0")
+	s.Require().NoError(err)
+	s.Assert().Equal(uint64(4294967296), defaults["namespaces._.memory-size"])
+
+	cfg, err := NewMapAsConfig(logr.Discard(), map[string]interface{}{
+		"network": migrateTestNetwork(),
+		"namespaces": []Conf{
+			{"name": "test", "memory-size": 1073741824, "storage-engine": Conf{"type": "memory"}},
+		},
+	})
+	s.Require().NoError(err)
+
+	valid, _, err := r2.IsValid(logr.Discard(), cfg, "6.4.0")
+	s.Require().NoError(err)
+	s.Assert().True(valid)
+
+	valid, _, _ = r1.IsValid(logr.Discard(), cfg, "7.0.0")
+	s.Assert().False(valid)
+}
+
+func (s *SchemaRegistryTestSuite) TestFlatSchemaCache() {
+	r := NewSchemaRegistryFromMap(logr.Discard(), map[string]string{"7.0.0": testSchema700})
+
+	f1, err := r.getFlatSchema("7.0.0")
+	s.Require().NoError(err)
+
+	f2, err := r.getFlatSchema("7.0.0.5")
+	s.Require().NoError(err)
+	s.Assert().Equal(reflect.ValueOf(f1).Pointer(), reflect.ValueOf(f2).Pointer())
+
+	r.Add("7.0.0", testSchema640)
+
+	f3, err := r.getFlatSchema("7.0.0")
+	s.Require().NoError(err)
+	s.Assert().NotEqual(reflect.ValueOf(f1).Pointer(), reflect.ValueOf(f3).Pointer())
+	s.Assert().Contains(f3, "properties.namespaces.items.properties.memory-size.default")
+}
+
+func (s *SchemaRegistryTestSuite) TestCompiledSchemaCache() {
+	r := NewSchemaRegistryFromMap(logr.Discard(), map[string]string{"7.0.0": testSchema700})
+
+	c1, err := r.getCompiledSchema("7.0.0")
+	s.Require().NoError(err)
+
+	c2, err := r.getCompiledSchema("7.0.0.5")
+	s.Require().NoError(err)
+	s.Assert().Same(c1, c2)
+
+	r.Add("7.0.0", testSchema640)
+
+	c3, err := r.getCompiledSchema("7.0.0")
+	s.Require().NoError(err)
+	s.Assert().NotSame(c1, c3)
+
+	r.Add("7.1.0", "{")
+
+	_, err = r.getCompiledSchema("7.1.0")
+	s.Assert().Error(err)
+}
+
+func (s *SchemaRegistryTestSuite) TestValueConstraintsCache() {
+	r := NewSchemaRegistryFromMap(logr.Discard(), map[string]string{"7.0.0": testSchema700})
+
+	vc1, err := r.getValueConstraintsForVersion("7.0.0")
+	s.Require().NoError(err)
+
+	vc2, err := r.getValueConstraintsForVersion("7.0.0.5")
+	s.Require().NoError(err)
+	s.Assert().Equal(reflect.ValueOf(vc1).Pointer(), reflect.ValueOf(vc2).Pointer())
+
+	r.Add("7.0.0", testSchema640)
+
+	vc3, err := r.getValueConstraintsForVersion("7.0.0")
+	s.Require().NoError(err)
+	s.Assert().NotEqual(reflect.ValueOf(vc1).Pointer(), reflect.ValueOf(vc3).Pointer())
+	s.Assert().Contains(vc3, "namespaces._.memory-size")
+}
+
+func (s *SchemaRegistryTestSuite) TestFromFS() {
+	fsys := fstest.MapFS{
+		"schemas/7_0_0.json": {Data: []byte(testSchema700)},
+		"schemas/6_4_0.json": {Data: []byte(testSchema640)},
+		"schemas/README.md":  {Data: []byte("# schemas")},
+	}
+
+	r, err := NewSchemaRegistryFromFS(logr.Discard(), fsys, "schemas")
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"6.4.0", "7.0.0"}, r.Versions())
+
+	_, err = NewSchemaRegistryFromFS(logr.Discard(), fstest.MapFS{"schemas/README.md": {}}, "schemas")
+	s.Assert().EqualError(err, "failed to read config schema dir schemas: no config schema file available")
+}
+
+func (s *SchemaRegistryTestSuite) TestFromDir() {
+	dir := s.T().TempDir()
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "7_0_0.json"), []byte(testSchema700), 0o600))
+
+	r, err := NewSchemaRegistryFromDir(logr.Discard(), dir)
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"7.0.0"}, r.Versions())
+
+	// unlike NewSchemaRegistryFromFS, the files are read whatever their extension
+	s.Require().NoError(os.WriteFile(filepath.Join(dir, "6_4_0"), []byte(testSchema640), 0o600))
+
+	r, err = NewSchemaRegistryFromDir(logr.Discard(), dir)
+	s.Require().NoError(err)
+	s.Assert().Equal([]string{"6.4.0", "7.0.0"}, r.Versions())
+
+	missing := filepath.Join(dir, "missing")
+
+	_, err = NewSchemaRegistryFromDir(logr.Discard(), missing)
+	s.Assert().ErrorContains(err, "failed to read config schema dir "+missing)
+
+	_, err = NewSchemaRegistryFromDir(logr.Discard(), "")
+	s.Assert().EqualError(err, "no config schema dir given")
+}
+
+func (s *SchemaRegistryTestSuite) TestConcurrentUse() {
+	r := NewSchemaRegistryFromMap(logr.Discard(), testSchemas)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := r.GetDynamic("7.0.0")
+			s.Assert().NoError(err)
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			r.Add("6.4.0", testSchema640)
+			_, err := r.DescribeKey(logr.Discard(), "6.4.0", "service.proto-fd-max")
+			s.Assert().NoError(err)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestSchemaRegistryTestSuite(t *testing.T) {
+	suite.Run(t, new(SchemaRegistryTestSuite))
+}
+
+func benchmarkConf(b *testing.B) *AsConfig {
+	b.Helper()
+
+	cfg, err := NewMapAsConfig(logr.Discard(), map[string]interface{}{
+		"network": migrateTestNetwork(),
+		"namespaces": []Conf{
+			{"name": "test", "storage-engine": Conf{"type": "memory", "data-size": 1073741824}},
+		},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return cfg
+}
+
+// BenchmarkIsValid validates with the compiled schema cached in the registry.
+func BenchmarkIsValid(b *testing.B) {
+	r := NewSchemaRegistryFromMap(logr.Discard(), testSchemas)
+	cfg := benchmarkConf(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if valid, _, err := r.IsValid(logr.Discard(), cfg, "7.0.0"); !valid || err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkIsValidUncached compiles the schema on every validation, as done
+// before the registry cached the compiled schemas.
+func BenchmarkIsValidUncached(b *testing.B) {
+	cfg := benchmarkConf(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		confJSON, err := json.Marshal(expandConf(logr.Discard(), cfg.baseConf, sep))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		result, err := gojsonschema.Validate(gojsonschema.NewStringLoader(testSchema700),
+			gojsonschema.NewStringLoader(string(confJSON)))
+		if err != nil || !result.Valid() {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetDynamic uses the flat schema cached in the registry.
+func BenchmarkGetDynamic(b *testing.B) {
+	r := NewSchemaRegistryFromMap(logr.Discard(), testSchemas)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := r.GetDynamic("7.0.0"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetDynamicUncached parses and flattens the schema on every call,
+// as done before the registry cached the flat schemas.
+func BenchmarkGetDynamicUncached(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r := NewSchemaRegistryFromMap(logr.Discard(), testSchemas)
+		if _, err := r.GetDynamic("7.0.0"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Package schemas bundles the aerospike config schemas into the binary.
+//
+// The bundle is opt-in: only the programs importing this package embed the
+// schemas. The json directory is generated from github.com/aerospike/schemas
+// by "go generate" and the generated files are committed with the package, so
+// that the programs getting the module with "go get" embed them. A tree where
+// the directory holds no schema reports ErrNotEmbedded from NewRegistry and
+// InitDefault.
+package schemas
+
+//go:generate make -C ../.. embed-schemas
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"path"
+
+	"github.com/go-logr/logr"
+
+	"github.com/aerospike/aerospike-management-lib/asconfig"
+)
+
+// schemaDir is the directory of the schema files in FS.
+const schemaDir = "json"
+
+// ErrNotEmbedded is returned when the json directory holds no schema, ie.
+// "go generate" was not run for the package.
+var ErrNotEmbedded = errors.New("no config schema embedded, run go generate for the schemas package")
+
+// FS holds the embedded schema files, named after the versions, eg. json/7_0_0.json.
+//
+//go:embed json
+var FS embed.FS
+
+// Embedded returns true if config schemas are embedded in the binary.
+func Embedded() bool {
+	matches, err := fs.Glob(FS, path.Join(schemaDir, "*.json"))
+
+	return err == nil && len(matches) > 0
+}
+
+// NewRegistry returns a SchemaRegistry with all the embedded schemas.
+func NewRegistry(log logr.Logger) (*asconfig.SchemaRegistry, error) {
+	if !Embedded() {
+		return nil, ErrNotEmbedded
+	}
+
+	return asconfig.NewSchemaRegistryFromFS(log, FS, schemaDir)
+}
+
+// InitDefault loads the embedded schemas in the default SchemaRegistry,
+// it can be used in place of asconfig.Init.
+func InitDefault(log logr.Logger) error {
+	if !Embedded() {
+		return ErrNotEmbedded
+	}
+
+	return asconfig.InitFromFS(log, FS, schemaDir)
+}
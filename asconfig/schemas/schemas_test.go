@@ -0,0 +1,33 @@
+package schemas
+
+import (
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+)
+
+type SchemasTestSuite struct {
+	suite.Suite
+}
+
+// TestNewRegistry checks the state of the bundle: without "make
+// embed-schemas" the package must report ErrNotEmbedded, with it every
+// embedded schema must load.
+func (s *SchemasTestSuite) TestNewRegistry() {
+	r, err := NewRegistry(logr.Discard())
+
+	if !Embedded() {
+		s.Assert().ErrorIs(err, ErrNotEmbedded)
+		s.Assert().ErrorIs(InitDefault(logr.Discard()), ErrNotEmbedded)
+
+		return
+	}
+
+	s.Require().NoError(err)
+	s.Assert().NotEmpty(r.Versions())
+}
+
+func TestSchemasTestSuite(t *testing.T) {
+	suite.Run(t, new(SchemasTestSuite))
+}
@@ -0,0 +1,201 @@
+package asconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	lib "github.com/aerospike/aerospike-management-lib"
+	"github.com/aerospike/aerospike-management-lib/info"
+)
+
+// SnapshotConfGetter is a ConfGetter reading the info of a node from a
+// snapshot, so that GenerateConf can run without access to the node, eg. on
+// the snapshots of a support bundle.
+//
+// A snapshot is the JSON object of the output of AsInfo.GetAsInfo, by info
+// command. The "configs" and "metadata" commands are needed by GenerateConf,
+// other commands, eg. "statistics", can be saved too:
+//
+//	{
+//	    "configs": {
+//	        "service": {"cluster-name": "prod", "proto-fd-max": 15000, ...},
+//	        "network": {"service.port": 3000, "heartbeat.mode": "mesh", ...},
+//	        "namespaces": {"test": {"replication-factor": 2, ...}},
+//	        "racks": [{"ns": "test", "rack_0": "BB9040011AC4202"}],
+//	        ...
+//	    },
+//	    "metadata": {"asd_build": "7.0.0.1", "node_id": "BB9040011AC4202", ...}
+//	}
+//
+// The snapshots are written by WriteSnapshot, see info/test/as_info.json
+// for a full example.
+type SnapshotConfGetter struct {
+	stats Conf
+}
+
+// NewSnapshotConfGetter reads a snapshot from r.
+func NewSnapshotConfGetter(r io.Reader) (*SnapshotConfGetter, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var data map[string]interface{}
+	if err := dec.Decode(&data); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+
+	stats := snapshotValue("", data).(Conf)
+
+	if _, ok := stats[info.ConstConfigs].(Conf); !ok {
+		return nil, fmt.Errorf("snapshot has no %s", info.ConstConfigs)
+	}
+
+	metadata, _ := stats[info.ConstMetadata].(Conf)
+	if _, ok := metadata[info.MetaBuild].(string); !ok {
+		return nil, fmt.Errorf("snapshot has no %s.%s", info.ConstMetadata, info.MetaBuild)
+	}
+
+	return &SnapshotConfGetter{stats: stats}, nil
+}
+
+// NewSnapshotConfGetterFromFile reads a snapshot from the file fileName.
+func NewSnapshotConfGetterFromFile(fileName string) (*SnapshotConfGetter, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	getter, err := NewSnapshotConfGetter(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+
+	return getter, nil
+}
+
+// AllConfigs returns the configs of the snapshot, like AsInfo.AllConfigs.
+func (g *SnapshotConfGetter) AllConfigs() (Conf, error) {
+	return lib.DeepCopy(g.stats[info.ConstConfigs]).(Conf), nil
+}
+
+// GetAsInfo returns the output of the info commands in cmdList, or of all
+// the commands of the snapshot if cmdList is empty, like AsInfo.GetAsInfo.
+func (g *SnapshotConfGetter) GetAsInfo(cmdList ...string) (Conf, error) {
+	if len(cmdList) == 0 {
+		return lib.DeepCopy(g.stats).(Conf), nil
+	}
+
+	res := make(Conf, len(cmdList))
+
+	for _, cmd := range cmdList {
+		v, ok := g.stats[cmd]
+		if !ok {
+			return nil, fmt.Errorf("snapshot has no %s", cmd)
+		}
+
+		res[cmd] = lib.DeepCopy(v)
+	}
+
+	return res, nil
+}
+
+// WriteSnapshot writes the snapshot of the node of confGetter, eg. an
+// *info.AsInfo, to w. The snapshot holds the output of the info commands in
+// cmdList, by default "configs" and "metadata", see SnapshotConfGetter.
+func WriteSnapshot(w io.Writer, confGetter ConfGetter, cmdList ...string) error {
+	if len(cmdList) == 0 {
+		cmdList = []string{info.ConstConfigs, info.ConstMetadata}
+	}
+
+	stats, err := confGetter.GetAsInfo(cmdList...)
+	if err != nil {
+		return fmt.Errorf("failed to get snapshot info from node: %w", err)
+	}
+
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+
+	if err := enc.Encode(stats); err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	_, err = w.Write(buf.Bytes())
+
+	return err
+}
+
+// WriteSnapshotFile writes the snapshot of the node of confGetter to the file
+// fileName, see WriteSnapshot.
+func WriteSnapshotFile(fileName string, confGetter ConfGetter, cmdList ...string) error {
+	buf := new(bytes.Buffer)
+
+	if err := WriteSnapshot(buf, confGetter, cmdList...); err != nil {
+		return err
+	}
+
+	return os.WriteFile(fileName, buf.Bytes(), 0o600)
+}
+
+// snapshotValue converts the decoded JSON value of the key to the types
+// returned by AsInfo: Conf for objects, int64 or float64 for numbers and
+// []Conf or []string for lists.
+func snapshotValue(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		conf := make(Conf, len(v))
+		for k := range v {
+			conf[k] = snapshotValue(k, v[k])
+		}
+
+		return conf
+
+	case []interface{}:
+		if key == info.ConfigRacksContext || (len(v) > 0 && isObjectList(v)) {
+			list := make([]Conf, 0, len(v))
+
+			for i := range v {
+				if c, ok := snapshotValue("", v[i]).(Conf); ok {
+					list = append(list, c)
+				}
+			}
+
+			return list
+		}
+
+		list := make([]string, 0, len(v))
+
+		for i := range v {
+			list = append(list, fmt.Sprint(snapshotValue("", v[i])))
+		}
+
+		return list
+
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+
+		f, _ := v.Float64()
+
+		return f
+
+	default:
+		return v
+	}
+}
+
+func isObjectList(v []interface{}) bool {
+	for i := range v {
+		if _, ok := v[i].(map[string]interface{}); !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,102 @@
+package asconfig
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/mock/gomock"
+
+	lib "github.com/aerospike/aerospike-management-lib"
+	"github.com/aerospike/aerospike-management-lib/info"
+)
+
+type SnapshotTestSuite struct {
+	suite.Suite
+	ctrl *gomock.Controller
+}
+
+func (s *SnapshotTestSuite) SetupSuite() {
+	InitFromMap(logr.Discard(), testSchemas)
+}
+
+func (s *SnapshotTestSuite) SetupTest() {
+	s.ctrl = gomock.NewController(s.T())
+}
+
+func (s *SnapshotTestSuite) TestWriteSnapshot() {
+	metadata := Conf{"asd_build": "7.0.0.1", "node_id": "A1"}
+	configs := clusterTestConfigs("A1", "10.0.0.1", 20000, "/dev/xvdb", "/dev/xvdc")
+	configs["racks"] = []Conf{{"ns": "test", "rack_1": "A1,B1"}}
+
+	node := NewMockConfGetter(s.ctrl)
+	node.EXPECT().GetAsInfo(info.ConstConfigs, info.ConstMetadata).Return(
+		Conf{info.ConstConfigs: configs, info.ConstMetadata: metadata}, nil)
+
+	var buf bytes.Buffer
+
+	s.Require().NoError(WriteSnapshot(&buf, node))
+
+	getter, err := NewSnapshotConfGetter(&buf)
+	s.Require().NoError(err)
+
+	allConfigs, err := getter.AllConfigs()
+	s.Require().NoError(err)
+	s.Assert().Equal(configs, allConfigs)
+
+	// the pipeline changes the configs, the snapshot can be used again
+	offline, err := GenerateConf(logr.Discard(), getter, true)
+	s.Require().NoError(err)
+
+	offline2, err := GenerateConf(logr.Discard(), getter, true)
+	s.Require().NoError(err)
+	s.Assert().Equal(offline, offline2)
+
+	live := NewMockConfGetter(s.ctrl)
+	live.EXPECT().AllConfigs().Return(lib.DeepCopy(configs).(Conf), nil)
+	live.EXPECT().GetAsInfo(info.ConstMetadata).Return(Conf{info.ConstMetadata: metadata}, nil)
+
+	expected, err := GenerateConf(logr.Discard(), live, true)
+	s.Require().NoError(err)
+	s.Assert().Equal(expected, offline)
+	s.Assert().EqualValues(1, expected.Conf["namespaces"].([]Conf)[1]["rack-id"])
+
+	_, err = getter.GetAsInfo(info.ConstStat)
+	s.Assert().ErrorContains(err, "snapshot has no statistics")
+}
+
+func (s *SnapshotTestSuite) TestSnapshotFile() {
+	getter, err := NewSnapshotConfGetterFromFile(filepath.Join("..", "info", "test", "as_info.json"))
+	s.Require().NoError(err)
+
+	configs, err := getter.AllConfigs()
+	s.Require().NoError(err)
+	s.Assert().Equal([]Conf{
+		{"ns": "test", "rack_0": "BB9040011AC4202"},
+		{"ns": "bar", "rack_0": "BB9040011AC4202"},
+	}, configs[info.ConfigRacksContext])
+	s.Assert().Equal(int64(3000), configs["network"].(Conf)["service.port"])
+
+	stats, err := getter.GetAsInfo()
+	s.Require().NoError(err)
+	s.Assert().Contains(stats, info.ConstStat)
+
+	fileName := filepath.Join(s.T().TempDir(), "snapshot.json")
+	s.Require().NoError(WriteSnapshotFile(fileName, getter))
+
+	written, err := NewSnapshotConfGetterFromFile(fileName)
+	s.Require().NoError(err)
+
+	writtenConfigs, err := written.AllConfigs()
+	s.Require().NoError(err)
+	s.Assert().Equal(configs, writtenConfigs)
+
+	_, err = NewSnapshotConfGetter(bytes.NewReader([]byte(`{"configs": {}}`)))
+	s.Assert().ErrorContains(err, "snapshot has no metadata.asd_build")
+}
+
+func TestSnapshotTestSuite(t *testing.T) {
+	suite.Run(t, new(SnapshotTestSuite))
+}
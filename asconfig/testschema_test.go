@@ -0,0 +1,488 @@
+package asconfig
+
+// testSchema700 is a trimmed down copy of the aerospike 7.0.0 config schema.
+// It keeps the layout of the real schema (properties, items, oneOf, dynamic,
+// default, required) for tests which do not need the full schema directory
+// pointed to by TEST_SCHEMA_DIR.
+const testSchema700 = `{
+  "$schema": "http://json-schema.org/draft-06/schema",
+  "additionalProperties": false,
+  "type": "object",
+  "required": ["network", "namespaces"],
+  "properties": {
+    "service": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "cluster-name": {"type": "string", "default": "", "dynamic": false, "description": ""},
+        "proto-fd-max": {"type": "integer", "default": 15000, "minimum": 0, "maximum": 2147483647,
+          "dynamic": true, "description": ""},
+        "migrate-threads": {"type": "integer", "default": 1, "minimum": 0, "maximum": 100,
+          "dynamic": true, "description": ""},
+        "node-id": {"type": "string", "default": "", "dynamic": false, "description": ""},
+        "advertise-ipv6": {"type": "boolean", "default": false, "dynamic": true, "description": ""},
+        "work-directory": {"type": "string", "default": "/opt/aerospike", "dynamic": false, "description": ""},
+        "feature-key-files": {"type": "array", "default": ["/etc/aerospike/features.conf"],
+          "dynamic": false, "description": "", "items": {"type": "string"}}
+      }
+    },
+    "logging": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["name"],
+        "properties": {
+          "name": {"type": "string", "default": " ", "dynamic": false, "description": ""},
+          "any": {"type": "string", "default": "INFO", "dynamic": true, "description": "",
+            "enum": ["CRITICAL", "critical", "WARNING", "warning", "INFO", "info", "DEBUG", "debug",
+              "DETAIL", "detail"]}
+        }
+      }
+    },
+    "network": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["service", "heartbeat", "fabric"],
+      "properties": {
+        "service": {
+          "type": "object",
+          "additionalProperties": false,
+          "required": ["port"],
+          "properties": {
+            "port": {"type": "integer", "default": 0, "minimum": 1024, "maximum": 65535,
+              "dynamic": false, "description": ""},
+            "addresses": {"type": "array", "default": [], "dynamic": false, "description": "",
+              "items": {"type": "string"}},
+            "access-addresses": {"type": "array", "default": [], "dynamic": false, "description": "",
+              "items": {"type": "string"}},
+            "tls-name": {"type": "string", "default": "", "dynamic": false, "description": ""},
+            "tls-port": {"type": "integer", "default": 0, "minimum": 1024, "maximum": 65535,
+              "dynamic": false, "description": ""}
+          }
+        },
+        "heartbeat": {
+          "type": "object",
+          "additionalProperties": false,
+          "required": ["mode"],
+          "properties": {
+            "mode": {"type": "string", "default": "", "enum": ["mesh", "multicast"], "dynamic": false,
+              "description": ""},
+            "port": {"type": "integer", "default": 0, "minimum": 1024, "maximum": 65535,
+              "dynamic": false, "description": ""},
+            "interval": {"type": "integer", "default": 150, "minimum": 50, "maximum": 600000,
+              "dynamic": true, "description": ""},
+            "mesh-seed-address-ports": {"type": "array", "default": [], "dynamic": false, "description": "",
+              "items": {"type": "string"}},
+            "tls-name": {"type": "string", "default": "", "dynamic": false, "description": ""}
+          }
+        },
+        "fabric": {
+          "type": "object",
+          "additionalProperties": false,
+          "required": ["port"],
+          "properties": {
+            "port": {"type": "integer", "default": 0, "minimum": 1024, "maximum": 65535,
+              "dynamic": false, "description": ""},
+            "tls-name": {"type": "string", "default": "", "dynamic": false, "description": ""}
+          }
+        },
+        "info": {
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "port": {"type": "integer", "default": 0, "minimum": 1024, "maximum": 65535,
+              "dynamic": false, "description": ""}
+          }
+        },
+        "tls": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "additionalProperties": false,
+            "required": ["name"],
+            "properties": {
+              "name": {"type": "string", "default": "", "dynamic": false, "description": ""},
+              "cert-file": {"type": "string", "default": "", "dynamic": false, "description": ""},
+              "key-file": {"type": "string", "default": "", "dynamic": false, "description": ""},
+              "ca-file": {"type": "string", "default": "", "dynamic": false, "description": ""}
+            }
+          }
+        }
+      }
+    },
+    "namespaces": {
+      "type": "array",
+      "minItems": 1,
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["name", "storage-engine"],
+        "properties": {
+          "name": {"type": "string", "default": " ", "dynamic": false, "description": ""},
+          "replication-factor": {"type": "integer", "default": 2, "minimum": 1, "maximum": 256,
+            "dynamic": false, "description": ""},
+          "default-ttl": {"type": "integer", "default": 0, "minimum": 0, "maximum": 315360000,
+            "dynamic": true, "description": ""},
+          "conflict-resolution-policy": {"type": "string", "default": "generation", "dynamic": true,
+            "description": "", "enum": ["generation", "last-update-time"]},
+          "strong-consistency": {"type": "boolean", "default": false, "dynamic": false, "description": ""},
+          "rack-id": {"type": "integer", "default": 0, "minimum": 0, "maximum": 1000000,
+            "dynamic": false, "description": ""},
+          "indexes-memory-budget": {"type": "integer", "default": 0, "minimum": 0,
+            "maximum": 18446744073709551615, "dynamic": true, "description": ""},
+          "stop-writes-sys-memory-pct": {"type": "integer", "default": 90, "minimum": 0, "maximum": 100,
+            "dynamic": true, "description": ""},
+          "evict-sys-memory-pct": {"type": "integer", "default": 0, "minimum": 0, "maximum": 100,
+            "dynamic": true, "description": ""},
+          "sets": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "additionalProperties": false,
+              "properties": {
+                "name": {"type": "string", "default": "", "dynamic": false, "description": ""},
+                "disable-eviction": {"type": "boolean", "default": false, "dynamic": true, "description": ""}
+              }
+            }
+          },
+          "storage-engine": {
+            "type": "object",
+            "oneOf": [
+              {
+                "type": "object",
+                "additionalProperties": false,
+                "required": ["type", "data-size"],
+                "properties": {
+                  "type": {"type": "string", "default": "", "enum": ["memory"], "dynamic": false,
+                    "description": ""},
+                  "data-size": {"type": "integer", "default": 0, "minimum": 536870912,
+                    "maximum": 2199023255552, "dynamic": false, "description": ""},
+                  "stop-writes-avail-pct": {"type": "integer", "default": 5, "minimum": 0, "maximum": 100,
+                    "dynamic": true, "description": ""},
+                  "evict-used-pct": {"type": "integer", "default": 0, "minimum": 0, "maximum": 100,
+                    "dynamic": true, "description": ""}
+                }
+              },
+              {
+                "type": "object",
+                "additionalProperties": false,
+                "required": ["type"],
+                "properties": {
+                  "type": {"type": "string", "default": "", "enum": ["device"], "dynamic": false,
+                    "description": ""},
+                  "devices": {"type": "array", "default": [], "dynamic": false, "description": "",
+                    "items": {"type": "string"}},
+                  "files": {"type": "array", "default": [], "dynamic": false, "description": "",
+                    "items": {"type": "string"}},
+                  "filesize": {"type": "integer", "default": 0, "minimum": 1048576,
+                    "maximum": 2199023255552, "dynamic": false, "description": ""},
+                  "write-block-size": {"type": "integer", "default": 1048576, "minimum": 1024,
+                    "maximum": 8388608, "dynamic": false, "description": ""},
+                  "stop-writes-avail-pct": {"type": "integer", "default": 5, "minimum": 0, "maximum": 100,
+                    "dynamic": true, "description": ""},
+                  "stop-writes-used-pct": {"type": "integer", "default": 70, "minimum": 0, "maximum": 100,
+                    "dynamic": true, "description": ""},
+                  "evict-used-pct": {"type": "integer", "default": 0, "minimum": 0, "maximum": 100,
+                    "dynamic": true, "description": ""}
+                }
+              }
+            ]
+          }
+        }
+      }
+    },
+    "security": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "privilege-refresh-period": {"type": "integer", "default": 300, "minimum": 10, "maximum": 86400,
+          "dynamic": true, "description": ""},
+        "log": {
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "report-data-op": {"type": "array", "default": [], "dynamic": true, "description": "",
+              "items": {"type": "string"}}
+          }
+        }
+      }
+    },
+    "xdr": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "src-id": {"type": "integer", "default": 0, "minimum": 0, "maximum": 255, "dynamic": true,
+          "description": ""},
+        "dcs": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "additionalProperties": false,
+            "properties": {
+              "name": {"type": "string", "default": "", "dynamic": true, "description": ""},
+              "node-address-ports": {"type": "array", "default": [], "dynamic": true, "description": "",
+                "items": {"type": "string"}},
+              "tls-name": {"type": "string", "default": "", "dynamic": true, "description": ""},
+              "namespaces": {
+                "type": "array",
+                "items": {
+                  "type": "object",
+                  "additionalProperties": false,
+                  "properties": {
+                    "name": {"type": "string", "default": "", "dynamic": true, "description": ""},
+                    "bin-policy": {"type": "string", "default": "all", "dynamic": true, "description": "",
+                      "enum": ["all", "no-bins", "only-changed", "changed-and-specified", "changed-or-specified"]}
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// testSchema640 is testSchema700 as it was before the 7.0.0 namespace storage
+// rework, plus a few made up default, dynamic, type and range differences.
+const testSchema640 = `{
+  "$schema": "http://json-schema.org/draft-06/schema",
+  "additionalProperties": false,
+  "type": "object",
+  "required": ["network", "namespaces"],
+  "properties": {
+    "service": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "cluster-name": {"type": "string", "default": "", "dynamic": false, "description": ""},
+        "proto-fd-max": {"type": "integer", "default": 15000, "minimum": 0, "maximum": 2147483647,
+          "dynamic": true, "description": ""},
+        "migrate-threads": {"type": "integer", "default": 1, "minimum": 0, "maximum": 100,
+          "dynamic": true, "description": ""},
+        "node-id": {"type": "integer", "default": "", "dynamic": false, "description": ""},
+        "advertise-ipv6": {"type": "boolean", "default": false, "dynamic": true, "description": ""},
+        "work-directory": {"type": "string", "default": "/opt/aerospike", "dynamic": false, "description": ""},
+        "feature-key-files": {"type": "array", "default": ["/etc/aerospike/features.conf"],
+          "dynamic": false, "description": "", "items": {"type": "string"}}
+      }
+    },
+    "logging": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["name"],
+        "properties": {
+          "name": {"type": "string", "default": " ", "dynamic": false, "description": ""},
+          "any": {"type": "string", "default": "INFO", "dynamic": true, "description": "",
+            "enum": ["CRITICAL", "critical", "WARNING", "warning", "INFO", "info", "DEBUG", "debug",
+              "DETAIL", "detail"]}
+        }
+      }
+    },
+    "network": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["service", "heartbeat", "fabric"],
+      "properties": {
+        "service": {
+          "type": "object",
+          "additionalProperties": false,
+          "required": ["port"],
+          "properties": {
+            "port": {"type": "integer", "default": 0, "minimum": 1024, "maximum": 65535,
+              "dynamic": false, "description": ""},
+            "addresses": {"type": "array", "default": [], "dynamic": false, "description": "",
+              "items": {"type": "string"}},
+            "access-addresses": {"type": "array", "default": [], "dynamic": false, "description": "",
+              "items": {"type": "string"}},
+            "tls-name": {"type": "string", "default": "", "dynamic": false, "description": ""},
+            "tls-port": {"type": "integer", "default": 0, "minimum": 1024, "maximum": 65535,
+              "dynamic": false, "description": ""}
+          }
+        },
+        "heartbeat": {
+          "type": "object",
+          "additionalProperties": false,
+          "required": ["mode"],
+          "properties": {
+            "mode": {"type": "string", "default": "", "enum": ["mesh", "multicast"], "dynamic": false,
+              "description": ""},
+            "port": {"type": "integer", "default": 0, "minimum": 1024, "maximum": 65535,
+              "dynamic": false, "description": ""},
+            "interval": {"type": "integer", "default": 150, "minimum": 50, "maximum": 600000,
+              "dynamic": true, "description": ""},
+            "mesh-seed-address-ports": {"type": "array", "default": [], "dynamic": false, "description": "",
+              "items": {"type": "string"}},
+            "tls-name": {"type": "string", "default": "", "dynamic": false, "description": ""}
+          }
+        },
+        "fabric": {
+          "type": "object",
+          "additionalProperties": false,
+          "required": ["port"],
+          "properties": {
+            "port": {"type": "integer", "default": 0, "minimum": 1024, "maximum": 65535,
+              "dynamic": false, "description": ""},
+            "tls-name": {"type": "string", "default": "", "dynamic": false, "description": ""}
+          }
+        },
+        "info": {
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "port": {"type": "integer", "default": 0, "minimum": 1024, "maximum": 65535,
+              "dynamic": false, "description": ""}
+          }
+        },
+        "tls": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "additionalProperties": false,
+            "required": ["name"],
+            "properties": {
+              "name": {"type": "string", "default": "", "dynamic": false, "description": ""},
+              "cert-file": {"type": "string", "default": "", "dynamic": false, "description": ""},
+              "key-file": {"type": "string", "default": "", "dynamic": false, "description": ""},
+              "ca-file": {"type": "string", "default": "", "dynamic": false, "description": ""}
+            }
+          }
+        }
+      }
+    },
+    "namespaces": {
+      "type": "array",
+      "minItems": 1,
+      "items": {
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["name", "storage-engine"],
+        "properties": {
+          "name": {"type": "string", "default": " ", "dynamic": false, "description": ""},
+          "replication-factor": {"type": "integer", "default": 2, "minimum": 1, "maximum": 128,
+            "dynamic": false, "description": ""},
+          "default-ttl": {"type": "integer", "default": 0, "minimum": 0, "maximum": 315360000,
+            "dynamic": true, "description": ""},
+          "conflict-resolution-policy": {"type": "string", "default": "generation", "dynamic": true,
+            "description": "", "enum": ["generation", "last-update-time"]},
+          "strong-consistency": {"type": "boolean", "default": false, "dynamic": false, "description": ""},
+          "rack-id": {"type": "integer", "default": 0, "minimum": 0, "maximum": 1000000,
+            "dynamic": false, "description": ""},
+          "memory-size": {"type": "integer", "default": 4294967296, "minimum": 0,
+            "maximum": 18446744073709551615, "dynamic": true, "description": ""},
+          "high-water-memory-pct": {"type": "integer", "default": 0, "minimum": 0, "maximum": 100,
+            "dynamic": true, "description": ""},
+          "high-water-disk-pct": {"type": "integer", "default": 0, "minimum": 0, "maximum": 100,
+            "dynamic": true, "description": ""},
+          "stop-writes-pct": {"type": "integer", "default": 90, "minimum": 0, "maximum": 100,
+            "dynamic": true, "description": ""},
+          "sets": {
+            "type": "array",
+            "items": {
+              "type": "object",
+              "additionalProperties": false,
+              "properties": {
+                "name": {"type": "string", "default": "", "dynamic": false, "description": ""},
+                "disable-eviction": {"type": "boolean", "default": false, "dynamic": true, "description": ""}
+              }
+            }
+          },
+          "storage-engine": {
+            "type": "object",
+            "oneOf": [
+              {
+                "type": "object",
+                "additionalProperties": false,
+                "required": ["type"],
+                "properties": {
+                  "type": {"type": "string", "default": "", "enum": ["memory"], "dynamic": false,
+                    "description": ""}
+                }
+              },
+              {
+                "type": "object",
+                "additionalProperties": false,
+                "required": ["type"],
+                "properties": {
+                  "type": {"type": "string", "default": "", "enum": ["device"], "dynamic": false,
+                    "description": ""},
+                  "devices": {"type": "array", "default": [], "dynamic": false, "description": "",
+                    "items": {"type": "string"}},
+                  "files": {"type": "array", "default": [], "dynamic": false, "description": "",
+                    "items": {"type": "string"}},
+                  "filesize": {"type": "integer", "default": 0, "minimum": 1048576,
+                    "maximum": 2199023255552, "dynamic": false, "description": ""},
+                  "write-block-size": {"type": "integer", "default": 131072, "minimum": 1024,
+                    "maximum": 8388608, "dynamic": false, "description": ""},
+                  "data-in-memory": {"type": "boolean", "default": false, "dynamic": false, "description": ""},
+                  "min-avail-pct": {"type": "integer", "default": 5, "minimum": 0, "maximum": 100,
+                    "dynamic": true, "description": ""},
+                  "max-used-pct": {"type": "integer", "default": 70, "minimum": 0, "maximum": 100,
+                    "dynamic": true, "description": ""}
+                }
+              }
+            ]
+          }
+        }
+      }
+    },
+    "security": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "privilege-refresh-period": {"type": "integer", "default": 300, "minimum": 10, "maximum": 86400,
+          "dynamic": true, "description": ""},
+        "log": {
+          "type": "object",
+          "additionalProperties": false,
+          "properties": {
+            "report-data-op": {"type": "array", "default": [], "dynamic": true, "description": "",
+              "items": {"type": "string"}}
+          }
+        }
+      }
+    },
+    "xdr": {
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "src-id": {"type": "integer", "default": 0, "minimum": 0, "maximum": 255, "dynamic": true,
+          "description": ""},
+        "dcs": {
+          "type": "array",
+          "items": {
+            "type": "object",
+            "additionalProperties": false,
+            "properties": {
+              "name": {"type": "string", "default": "", "dynamic": true, "description": ""},
+              "node-address-ports": {"type": "array", "default": [], "dynamic": true, "description": "",
+                "items": {"type": "string"}},
+              "tls-name": {"type": "string", "default": "", "dynamic": false, "description": ""},
+              "namespaces": {
+                "type": "array",
+                "items": {
+                  "type": "object",
+                  "additionalProperties": false,
+                  "properties": {
+                    "name": {"type": "string", "default": "", "dynamic": true, "description": ""},
+                    "bin-policy": {"type": "string", "default": "all", "dynamic": true, "description": "",
+                      "enum": ["all", "no-bins", "only-changed", "changed-and-specified", "changed-or-specified"]}
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// testSchemas is passed to InitFromMap by the tests using the test schemas.
+var testSchemas = map[string]string{
+	"6.4.0": testSchema640,
+	"7.0.0": testSchema700,
+}
@@ -21,13 +21,19 @@ func setUnsupportedJumps() error {
 // IsValidUpgrade validates fromVersion and toVersion for
 // all upgrade/downgrade restrictions
 func IsValidUpgrade(fromVersion, toVersion string) error {
+	return defaultRegistry.IsValidUpgrade(fromVersion, toVersion)
+}
+
+// IsValidUpgrade validates fromVersion and toVersion, which should both have
+// a schema in the registry, for all upgrade/downgrade restrictions
+func (r *SchemaRegistry) IsValidUpgrade(fromVersion, toVersion string) error {
 	// check version validity
-	valid, err := IsSupportedVersion(fromVersion)
+	valid, err := r.IsSupportedVersion(fromVersion)
 	if !valid || err != nil {
 		return fmt.Errorf("unsupported aerospike version %s", fromVersion)
 	}
 
-	valid, err = IsSupportedVersion(toVersion)
+	valid, err = r.IsSupportedVersion(toVersion)
 	if !valid || err != nil {
 		return fmt.Errorf("unsupported aerospike version %s", toVersion)
 	}
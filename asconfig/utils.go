@@ -116,6 +116,43 @@ func deHumanizeSize(val string) (uint64, error) {
 	return n, nil
 }
 
+// humanizeTime is the reverse of deHumanizeTime, it returns the value with
+// the largest unit dividing it, eg. 2592000 -> 30d.
+func humanizeTime(val uint64) string {
+	return humanizeUnits(val, []humanUnit{
+		{"d", 24 * 60 * 60},
+		{"h", 60 * 60},
+		{"m", 60},
+	})
+}
+
+// humanizeSize is the reverse of deHumanizeSize, it returns the value with
+// the largest unit dividing it, eg. 4294967296 -> 4G.
+func humanizeSize(val uint64) string {
+	return humanizeUnits(val, []humanUnit{
+		{"P", 1024 * 1024 * 1024 * 1024 * 1024},
+		{"T", 1024 * 1024 * 1024 * 1024},
+		{"G", 1024 * 1024 * 1024},
+		{"M", 1024 * 1024},
+		{"K", 1024},
+	})
+}
+
+type humanUnit struct {
+	suffix     string
+	multiplier uint64
+}
+
+func humanizeUnits(val uint64, units []humanUnit) string {
+	for _, unit := range units {
+		if val != 0 && val%unit.multiplier == 0 {
+			return strconv.FormatUint(val/unit.multiplier, 10) + unit.suffix
+		}
+	}
+
+	return strconv.FormatUint(val, 10)
+}
+
 // expandConf expands map with flat keys (with sep) to Conf
 func expandConf(log logr.Logger, input *Conf, sep string) Conf { //nolint:unparam // We should think about removing the arg 'sep'
 	m := expandConfMap(log, input, sep)
@@ -492,8 +529,18 @@ func getSystemProperty(log logr.Logger, c Conf, key string) (
 	switch baseKey {
 	// device <deviceName>:<shadowDeviceName>
 	case keyDevice:
-		for _, d := range c[key].([]interface{}) {
-			value = append(value, strings.Split(d.(string), colon)...)
+		devices, ok := c[key].([]string)
+		if !ok {
+			for _, d := range c[key].([]interface{}) {
+				devices = append(devices, d.(string))
+			}
+		}
+
+		for _, d := range devices {
+			// shadow device separated by ":" or by a space in aerospike.conf
+			value = append(value, strings.FieldsFunc(d, func(r rune) bool {
+				return r == ':' || r == ' '
+			})...)
 		}
 
 		return DEVICE, value
@@ -501,8 +548,11 @@ func getSystemProperty(log logr.Logger, c Conf, key string) (
 	// file <filename>
 	// feature-key-file <filename>
 	// work-directory <direname>
+	// cert-file, key-file, ca-file <filename>
+	// ca-path <dirname>
 	// FIXME FIXME add logging file ...
-	case keyFile, keyFeatureKeyFile, "work-directory", "system-path", "user-path":
+	case keyFile, keyFeatureKeyFile, "work-directory", "system-path", "user-path",
+		"cert-file", "key-file", "ca-file", "ca-path":
 		v := c[key]
 		switch v := v.(type) {
 		case string:
@@ -742,24 +792,54 @@ func isNodeSpecificContext(key string) bool {
 }
 
 func isSizeOrTime(key string) (bool, humanize) {
+	if isTimeField(key) {
+		return true, deHumanizeTime
+	}
+
+	if isByteSizeField(key) || isSizeCountField(key) {
+		return true, deHumanizeSize
+	}
+
+	return false, nil
+}
+
+// isByteSizeField returns true for the fields which are sizes in bytes and can
+// be written with a size unit, eg. 4G.
+func isByteSizeField(key string) bool {
+	switch key {
+	case "memory-size", "filesize", "write-block-size", "max-write-cache",
+		"mounts-size-limit", "index-stage-size", "stop-writes-size",
+		"mounts-budget", "data-size", "flush-size", "post-write-cache",
+		"indexes-memory-budget", "sindex-stage-size", "max-record-size":
+		return true
+
+	default:
+		return false
+	}
+}
+
+// isSizeCountField returns true for the fields which are counts, but can be
+// written with a size unit, eg. stop-writes-count 4M.
+func isSizeCountField(key string) bool {
+	switch key {
+	case "partition-tree-sprigs", "stop-writes-count", "quarantine-allocations":
+		return true
+
+	default:
+		return false
+	}
+}
+
+// isTimeField returns true for the fields which can be written with a time unit, eg. 30d.
+func isTimeField(key string) bool {
 	switch key {
 	case "default-ttl", "tomb-raider-eligible-age",
 		"tomb-raider-period", "nsup-period", "migrate-fill-delay",
 		"tls-refresh-period", "ship-versions-interval", "mrt-duration":
-		return true, deHumanizeTime
-
-	case "memory-size", "filesize", "write-block-size",
-		"partition-tree-sprigs", "max-write-cache",
-		"mounts-size-limit", "index-stage-size",
-		"stop-writes-count", "stop-writes-size",
-		"mounts-budget", "data-size",
-		"quarantine-allocations", "flush-size",
-		"post-write-cache", "indexes-memory-budget",
-		"sindex-stage-size", "max-record-size":
-		return true, deHumanizeSize
+		return true
 
 	default:
-		return false, nil
+		return false
 	}
 }
 
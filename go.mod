@@ -3,6 +3,7 @@ module github.com/aerospike/aerospike-management-lib
 go 1.25.8
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/aerospike/aerospike-client-go/v8 v8.7.0
 	github.com/deckarep/golang-set/v2 v2.9.0
 	github.com/docker/docker v28.5.2+incompatible